@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/AureClai/RayTracingGoTest/denoise"
+	"github.com/AureClai/RayTracingGoTest/output"
+)
+
+// runDenoise denoise an existing image with its AOVs
+func runDenoise(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("denoise", "<image>", "Denoise an image rendered with -aovs: an OpenEXR file with the AOVs in its\nchannels, or a PFM with the PFM AOVs next to it (image_albedo.pfm...).", stderr)
+	var (
+		outputPath string
+		formatName string
+		strength   float64
+		workers    int
+		exposure   float64
+		toneMapper string
+	)
+	fs.StringVar(&outputPath, "o", "outputImage.png", "path of the denoised image")
+	fs.StringVar(&formatName, "format", "auto", "format of the image: png, ppm (binary P6), p3 (ASCII), the linear hdr, pfm and exr, or auto to choose from the extension")
+	fs.Float64Var(&strength, "strength", 1, "strength of the filter, more is smoother")
+	fs.IntVar(&workers, "threads", 0, "number of goroutines filtering the image, 0 to use all the cores")
+	fs.Float64Var(&exposure, "exposure", 0, "number of stops the radiance is brightened (> 0) or darkened (< 0) by before the tone mapping of the 8 bits formats")
+	fs.StringVar(&toneMapper, "tonemap", "clamp", "tone mapping of the 8 bits formats: clamp, reinhard, reinhard-extended, aces or hable")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	switch {
+	case strength <= 0:
+		return fmt.Errorf("invalid denoising strength %v, it must be positive", strength)
+	case workers < 0:
+		return fmt.Errorf("invalid number of threads %v, it must be positive or 0 for all the cores", workers)
+	}
+	format, err := output.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+	toneMapping, err := parseToneMapping(toneMapper, exposure)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	fb, aovs, err := output.ReadFileWithAOVs(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fb = denoise.Denoise(fb, aovs, denoise.Options{Strength: strength, Workers: workers})
+	if err := output.WriteFile(outputPath, fb, format, toneMapping); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Denoised in %v\n", time.Since(start))
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"text/tabwriter"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/scenes"
+)
+
+// runListScenes print the names and descriptions of the built-in scenes
+func runListScenes(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("list-scenes", "", "List the built-in scenes.", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	entries := scenes.List()
+	for i := 0; i < len(entries); i++ {
+		fmt.Fprintf(tw, "%v\t%v\n", entries[i].Name, entries[i].Description)
+	}
+	return tw.Flush()
+}
+
+// runInfo describe a scene and the machine rendering it
+func runInfo(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("info", "", "Describe a scene: its objects, lights and camera, and the machine.", stderr)
+	scene := addSceneFlags(fs)
+	var settings render.Settings
+	fs.IntVar(&settings.Width, "width", 400, "number of pixels on the X axis, which sets the aspect of the camera")
+	fs.IntVar(&settings.Height, "height", 400, "number of pixels on the Y axis, which sets the aspect of the camera")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	settings.Samples = 1
+	sc, err := scene.build(fs, &settings)
+	if err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "scene\t%v\n", scene.name)
+	if scene.description != "" {
+		fmt.Fprintf(tw, "description\t%v\n", scene.description)
+	}
+	fmt.Fprintf(tw, "objects\t%v\n", count(sc.Objects))
+	fmt.Fprintf(tw, "lights\t%v\n", count(sc.Lights))
+	var box geom.Aabb
+	if sc.Objects.BoundingBox(sc.Camera.Time0, sc.Camera.Time1, &box) {
+		fmt.Fprintf(tw, "bounds\t%v to %v\n", formatVec(box.Min()), formatVec(box.Max()))
+	}
+	// the screen is at the focus distance in front of the camera
+	cam := sc.Camera
+	center := cam.LowerLeftCorner.Plus(cam.Horizontal.TimesScalar(0.5)).Plus(cam.Vertical.TimesScalar(0.5))
+	vfov := 2 * math.Atan(cam.Vertical.Length()/2/center.Minus(cam.Origin).Length()) * 180 / math.Pi
+	fmt.Fprintf(tw, "camera\tat %v looking toward %v\n", formatVec(cam.Origin), formatVec(cam.W))
+	fmt.Fprintf(tw, "field of view\t%.4g° vertical, aperture %.4g\n", vfov, 2*cam.LensRadius)
+	fmt.Fprintf(tw, "cores\t%v\n", runtime.NumCPU())
+	fmt.Fprintf(tw, "go\t%v %v/%v\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return tw.Flush()
+}
+
+// count return the number of objects of a hitable, the lists counting their
+// elements
+func count(h geom.Hitable) int {
+	if h == nil {
+		return 0
+	}
+	if l, ok := h.(*geom.HitableList); ok {
+		return l.Len()
+	}
+	return 1
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AureClai/RayTracingGoTest/denoise"
+	"github.com/AureClai/RayTracingGoTest/output"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/scenes"
+)
+
+// sceneFlags are the flags selecting the scene
+type sceneFlags struct {
+	name      string
+	leftWall  colorFlag
+	rightWall colorFlag
+	// description is the description of the scene once built
+	description string
+}
+
+// runRender render a scene into an image
+func runRender(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("render", "", "Render a scene into an image.", stderr)
+	var (
+		settings     render.Settings
+		scene        = addSceneFlags(fs)
+		outputPath   string
+		formatName   string
+		filterName   string
+		filterRadius float64
+		exposure     float64
+		toneMapper   string
+		timeBudget   time.Duration
+		noiseTarget  float64
+		adaptive     float64
+		heatmap      string
+		aovs         bool
+		strength     float64
+	)
+	fs.IntVar(&settings.Width, "width", 400, "number of pixels on the X axis")
+	fs.IntVar(&settings.Height, "height", 400, "number of pixels on the Y axis")
+	fs.IntVar(&settings.Samples, "spp", 100, "number of samples per pixel: 10 for a first test, 100 for a pretty good render, 1000 for a very good one")
+	fs.IntVar(&settings.MaxDepth, "max-depth", 50, "maximum number of bounces of a path, paths are also ended randomly after 5 bounces depending on how much light they still carry")
+	fs.Uint64Var(&settings.Seed, "seed", 0, "seed of the random numbers, same seed same image")
+	fs.IntVar(&settings.Workers, "threads", 0, "number of goroutines rendering the image, 0 to use all the cores")
+	fs.StringVar(&outputPath, "o", "outputImage.png", "path of the image")
+	fs.StringVar(&formatName, "format", "auto", "format of the images: png, ppm (binary P6), p3 (ASCII), the linear hdr, pfm and exr, or auto to choose from the extension")
+	fs.StringVar(&filterName, "filter", "box", "pixel reconstruction filter: box, tent, gaussian, mitchell or lanczos")
+	fs.Float64Var(&filterRadius, "filter-radius", 0, "radius of the filter in pixels, 0 for the default radius of the filter")
+	fs.Float64Var(&exposure, "exposure", 0, "number of stops the radiance is brightened (> 0) or darkened (< 0) by before the tone mapping of the 8 bits formats")
+	fs.StringVar(&toneMapper, "tonemap", "clamp", "tone mapping of the 8 bits formats: clamp, reinhard, reinhard-extended, aces or hable")
+	fs.DurationVar(&timeBudget, "time-budget", 0, "progressive render: passes of a few samples are accumulated until the time budget is spent, the image being written every 10 seconds, with no limit of samples unless -spp is set")
+	fs.Float64Var(&noiseTarget, "noise-target", 0, "progressive render until the estimated noise is under the target (0.01 is pretty clean), with no limit of samples unless -spp is set")
+	fs.Float64Var(&adaptive, "adaptive", 0, "adaptive sampling: pixels stop being sampled once the 95% confidence interval of their luminance is narrower than this fraction of their value (0.05 is a good start)")
+	fs.StringVar(&heatmap, "heatmap", "", "path of the heatmap of the samples of the adaptive sampling, next to the image by default")
+	fs.BoolVar(&aovs, "aovs", false, "also write the normal, position, depth, albedo, uv, object and material IDs of the first hits, as layers of an OpenEXR image or files next to the image")
+	fs.Float64Var(&strength, "denoise", 0, "denoise the image with this strength (1 is a good start, more is smoother), the noisy image being also written next to it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	format, err := output.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+	if settings.Filter, err = render.ParseFilter(filterName, filterRadius); err != nil {
+		return err
+	}
+	toneMapping, err := parseToneMapping(toneMapper, exposure)
+	if err != nil {
+		return err
+	}
+	switch {
+	case timeBudget < 0:
+		return fmt.Errorf("invalid time budget %v, it must be positive", timeBudget)
+	case noiseTarget < 0:
+		return fmt.Errorf("invalid noise target %v, it must be positive", noiseTarget)
+	case adaptive < 0:
+		return fmt.Errorf("invalid adaptive threshold %v, it must be positive", adaptive)
+	case adaptive > 0 && (timeBudget > 0 || noiseTarget > 0):
+		return fmt.Errorf("the adaptive sampling can not be combined with a progressive render")
+	case strength < 0:
+		return fmt.Errorf("invalid denoising strength %v, it must be positive", strength)
+	}
+	if heatmap == "" {
+		heatmap = output.AOVPath(outputPath, "samples")
+	}
+	settings.AOVs = aovs || strength > 0
+	sc, err := scene.build(fs, &settings)
+	if err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	renderer := render.NewRenderer(sc)
+	renderer.Progress = func(done, total int) {
+		fmt.Fprintf(stdout, "\r%5.2f %%", 100.0*float64(done)/float64(total))
+	}
+	var fb *render.Framebuffer
+	if adaptive > 0 {
+		var samples *render.Framebuffer
+		fb, samples = renderer.RenderAdaptive(render.AdaptiveOptions{Threshold: adaptive})
+		fmt.Fprintln(stdout)
+		if err := output.WriteFile(heatmap, samples, format, output.ToneMapping{}); err != nil {
+			return err
+		}
+	} else if timeBudget > 0 || noiseTarget > 0 {
+		// progressive render, the image is rewritten between passes. The
+		// number of samples only limits it when it is given.
+		// The percentage of each pass would start again at 0, the snapshots
+		// report the progress instead.
+		renderer.Progress = nil
+		maxSamples := -1
+		fs.Visit(func(fl *flag.Flag) {
+			if fl.Name == "spp" {
+				maxSamples = settings.Samples
+			}
+		})
+		var snapshotErr error
+		fb = renderer.RenderProgressive(render.ProgressiveOptions{
+			MaxSamples:       maxSamples,
+			TimeBudget:       timeBudget,
+			NoiseTarget:      noiseTarget,
+			SnapshotInterval: 10 * time.Second,
+			Snapshot: func(fb *render.Framebuffer, status render.ProgressiveStatus) {
+				fmt.Fprintf(stdout, "pass %v, %v samples in %v, noise %.4f\n", status.Pass, status.Samples, status.Elapsed.Round(time.Millisecond), status.Noise)
+				if !status.Done && snapshotErr == nil {
+					snapshotErr = output.WriteFile(outputPath, fb, format, toneMapping)
+				}
+			},
+		})
+		if snapshotErr != nil {
+			return snapshotErr
+		}
+	} else {
+		fb = renderer.Render()
+		fmt.Fprintln(stdout)
+	}
+
+	// the image is written from the film of the render, or denoised
+	var img render.Image = renderer.Film()
+	if strength > 0 {
+		if err := output.WriteFile(output.AOVPath(outputPath, "noisy"), img, format, toneMapping); err != nil {
+			return err
+		}
+		img = denoise.Denoise(fb, renderer.AOVs(), denoise.Options{Strength: strength, Workers: settings.Workers})
+	}
+	if aovs {
+		err = output.WriteFileWithAOVs(outputPath, img, renderer.AOVs(), format, toneMapping)
+	} else {
+		err = output.WriteFile(outputPath, img, format, toneMapping)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Calculations made in %v\n", time.Since(start))
+	fmt.Fprintf(stdout, "%v written successfully\n", outputPath)
+	return nil
+}
+
+// parseToneMapping return the tone mapping of the operator of the given name
+// and the exposure
+func parseToneMapping(name string, exposure float64) (output.ToneMapping, error) {
+	tm, err := output.ParseToneMapper(name)
+	if err != nil {
+		return output.ToneMapping{}, err
+	}
+	return output.ToneMapping{Operator: tm, Exposure: exposure}, nil
+}
+
+// addSceneFlags register the flags selecting the scene
+func addSceneFlags(fs *flag.FlagSet) *sceneFlags {
+	sf := &sceneFlags{}
+	fs.StringVar(&sf.name, "scene", "cornell", "name of a built-in scene (see list-scenes) or path of a JSON or glTF scene file, whose settings are used unless they are set by the flags")
+	fs.Var(&sf.leftWall, "left-wall", "color r,g,b of the left wall of the cornell scene, components between 0 and 1 (default 0.65,0.05,0.05)")
+	fs.Var(&sf.rightWall, "right-wall", "color r,g,b of the right wall of the cornell scene, components between 0 and 1 (default 0.45,0.45,0.45, 0.12,0.45,0.15 for the green of the classic box)")
+	return sf
+}
+
+// isSceneFile return true if the scene flag is the path of a scene file
+// rather than the name of a built-in scene
+func isSceneFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".json" || ext == ".gltf" || ext == ".glb"
+}
+
+// build return the selected scene. The settings of a scene file replace the
+// settings, except the ones set by the flags of the command line.
+func (sf *sceneFlags) build(fs *flag.FlagSet, settings *render.Settings) (*render.Scene, error) {
+	if sf.name == "cornell" {
+		sf.description = scenes.CornellDescription
+		left, right := scenes.DefaultLeftWall, scenes.DefaultRightWall
+		if sf.leftWall.set {
+			left = sf.leftWall.color
+		}
+		if sf.rightWall.set {
+			right = sf.rightWall.color
+		}
+		return scenes.CornellBox(settings, left, right), nil
+	}
+	if sf.leftWall.set || sf.rightWall.set {
+		return nil, fmt.Errorf("the wall colors only apply to the cornell scene")
+	}
+	if isSceneFile(sf.name) {
+		f, err := scenes.LoadFile(sf.name)
+		if err != nil {
+			return nil, err
+		}
+		sf.description = f.Description
+		set := map[string]bool{}
+		fs.Visit(func(fl *flag.Flag) {
+			set[fl.Name] = true
+		})
+		s := f.Settings
+		if set["width"] {
+			s.Width = settings.Width
+		}
+		if set["height"] {
+			s.Height = settings.Height
+		}
+		if set["spp"] {
+			s.Samples = settings.Samples
+		}
+		if set["max-depth"] {
+			s.MaxDepth = settings.MaxDepth
+		}
+		if set["seed"] {
+			s.Seed = settings.Seed
+		}
+		if set["filter"] || set["filter-radius"] {
+			s.Filter = settings.Filter
+		}
+		s.Workers = settings.Workers
+		s.AOVs = settings.AOVs
+		*settings = s
+		return f.Scene(settings), nil
+	}
+	e, ok := scenes.Lookup(sf.name)
+	if !ok {
+		return nil, fmt.Errorf("unknown scene %q, see list-scenes", sf.name)
+	}
+	sf.description = e.Description
+	return e.Build(settings), nil
+}
@@ -0,0 +1,223 @@
+// Package denoise removes the noise of low-sample renders with an
+// edge-avoiding à-trous wavelet filter (Dammertz et al. 2010) guided by the
+// AOVs of the render.
+package denoise
+
+import (
+	"math"
+	"runtime"
+	"sync"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// default values of Options
+const (
+	DefaultIterations  = 4
+	DefaultColorSigma  = 1.0
+	DefaultNormalSigma = 0.3
+	DefaultDepthSigma  = 0.02
+	DefaultAlbedoSigma = 0.1
+)
+
+// minAlbedo is the albedo under which the color is not divided by it
+const minAlbedo = 0.01
+
+// kernel is the 5 taps B3 spline of the à-trous transform
+var kernel = [5]float64{1.0 / 16, 1.0 / 4, 3.0 / 8, 1.0 / 4, 1.0 / 16}
+
+// Options are the parameters of Denoise
+type Options struct {
+	// Strength scales the color differences tolerated between the pixels
+	// averaged : 1 is the default, above smooths more, under keeps more
+	// details and noise. 0 means 1.
+	Strength float64
+	// Iterations is the number of passes, each one doubling the radius of
+	// the filter, 0 means DefaultIterations
+	Iterations int
+	// NormalSigma, DepthSigma and AlbedoSigma are the differences of the
+	// features under which pixels are averaged, the depth difference being
+	// relative to the depth. 0 means the default values.
+	NormalSigma float64
+	DepthSigma  float64
+	AlbedoSigma float64
+	// Workers is the number of goroutines filtering rows, 0 means
+	// GOMAXPROCS
+	Workers int
+}
+
+// features are the guides of the filter, the missing ones being nil
+type features struct {
+	albedo []geom.Vec3
+	normal []geom.Vec3
+	depth  []geom.Vec3
+}
+
+// Denoise filter the noise of an image. The albedo, normal and depth AOVs of
+// the render, when they are not nil, keep the edges and textures : the color
+// is divided by the albedo before filtering and only pixels with close
+// features are averaged.
+func Denoise(fb *render.Framebuffer, aovs *render.AOVs, opts Options) *render.Framebuffer {
+	strength := opts.Strength
+	if strength <= 0 {
+		strength = 1
+	}
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var feat features
+	if aovs != nil {
+		feat.albedo = pixels(aovs.Albedo, fb)
+		feat.normal = pixels(aovs.Normal, fb)
+		feat.depth = pixels(aovs.Depth, fb)
+	}
+
+	// the filter works on the lighting, the texture is put back at the end
+	n := fb.Width * fb.Height
+	cur := make([]geom.Vec3, n)
+	for i := 0; i < n; i++ {
+		cur[i] = fb.Pix[i]
+		if feat.albedo != nil {
+			cur[i] = cur[i].By(modulation(feat.albedo[i]))
+		}
+	}
+	next := make([]geom.Vec3, n)
+	f := filter{
+		width:       fb.Width,
+		height:      fb.Height,
+		feat:        feat,
+		normalSigma: orDefault(opts.NormalSigma, DefaultNormalSigma),
+		depthSigma:  orDefault(opts.DepthSigma, DefaultDepthSigma),
+		albedoSigma: orDefault(opts.AlbedoSigma, DefaultAlbedoSigma),
+	}
+	for it := 0; it < iterations; it++ {
+		f.step = 1 << it
+		// the color tolerance is halved at each pass as the noise decreases
+		f.colorSigma = strength * DefaultColorSigma / float64(int(1)<<it)
+		f.run(cur, next, workers)
+		cur, next = next, cur
+	}
+
+	res := render.NewFramebuffer(fb.Width, fb.Height)
+	for i := 0; i < n; i++ {
+		res.Pix[i] = cur[i]
+		if feat.albedo != nil {
+			res.Pix[i] = res.Pix[i].Times(modulation(feat.albedo[i]))
+		}
+	}
+	return res
+}
+
+// pixels return the pixels of an AOV, nil if it is missing or not of the size
+// of the image
+func pixels(aov, fb *render.Framebuffer) []geom.Vec3 {
+	if aov == nil || aov.Width != fb.Width || aov.Height != fb.Height {
+		return nil
+	}
+	return aov.Pix
+}
+
+func orDefault(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// modulation return the albedo the lighting is multiplied by, 1 for the
+// components too dark to be divided by
+func modulation(albedo geom.Vec3) geom.Vec3 {
+	var m geom.Vec3
+	for c := 0; c < 3; c++ {
+		m.SetAt(c, 1)
+		if albedo.At(c) >= minAlbedo {
+			m.SetAt(c, albedo.At(c))
+		}
+	}
+	return m
+}
+
+// filter is one pass of the à-trous transform
+type filter struct {
+	width       int
+	height      int
+	feat        features
+	step        int
+	colorSigma  float64
+	normalSigma float64
+	depthSigma  float64
+	albedoSigma float64
+}
+
+// run filter src into dst, the rows being shared between the workers
+func (f *filter) run(src, dst []geom.Vec3, workers int) {
+	var wg sync.WaitGroup
+	rows := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < f.width; x++ {
+					dst[y*f.width+x] = f.pixel(src, x, y)
+				}
+			}
+		}()
+	}
+	for y := 0; y < f.height; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+}
+
+// pixel return the filtered value of the pixel (x, y)
+func (f *filter) pixel(src []geom.Vec3, x, y int) geom.Vec3 {
+	p := y*f.width + x
+	cp := compress(src[p])
+	sum := geom.NewVec3(0, 0, 0)
+	weightSum := 0.0
+	for j := 0; j < 5; j++ {
+		qy := y + (j-2)*f.step
+		if qy < 0 || qy >= f.height {
+			continue
+		}
+		for i := 0; i < 5; i++ {
+			qx := x + (i-2)*f.step
+			if qx < 0 || qx >= f.width {
+				continue
+			}
+			q := qy*f.width + qx
+			dist := compress(src[q]).Minus(cp).SquaredLength() / (f.colorSigma * f.colorSigma)
+			if f.feat.normal != nil {
+				dist += f.feat.normal[q].Minus(f.feat.normal[p]).SquaredLength() / (f.normalSigma * f.normalSigma)
+			}
+			if f.feat.depth != nil {
+				dp := f.feat.depth[p].X()
+				dq := f.feat.depth[q].X()
+				// the depth varies along the surfaces, more so with a larger step
+				dist += math.Abs(dq-dp) / (f.depthSigma * float64(f.step) * math.Max(dp, 1e-3))
+			}
+			if f.feat.albedo != nil {
+				dist += f.feat.albedo[q].Minus(f.feat.albedo[p]).SquaredLength() / (f.albedoSigma * f.albedoSigma)
+			}
+			w := kernel[i] * kernel[j] * math.Exp(-dist)
+			sum = sum.Plus(src[q].TimesScalar(w))
+			weightSum += w
+		}
+	}
+	// the center pixel always has a weight
+	return sum.ByScalar(weightSum)
+}
+
+// compress map each component c to c / (1 + c) so that the color differences
+// of the highlights do not outweigh the others
+func compress(c geom.Vec3) geom.Vec3 {
+	return geom.NewVec3(c.X()/(1+c.X()), c.Y()/(1+c.Y()), c.Z()/(1+c.Z()))
+}
@@ -0,0 +1,101 @@
+package denoise
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+const testWidth, testHeight = 16, 12
+
+// halves return a framebuffer of the test size whose left half is left and
+// right half is right
+func halves(left, right geom.Vec3) *render.Framebuffer {
+	fb := render.NewFramebuffer(testWidth, testHeight)
+	for y := 0; y < testHeight; y++ {
+		for x := 0; x < testWidth; x++ {
+			if x < testWidth/2 {
+				fb.Set(x, y, left)
+			} else {
+				fb.Set(x, y, right)
+			}
+		}
+	}
+	return fb
+}
+
+func TestDenoiseFlat(t *testing.T) {
+	grey := geom.NewVec3(0.3, 0.4, 0.5)
+	fb := halves(grey, grey)
+	aovs := &render.AOVs{
+		Albedo: halves(geom.NewVec3(0.5, 0.5, 0.5), geom.NewVec3(0.5, 0.5, 0.5)),
+		Normal: halves(geom.NewVec3(0, 1, 0), geom.NewVec3(0, 1, 0)),
+		Depth:  halves(geom.NewVec3(2, 2, 2), geom.NewVec3(2, 2, 2)),
+	}
+	for _, guide := range []*render.AOVs{nil, aovs} {
+		res := Denoise(fb, guide, Options{Workers: 3})
+		for i := 0; i < len(res.Pix); i++ {
+			if res.Pix[i].Minus(grey).Length() > 1e-12 {
+				t.Fatalf("pixel %v of a flat image becomes %v with the AOVs %v", i, res.Pix[i], guide != nil)
+			}
+		}
+	}
+}
+
+func TestDenoiseEdges(t *testing.T) {
+	dark, light := geom.NewVec3(0.1, 0.1, 0.1), geom.NewVec3(0.9, 0.9, 0.9)
+	fb := halves(dark, light)
+	// the pixels on both sides of the edge
+	left, right := 5*testWidth+testWidth/2-1, 5*testWidth+testWidth/2
+	unguided := Denoise(fb, nil, Options{})
+	if unguided.Pix[left].Minus(dark).Length() < 0.01 {
+		t.Fatalf("the edge is kept without guide, the test is not conclusive")
+	}
+
+	guides := map[string]*render.AOVs{
+		"normal": {Normal: halves(geom.NewVec3(1, 0, 0), geom.NewVec3(0, 1, 0))},
+		// the lighting is the same on both sides, only the texture changes
+		"albedo": {Albedo: halves(dark, light)},
+	}
+	for name, aovs := range guides {
+		res := Denoise(fb, aovs, Options{})
+		if res.Pix[left].Minus(dark).Length() > 1e-3 || res.Pix[right].Minus(light).Length() > 1e-3 {
+			t.Errorf("the %v guide turns the edge %v %v into %v %v", name, dark, light, res.Pix[left], res.Pix[right])
+		}
+	}
+}
+
+func TestDenoiseNoise(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	fb := render.NewFramebuffer(testWidth, testHeight)
+	for i := 0; i < len(fb.Pix); i++ {
+		v := 0.5 + 0.4*(rnd.Float64()-0.5)
+		fb.Pix[i] = geom.NewVec3(v, v, v)
+	}
+	mean, variance := stats(fb)
+	res := Denoise(fb, nil, Options{})
+	resMean, resVariance := stats(res)
+	if resVariance > variance/10 {
+		t.Errorf("the variance goes from %v to %v", variance, resVariance)
+	}
+	if math.Abs(resMean-mean) > 0.01 {
+		t.Errorf("the mean goes from %v to %v", mean, resMean)
+	}
+}
+
+// stats return the mean and the variance of the red component of the pixels
+func stats(fb *render.Framebuffer) (float64, float64) {
+	mean := 0.0
+	for i := 0; i < len(fb.Pix); i++ {
+		mean += fb.Pix[i].X()
+	}
+	mean /= float64(len(fb.Pix))
+	variance := 0.0
+	for i := 0; i < len(fb.Pix); i++ {
+		variance += (fb.Pix[i].X() - mean) * (fb.Pix[i].X() - mean)
+	}
+	return mean, variance / float64(len(fb.Pix))
+}
@@ -1,12 +1,13 @@
 package geometry
 
 type Box struct {
-	PMin    *Vec3
-	PMax    *Vec3
+	PMin    Vec3
+	PMax    Vec3
+	Mat     Material
 	ListPtr *HitableList
 }
 
-func NewBox(p0, p1 *Vec3, ptr Material) *Box {
+func NewBox(p0, p1 Vec3, ptr Material) *Box {
 	list := make([]Hitable, 6)
 	list[0] = NewXYRect(p0.X(), p1.X(), p0.Y(), p1.Y(), p1.Z(), ptr)
 	list[1] = NewFlipNormals(NewXYRect(p0.X(), p1.X(), p0.Y(), p1.Y(), p0.Z(), ptr))
@@ -17,23 +18,24 @@ func NewBox(p0, p1 *Vec3, ptr Material) *Box {
 	return &Box{
 		PMin:    p0,
 		PMax:    p1,
+		Mat:     ptr,
 		ListPtr: NewHitableList(&list, 6),
 	}
 }
 
-func (bx Box) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	*box = *NewAabb(bx.PMin, bx.PMax)
+func (bx *Box) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	*box = NewAabb(bx.PMin, bx.PMax)
 	return true
 }
 
-func (bx Box) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
+func (bx *Box) Hit(r Ray, t0, t1 float64, rec *HitRecord) bool {
 	return bx.ListPtr.Hit(r, t0, t1, rec)
 }
 
-func (bx Box) PdfValue(o, v *Vec3) float64 {
+func (bx *Box) PdfValue(o, v Vec3) float64 {
 	return 0.0
 }
 
-func (bx Box) Random(o *Vec3) *Vec3 {
+func (bx *Box) Random(o Vec3, s *Sampler) Vec3 {
 	return NewVec3(1, 0, 0)
 }
@@ -0,0 +1,250 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// BVHStrategy selects how NewBVH splits the objects of a node
+type BVHStrategy int
+
+const (
+	// BVHMedian splits the objects in two halves along the axis where their
+	// centers are the most spread
+	BVHMedian BVHStrategy = iota
+	// BVHSAH chooses the axis and the split position minimizing the Surface
+	// Area Heuristic cost, evaluated on buckets of object centers
+	BVHSAH
+)
+
+// BVHOptions are the parameters of NewBVH
+type BVHOptions struct {
+	Strategy BVHStrategy
+	// LeafSize is the maximum number of objects in a leaf, 0 means 1.
+	// With BVHMedian the nodes of LeafSize objects or less are leaves, with
+	// BVHSAH they are only split when it is cheaper than a leaf.
+	LeafSize int
+	// Buckets is the number of buckets per axis used by BVHSAH, 0 means 12
+	Buckets int
+}
+
+// the relative costs used by the Surface Area Heuristic
+const (
+	sahTraversalCost    = 0.125
+	sahIntersectionCost = 1.0
+)
+
+// bvhPrimitive is an object being sorted in the tree with its bounds
+type bvhPrimitive struct {
+	hitable  Hitable
+	box      Aabb
+	centroid Vec3
+}
+
+// NewBVH build a bounding volume hierarchy over the list with the given
+// options. Interior nodes are *BVHNode, leaves are either the object itself or
+// a *HitableList when they hold several objects.
+func NewBVH(l *HitableList, time0, time1 float64, opts BVHOptions) Hitable {
+	if l.listSize < 1 {
+		panic("empty list in bvh constructor")
+	}
+	if opts.LeafSize < 1 {
+		opts.LeafSize = 1
+	}
+	if opts.Buckets < 2 {
+		opts.Buckets = 12
+	}
+	prims := make([]bvhPrimitive, l.listSize)
+	for i := 0; i < l.listSize; i++ {
+		prims[i].hitable = l.list[i]
+		if !l.list[i].BoundingBox(time0, time1, &prims[i].box) {
+			panic("no bounding box in bvh_node constructor")
+		}
+		prims[i].centroid = prims[i].box.Centroid()
+	}
+	return buildBVH(prims, &opts)
+}
+
+// buildBVH recursively build the node over prims
+func buildBVH(prims []bvhPrimitive, opts *BVHOptions) Hitable {
+	box := prims[0].box
+	cbMin := prims[0].centroid
+	cbMax := prims[0].centroid
+	for i := 1; i < len(prims); i++ {
+		box = SurroundingBox(box, prims[i].box)
+		for a := 0; a < 3; a++ {
+			cbMin.SetAt(a, Fmin(cbMin.At(a), prims[i].centroid.At(a)))
+			cbMax.SetAt(a, Fmax(cbMax.At(a), prims[i].centroid.At(a)))
+		}
+	}
+	// axis along which the centers are the most spread
+	extent := cbMax.Minus(cbMin)
+	axis := 0
+	for a := 1; a < 3; a++ {
+		if extent.At(a) > extent.At(axis) {
+			axis = a
+		}
+	}
+	// all the centers at the same place, nothing to split
+	if len(prims) == 1 || extent.At(axis) == 0 {
+		return newBVHLeaf(prims)
+	}
+
+	mid := len(prims) / 2
+	if opts.Strategy == BVHSAH {
+		var ok bool
+		axis, mid, ok = sahSplit(prims, box, cbMin, cbMax, axis, opts)
+		if !ok {
+			return newBVHLeaf(prims)
+		}
+	} else {
+		if len(prims) <= opts.LeafSize {
+			return newBVHLeaf(prims)
+		}
+		sortByCentroid(prims, axis)
+	}
+	return &BVHNode{
+		Left:  buildBVH(prims[:mid], opts),
+		Right: buildBVH(prims[mid:], opts),
+		Box:   box,
+		Axis:  axis,
+	}
+}
+
+// sahSplit find the cheapest split of prims on the buckets of the three axes
+// and partition prims accordingly. It returns false when a leaf is cheaper
+// and the leaf size allows it. widest is the axis along which the centers
+// are the most spread, split at the median when the buckets can not
+// separate them.
+func sahSplit(prims []bvhPrimitive, box Aabb, cbMin, cbMax Vec3, widest int, opts *BVHOptions) (int, int, bool) {
+	nBuckets := opts.Buckets
+	counts := make([]int, nBuckets)
+	bounds := make([]Aabb, nBuckets)
+	// areas of the boxes on the left / right of each split position
+	leftArea := make([]float64, nBuckets-1)
+	rightArea := make([]float64, nBuckets-1)
+	leftCount := make([]int, nBuckets-1)
+
+	bestCost := math.MaxFloat64
+	bestAxis := -1
+	bestSplit := 0
+	for axis := 0; axis < 3; axis++ {
+		lo := cbMin.At(axis)
+		hi := cbMax.At(axis)
+		if hi <= lo {
+			continue
+		}
+		for b := 0; b < nBuckets; b++ {
+			counts[b] = 0
+		}
+		for i := range prims {
+			b := bucketOf(prims[i].centroid.At(axis), lo, hi, nBuckets)
+			if counts[b] == 0 {
+				bounds[b] = prims[i].box
+			} else {
+				bounds[b] = SurroundingBox(bounds[b], prims[i].box)
+			}
+			counts[b]++
+		}
+		// sweep from the left then from the right
+		var acc Aabb
+		n := 0
+		for b := 0; b < nBuckets-1; b++ {
+			acc, n = growBucket(acc, n, bounds[b], counts[b])
+			leftArea[b] = acc.SurfaceArea()
+			leftCount[b] = n
+		}
+		n = 0
+		for b := nBuckets - 1; b > 0; b-- {
+			acc, n = growBucket(acc, n, bounds[b], counts[b])
+			rightArea[b-1] = acc.SurfaceArea()
+		}
+		for s := 0; s < nBuckets-1; s++ {
+			nLeft := leftCount[s]
+			nRight := len(prims) - nLeft
+			if nLeft == 0 || nRight == 0 {
+				continue
+			}
+			cost := leftArea[s]*float64(nLeft) + rightArea[s]*float64(nRight)
+			if cost < bestCost {
+				bestCost = cost
+				bestAxis = axis
+				bestSplit = s
+			}
+		}
+	}
+
+	area := box.SurfaceArea()
+	leafCost := sahIntersectionCost * float64(len(prims))
+	if bestAxis < 0 {
+		// the centers can not be separated by the buckets, fall back on a
+		// median split
+		if len(prims) <= opts.LeafSize {
+			return 0, 0, false
+		}
+		sortByCentroid(prims, widest)
+		return widest, len(prims) / 2, true
+	}
+	splitCost := sahTraversalCost
+	if area > 0 {
+		splitCost += sahIntersectionCost * bestCost / area
+	}
+	if len(prims) <= opts.LeafSize && leafCost <= splitCost {
+		return 0, 0, false
+	}
+
+	// partition prims around the chosen bucket
+	lo := cbMin.At(bestAxis)
+	hi := cbMax.At(bestAxis)
+	mid := 0
+	for i := range prims {
+		if bucketOf(prims[i].centroid.At(bestAxis), lo, hi, nBuckets) <= bestSplit {
+			prims[i], prims[mid] = prims[mid], prims[i]
+			mid++
+		}
+	}
+	return bestAxis, mid, true
+}
+
+// sortByCentroid sort prims by the coordinate of their center along axis
+func sortByCentroid(prims []bvhPrimitive, axis int) {
+	sort.Slice(prims, func(i, j int) bool {
+		return prims[i].centroid.At(axis) < prims[j].centroid.At(axis)
+	})
+}
+
+// bucketOf return the bucket of a center coordinate c in [lo, hi]
+func bucketOf(c, lo, hi float64, nBuckets int) int {
+	b := int(float64(nBuckets) * (c - lo) / (hi - lo))
+	if b >= nBuckets {
+		b = nBuckets - 1
+	}
+	if b < 0 {
+		b = 0
+	}
+	return b
+}
+
+// growBucket add a bucket of count objects bounded by box to the
+// accumulated box of n objects
+func growBucket(acc Aabb, n int, box Aabb, count int) (Aabb, int) {
+	if count == 0 {
+		return acc, n
+	}
+	if n == 0 {
+		return box, count
+	}
+	return SurroundingBox(acc, box), n + count
+}
+
+// newBVHLeaf return the leaf holding prims
+func newBVHLeaf(prims []bvhPrimitive) Hitable {
+	if len(prims) == 1 {
+		return prims[0].hitable
+	}
+	list := make([]Hitable, len(prims))
+	for i := range prims {
+		list[i] = prims[i].hitable
+	}
+	return NewHitableList(&list, len(list))
+}
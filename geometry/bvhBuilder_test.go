@@ -0,0 +1,70 @@
+package geometry
+
+import "testing"
+
+// sameHits check that h hits the rays as the brute-force list
+func sameHits(t *testing.T, name string, h Hitable, list *HitableList, rays []Ray) {
+	t.Helper()
+	for i := 0; i < len(rays); i++ {
+		var want, got HitRecord
+		wantHit := list.Hit(rays[i], 0.001, 1e9, &want)
+		gotHit := h.Hit(rays[i], 0.001, 1e9, &got)
+		if wantHit != gotHit || (wantHit && (want.T != got.T || want.P != got.P || want.Normal != got.Normal)) {
+			t.Fatalf("%v : ray %v hits %v at %v, the list %v at %v", name, i, gotHit, got.T, wantHit, want.T)
+		}
+	}
+}
+
+// copyList return a new list of the objects of l, the builders sorting their
+// list in place
+func copyList(l *HitableList) *HitableList {
+	list := make([]Hitable, l.listSize)
+	copy(list, l.list[:l.listSize])
+	return NewHitableList(&list, len(list))
+}
+
+func TestBVHSameHits(t *testing.T) {
+	s := NewSampler(3)
+	rays := randomRays(2000, s)
+	// the last list has clusters of objects at the same center, which the
+	// buckets can not separate
+	clustered := make([]Hitable, 300)
+	for i := 0; i < len(clustered); i++ {
+		center := NewVec3(float64(i%3), 0, 0)
+		clustered[i] = NewSphere(center, 0.2+0.01*float64(i), NewNoMaterial())
+	}
+	lists := map[string]*HitableList{
+		"spheres":   randomSpheres(500, s),
+		"few":       randomSpheres(3, s),
+		"clustered": NewHitableList(&clustered, len(clustered)),
+	}
+	strategies := map[string]BVHStrategy{"median": BVHMedian, "sah": BVHSAH}
+	for listName, list := range lists {
+		for strategyName, strategy := range strategies {
+			for _, leafSize := range []int{1, 4} {
+				opts := BVHOptions{Strategy: strategy, LeafSize: leafSize}
+				name := listName + "/" + strategyName
+				sameHits(t, name, NewBVH(copyList(list), 0, 1, opts), list, rays)
+				sameHits(t, name+"/linear", NewLinearBVH(copyList(list), 0, 1, opts), list, rays)
+			}
+		}
+	}
+}
+
+func TestSAHLeafCost(t *testing.T) {
+	opts := BVHOptions{Strategy: BVHSAH, LeafSize: 4}
+	// distant objects are cheaper to split, overlapping ones to keep in a
+	// leaf
+	distant := make([]Hitable, 4)
+	overlapping := make([]Hitable, 4)
+	for i := 0; i < 4; i++ {
+		distant[i] = NewSphere(NewVec3(100*float64(i), 0, 0), 1, NewNoMaterial())
+		overlapping[i] = NewSphere(NewVec3(0.001*float64(i), 0, 0), 1, NewNoMaterial())
+	}
+	if _, ok := NewBVH(NewHitableList(&distant, 4), 0, 1, opts).(*BVHNode); !ok {
+		t.Error("the distant objects are not split")
+	}
+	if _, ok := NewBVH(NewHitableList(&overlapping, 4), 0, 1, opts).(*HitableList); !ok {
+		t.Error("the overlapping objects are not in a leaf")
+	}
+}
@@ -7,11 +7,15 @@ import (
 type BVHNode struct {
 	Left  Hitable
 	Right Hitable
-	Box   *Aabb
+	Box   Aabb
+	// Axis is the axis along which the children were split
+	Axis int
 }
 
+// NewBVHNode build a bounding volume hierarchy over the list, splitting each
+// node at the median along the axis where the objects are the most spread
 func NewBVHNode(l *HitableList, time0, time1 float64) *BVHNode {
-	axis := int(3 * drand48())
+	axis := longestAxis(l, time0, time1)
 	if axis == 0 {
 		sort.Sort(byX(*l))
 	} else if axis == 1 {
@@ -37,49 +41,55 @@ func NewBVHNode(l *HitableList, time0, time1 float64) *BVHNode {
 	if !(*left).BoundingBox(time0, time1, boxLeft) || !(*right).BoundingBox(time0, time1, boxRight) {
 		panic("no bounding box in bvh_node constructor")
 	}
-	box := SurroundingBox(boxLeft, boxRight)
+	box := SurroundingBox(*boxLeft, *boxRight)
 	return &BVHNode{
 		Left:  *left,
 		Right: *right,
 		Box:   box,
+		Axis:  axis,
 	}
 }
 
-func (bvhn BVHNode) Hit(r *Ray, tMin float64, tMax float64, rec *HitRecord) bool {
-	if bvhn.Box.Hit(r, tMin, tMax) {
-		var leftRec = new(HitRecord)
-		var rightRec = new(HitRecord)
-		hitLeft := bvhn.Left.Hit(r, tMin, tMax, leftRec)
-		hitRight := bvhn.Right.Hit(r, tMin, tMax, rightRec)
-		if hitLeft && hitRight {
-			if leftRec.T < rightRec.T {
-				*rec = *leftRec
-			} else {
-				*rec = *rightRec
-			}
-			return true
-		} else if hitLeft {
-			*rec = *leftRec
-			return true
-		} else if hitRight {
-			*rec = *rightRec
-			return true
-		} else {
-			return false
+// longestAxis return the axis (0 for X, 1 for Y, 2 for Z) along which the
+// bounding box of the list is the longest
+func longestAxis(l *HitableList, time0, time1 float64) int {
+	var box = new(Aabb)
+	if !l.BoundingBox(time0, time1, box) {
+		panic("no bounding box in bvh_node constructor")
+	}
+	var extent = box.Max().Minus(box.Min())
+	axis := 0
+	for a := 1; a < 3; a++ {
+		if extent.At(a) > extent.At(axis) {
+			axis = a
+		}
+	}
+	return axis
+}
+
+func (bvhn *BVHNode) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+	if bvhn.Box.Hit(&r, tMin, tMax) {
+		// rec is only written on a hit, the right child only has to beat the
+		// left one
+		hitLeft := hitChild(bvhn.Left, r, tMin, tMax, rec)
+		if hitLeft {
+			tMax = rec.T
 		}
+		hitRight := hitChild(bvhn.Right, r, tMin, tMax, rec)
+		return hitLeft || hitRight
 	}
 	return false
 }
 
-func (bvhn BVHNode) BoundingBox(t0, t1 float64, b *Aabb) bool {
-	*b = *bvhn.Box
+func (bvhn *BVHNode) BoundingBox(t0, t1 float64, b *Aabb) bool {
+	*b = bvhn.Box
 	return true
 }
 
-func (bvhn BVHNode) PdfValue(o, v *Vec3) float64 {
+func (bvhn *BVHNode) PdfValue(o, v Vec3) float64 {
 	return 0.0
 }
 
-func (bvhn BVHNode) Random(o *Vec3) *Vec3 {
+func (bvhn *BVHNode) Random(o Vec3, s *Sampler) Vec3 {
 	return NewVec3(1, 0, 0)
 }
@@ -0,0 +1,19 @@
+package geometry
+
+import "math"
+
+// SRGB encode a linear value between 0 and 1 with the sRGB transfer function
+func SRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// LinearSRGB decode a value encoded by SRGB
+func LinearSRGB(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
@@ -2,125 +2,108 @@ package geometry
 
 import "math"
 
+// Translate is an object moved by Offset, the Transform of a translation
 type Translate struct {
-	Ptr    Hitable
-	Offset *Vec3
+	*Transform
+	Offset Vec3
 }
 
-func NewTranslate(p Hitable, displacement *Vec3) *Translate {
+func NewTranslate(p Hitable, displacement Vec3) *Translate {
 	return &Translate{
-		Ptr:    p,
-		Offset: displacement,
+		Transform: NewTransform(p, Translation(displacement)),
+		Offset:    displacement,
 	}
 }
 
-func (tr Translate) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	if tr.Ptr.BoundingBox(t0, t1, box) {
-		*box = *NewAabb(box.Min().Plus(tr.Offset), box.Max().Plus(tr.Offset))
-		return true
-	}
-	return false
-}
-
-func (tr Translate) Hit(r *Ray, tMin, tMax float64, rec *HitRecord) bool {
-	movedR := NewRayWithTime(r.Origin().Minus(tr.Offset), r.Direction(), r.Time())
-	if tr.Ptr.Hit(movedR, tMin, tMax, rec) {
-		*rec.P = *(rec.P.Plus(tr.Offset))
-		return true
-	}
-	return false
-}
+//
 
-func (tr Translate) PdfValue(o, v *Vec3) float64 {
-	return 0.0
+// RotateY is an object rotated around the Y axis, the Transform of a
+// rotation
+type RotateY struct {
+	*Transform
+	// Angle is the rotation in degrees
+	Angle float64
 }
 
-func (tr Translate) Random(o *Vec3) *Vec3 {
-	return NewVec3(1, 0, 0)
+func NewRotateY(p Hitable, angle float64) *RotateY {
+	return &RotateY{
+		Transform: NewTransform(p, Rotation(NewVec3(0, 1, 0), angle)),
+		Angle:     angle,
+	}
 }
 
 //
 
-type RotateY struct {
-	Ptr      Hitable
-	SinTheta float64
-	CosTheta float64
-	HasBox   bool
-	Bbox     *Aabb
+// Transform is an object transformed by an affine matrix : the rays are
+// moved into the space of the object, and its normals back by the inverse
+// transpose of the matrix
+type Transform struct {
+	Ptr Hitable
+	// Matrix transforms the object into the world
+	Matrix  Matrix
+	inverse Matrix
+	normal  Matrix
 }
 
-func NewRotateY(p Hitable, angle float64) *RotateY {
-	radians := (math.Pi / 180.0) * angle
-	sinTheta := math.Sin(radians)
-	cosTheta := math.Cos(radians)
-	bbox := new(Aabb)
-	hasBox := p.BoundingBox(0, 1, bbox)
-	min := NewVec3(math.MaxFloat64, math.MaxFloat64, math.MaxFloat64)
-	max := NewVec3(-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64)
-	for i := 0; i < 2; i++ {
-		x := float64(i)*bbox.Max().X() + (float64(i)-1)*bbox.Min().X()
-		for j := 0; j < 2; j++ {
-			y := float64(j)*bbox.Max().Y() + (float64(j)-1)*bbox.Min().Y()
-			for k := 0; k < 2; k++ {
-				z := float64(k)*bbox.Max().Z() + (float64(k)-1)*bbox.Min().Z()
-				newX := cosTheta*x + sinTheta*z
-				newZ := -sinTheta*x + cosTheta*z
-				tester := NewVec3(newX, y, newZ)
-				for c := 0; c < 3; c++ {
-					if tester.At(c) > max.At(c) {
-						max.SetAt(c, tester.At(c))
-					}
-					if tester.At(c) < min.At(c) {
-						min.SetAt(c, tester.At(c))
-					}
-				}
-			}
-		}
+// NewTransform instantiate a new Transform of the object by the matrix,
+// which must be affine and invertible
+func NewTransform(p Hitable, m Matrix) *Transform {
+	if m[3] != [4]float64{0, 0, 0, 1} {
+		panic("the matrix of a transform must be affine")
 	}
-	return &RotateY{
-		Ptr:      p,
-		SinTheta: sinTheta,
-		CosTheta: cosTheta,
-		HasBox:   hasBox,
-		Bbox:     bbox,
+	inverse, ok := m.Inverse()
+	if !ok {
+		panic("the matrix of a transform must be invertible")
+	}
+	return &Transform{
+		Ptr:     p,
+		Matrix:  m,
+		inverse: inverse,
+		normal:  inverse.Transpose(),
 	}
 }
 
-func (ry RotateY) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	*box = *ry.Bbox
-	return ry.HasBox
-}
-
-func (ry RotateY) Hit(r *Ray, tMin, tMax float64, rec *HitRecord) bool {
-	origin := new(Vec3)
-	*origin = *r.Origin()
-	direction := new(Vec3)
-	*direction = *r.Direction()
-	origin.SetAt(0, ry.CosTheta*r.Origin().At(0)-ry.SinTheta*r.Origin().At(2))
-	origin.SetAt(2, ry.SinTheta*r.Origin().At(0)+ry.CosTheta*r.Origin().At(2))
-	direction.SetAt(0, ry.CosTheta*r.Direction().At(0)-ry.SinTheta*r.Direction().At(2))
-	direction.SetAt(2, ry.SinTheta*r.Direction().At(0)+ry.CosTheta*r.Direction().At(2))
-	rotatedR := NewRayWithTime(origin, direction, r.Time())
-	if ry.Ptr.Hit(rotatedR, tMin, tMax, rec) {
-		p := new(Vec3)
-		*p = *rec.P
-		normal := new(Vec3)
-		*normal = *rec.Normal
-		p.SetAt(0, ry.CosTheta*rec.P.At(0)+ry.SinTheta*rec.P.At(2))
-		p.SetAt(2, -ry.SinTheta*rec.P.At(0)+ry.CosTheta*rec.P.At(2))
-		normal.SetAt(0, ry.CosTheta*rec.Normal.At(0)+ry.SinTheta*rec.Normal.At(2))
-		normal.SetAt(2, -ry.SinTheta*rec.Normal.At(0)+ry.CosTheta*rec.Normal.At(2))
-		*rec.P = *p
-		*rec.Normal = *normal
+func (tr *Transform) Hit(r Ray, tMin, tMax float64, rec *HitRecord) bool {
+	// the direction is not normalized so that t is the same in both spaces
+	objectR := NewRayWithTime(tr.inverse.Point(r.Origin()), tr.inverse.Vector(r.Direction()), r.Time())
+	if tr.Ptr.Hit(objectR, tMin, tMax, rec) {
+		rec.P = r.PointAt(rec.T)
+		rec.Normal = tr.normal.Vector(rec.Normal).UnitVector()
 		return true
 	}
 	return false
 }
 
-func (ry RotateY) PdfValue(o, v *Vec3) float64 {
-	return 0.0
+// BoundingBox return the box around the transformed box of the object, each
+// bound being the sum of the extreme products of a row by the box
+func (tr *Transform) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	var objectBox Aabb
+	if !tr.Ptr.BoundingBox(t0, t1, &objectBox) {
+		return false
+	}
+	m := tr.Matrix
+	min := NewVec3(m[0][3], m[1][3], m[2][3])
+	max := min
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			a := m[i][j] * objectBox._min.e[j]
+			b := m[i][j] * objectBox._max.e[j]
+			min.e[i] += math.Min(a, b)
+			max.e[i] += math.Max(a, b)
+		}
+	}
+	*box = NewAabb(min, max)
+	return true
+}
+
+// PdfValue convert the density of the object over the solid angles of its
+// space to the ones of the world, by the jacobian of the directions
+func (tr *Transform) PdfValue(o, v Vec3) float64 {
+	d := tr.inverse.Vector(v.UnitVector())
+	l := d.Length()
+	return tr.Ptr.PdfValue(tr.inverse.Point(o), d) * math.Abs(tr.inverse.Determinant()) / (l * l * l)
 }
 
-func (ry RotateY) Random(o *Vec3) *Vec3 {
-	return NewVec3(1, 0, 0)
+func (tr *Transform) Random(o Vec3, s *Sampler) Vec3 {
+	return tr.Matrix.Vector(tr.Ptr.Random(tr.inverse.Point(o), s))
 }
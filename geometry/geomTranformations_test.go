@@ -0,0 +1,86 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransformBoundingBox(t *testing.T) {
+	// the unit cube turned by 45 degrees around Z is a diamond, whose box
+	// is tight
+	h := NewTransform(NewBox(NewVec3(0, 0, 0), NewVec3(1, 1, 1), NewNoMaterial()), Rotation(NewVec3(0, 0, 1), 45))
+	var box Aabb
+	if !h.BoundingBox(0, 1, &box) {
+		t.Fatal("the transformed box has no bounding box")
+	}
+	s := math.Sqrt2 / 2
+	if !nearVec3(box.Min(), NewVec3(-s, 0, 0), 1e-12) || !nearVec3(box.Max(), NewVec3(s, math.Sqrt2, 1), 1e-12) {
+		t.Errorf("box %v %v instead of [%v 0 0] [%v %v 1]", box.Min(), box.Max(), -s, s, math.Sqrt2)
+	}
+}
+
+func TestTransformSameHits(t *testing.T) {
+	// a unit sphere scaled, turned and moved is the sphere of radius 2 at the
+	// translation
+	center := NewVec3(1, 2, 3)
+	transformed := map[string]Hitable{
+		"Transform": NewTransform(NewSphere(NewVec3(0, 0, 0), 1, NewNoMaterial()),
+			Compose(Scaling(NewVec3(2, 2, 2)), Rotation(NewVec3(1, 1, 0), 30), Translation(center))),
+		"Translate": NewTranslate(NewSphere(NewVec3(0, 0, 0), 2, NewNoMaterial()), center),
+		"RotateY":   NewRotateY(NewSphere(Rotation(NewVec3(0, 1, 0), -40).Point(center), 2, NewNoMaterial()), 40),
+	}
+	sphere := NewSphere(center, 2, NewNoMaterial())
+	rays := randomRays(500, NewSampler(3))
+	for name, h := range transformed {
+		for i := 0; i < len(rays); i++ {
+			var want, got HitRecord
+			hit := sphere.Hit(rays[i], 0.001, 1e9, &want)
+			if h.Hit(rays[i], 0.001, 1e9, &got) != hit {
+				t.Fatalf("%v : ray %v is hit %v instead of %v", name, i, !hit, hit)
+			}
+			if hit && (math.Abs(got.T-want.T) > 1e-9 || !nearVec3(got.P, want.P, 1e-9) || !nearVec3(got.Normal, want.Normal, 1e-9)) {
+				t.Fatalf("%v : ray %v hits at %v %v %v instead of %v %v %v", name, i, got.T, got.P, got.Normal, want.T, want.P, want.Normal)
+			}
+		}
+	}
+}
+
+func TestTransformNormal(t *testing.T) {
+	// the unit sphere stretched along X is the ellipsoid x²/4 + y² + z² = 1,
+	// whose normal at p is along (x/4, y, z)
+	h := NewTransform(NewSphere(NewVec3(0, 0, 0), 1, NewNoMaterial()), Scaling(NewVec3(2, 1, 1)))
+	p := NewVec3(math.Sqrt2, math.Sqrt2/2, 0)
+	normal := NewVec3(1, 2, 0).UnitVector()
+	var rec HitRecord
+	if !h.Hit(NewRay(p.Plus(normal.TimesScalar(5)), normal.TimesScalar(-1)), 0.001, 1e9, &rec) {
+		t.Fatal("the ellipsoid is not hit")
+	}
+	if !nearVec3(rec.P, p, 1e-12) || !nearVec3(rec.Normal, normal, 1e-12) {
+		t.Errorf("hit at %v of normal %v instead of %v %v", rec.P, rec.Normal, p, normal)
+	}
+}
+
+func TestTransformPdfValue(t *testing.T) {
+	// the square light turned by 90 degrees and stretched along X is the
+	// light of twice its area
+	square := NewXZRect(-1, 1, -1, 1, 3, NewNoMaterial())
+	transformed := map[string]Hitable{
+		"Transform": NewTransform(square, Compose(Rotation(NewVec3(0, 1, 0), 90), Scaling(NewVec3(2, 1, 1)))),
+		"Translate": NewTranslate(NewXZRect(-3, 1, -2, 0, 1, NewNoMaterial()), NewVec3(1, 2, 1)),
+	}
+	light := NewXZRect(-2, 2, -1, 1, 3, NewNoMaterial())
+	o := NewVec3(0.3, 0, 0.2)
+	s := NewSampler(4)
+	for name, h := range transformed {
+		for i := 0; i < 100; i++ {
+			v := h.Random(o, s)
+			want := light.PdfValue(o, v)
+			if want == 0 {
+				t.Fatalf("%v : the direction %v does not see the light", name, v)
+			}
+			if got := h.PdfValue(o, v); math.Abs(got-want) > 1e-9*want {
+				t.Fatalf("%v : density %v instead of %v toward %v", name, got, want, v)
+			}
+		}
+	}
+}
@@ -1,23 +1,26 @@
 package geometry
 
-import "math/rand"
-
 // HitRecord is the record of the hit
 type HitRecord struct {
 	T      float64
 	U      float64
 	V      float64
-	P      *Vec3
-	Normal *Vec3
+	P      Vec3
+	Normal Vec3
 	MatPtr Material
+	// ObjectID and MaterialID are set by Tagged, 0 for untagged objects
+	ObjectID   int
+	MaterialID int
 }
 
-// Hitable is the interface of all Hitable objects
+// Hitable is the interface of all Hitable objects.
+// Hit must leave rec untouched when it returns false, so that the same record
+// can be handed down to every candidate without allocating temporary ones.
 type Hitable interface {
-	Hit(r *Ray, tMin float64, tMax float64, rec *HitRecord) bool
+	Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool
 	BoundingBox(t0, t1 float64, box *Aabb) bool
-	PdfValue(o, v *Vec3) float64
-	Random(o *Vec3) *Vec3
+	PdfValue(o, v Vec3) float64
+	Random(o Vec3, s *Sampler) Vec3
 }
 
 // HitableList is a list of Hitable
@@ -41,6 +44,11 @@ func NewHitableList(list *[]Hitable, listSize int) *HitableList {
 	return &HitableList{newList, listSize}
 }
 
+// Len return the number of hitables of the list
+func (hList *HitableList) Len() int {
+	return hList.listSize
+}
+
 func (hList *HitableList) GetAt(i int) *Hitable {
 	return &(hList.list[i])
 }
@@ -55,22 +63,20 @@ func (hList *HitableList) Slice() (*HitableList, *HitableList) {
 }
 
 // Hit test if the ray hit any of the hitable in the list
-func (hList HitableList) Hit(r *Ray, tMin float64, tMax float64, rec *HitRecord) bool {
-	var tempRec = HitRecord{}
+func (hList *HitableList) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
 	hitAnything := false
 	closestSoFar := tMax
 	for i := 0; i < hList.listSize; i++ {
-		var hitable = hList.GetAt(i)
-		if (*hitable).Hit(r, tMin, closestSoFar, &tempRec) {
+		// rec is only written on a hit closer than closestSoFar
+		if hitChild(hList.list[i], r, tMin, closestSoFar, rec) {
 			hitAnything = true
-			closestSoFar = tempRec.T
-			*rec = tempRec
+			closestSoFar = rec.T
 		}
 	}
 	return hitAnything
 }
 
-func (hList HitableList) BoundingBox(t0, t1 float64, box *Aabb) bool {
+func (hList *HitableList) BoundingBox(t0, t1 float64, box *Aabb) bool {
 	if hList.listSize < 1 {
 		return false
 	}
@@ -82,8 +88,8 @@ func (hList HitableList) BoundingBox(t0, t1 float64, box *Aabb) bool {
 		*box = *tempBox
 	}
 	for i := 1; i < hList.listSize; i++ {
-		if hList.list[0].BoundingBox(t0, t1, tempBox) {
-			*box = *SurroundingBox(box, tempBox)
+		if hList.list[i].BoundingBox(t0, t1, tempBox) {
+			*box = SurroundingBox(*box, *tempBox)
 		} else {
 			return false
 		}
@@ -91,7 +97,7 @@ func (hList HitableList) BoundingBox(t0, t1 float64, box *Aabb) bool {
 	return true
 }
 
-func (hList HitableList) PdfValue(o, v *Vec3) float64 {
+func (hList *HitableList) PdfValue(o, v Vec3) float64 {
 	weight := 1.0 / float64(hList.listSize)
 	sum := 0.0
 	for i := 0; i < hList.listSize; i++ {
@@ -100,9 +106,9 @@ func (hList HitableList) PdfValue(o, v *Vec3) float64 {
 	return sum
 }
 
-func (hList HitableList) Random(o *Vec3) *Vec3 {
-	index := int(rand.Float64() * float64(hList.listSize))
-	return (*hList.GetAt(index)).Random(o)
+func (hList *HitableList) Random(o Vec3, s *Sampler) Vec3 {
+	index := int(s.Float64() * float64(hList.listSize))
+	return (*hList.GetAt(index)).Random(o, s)
 }
 
 // Interface sort implementation for liust of Hitable
@@ -188,22 +194,74 @@ func NewFlipNormals(ptr Hitable) *FlipNormals {
 	}
 }
 
-func (fn FlipNormals) BoundingBox(t0, t1 float64, box *Aabb) bool {
+func (fn *FlipNormals) BoundingBox(t0, t1 float64, box *Aabb) bool {
 	return fn.Ptr.BoundingBox(t0, t1, box)
 }
 
-func (fn FlipNormals) Hit(r *Ray, tMin, tMax float64, rec *HitRecord) bool {
+func (fn *FlipNormals) Hit(r Ray, tMin, tMax float64, rec *HitRecord) bool {
 	if fn.Ptr.Hit(r, tMin, tMax, rec) {
-		*(rec.Normal) = *(rec.Normal.TimesScalar(-1))
+		rec.Normal = rec.Normal.Opposite()
 		return true
 	}
 	return false
 }
 
-func (fn FlipNormals) PdfValue(o, v *Vec3) float64 {
+func (fn *FlipNormals) PdfValue(o, v Vec3) float64 {
 	return 0.0
 }
 
-func (fn FlipNormals) Random(o *Vec3) *Vec3 {
+func (fn *FlipNormals) Random(o Vec3, s *Sampler) Vec3 {
 	return NewVec3(1, 0, 0)
 }
+
+// hitChild hit a child of a group of objects. The IDs of rec are reset for
+// the hit, so that an untagged object does not keep the IDs of a farther
+// tagged one, and restored when the child is missed.
+func hitChild(h Hitable, r Ray, tMin, tMax float64, rec *HitRecord) bool {
+	objectID, materialID := rec.ObjectID, rec.MaterialID
+	rec.ObjectID, rec.MaterialID = 0, 0
+	if h.Hit(r, tMin, tMax, rec) {
+		return true
+	}
+	rec.ObjectID, rec.MaterialID = objectID, materialID
+	return false
+}
+
+// Tagged sets the object and material IDs of the hits of Ptr, which are
+// recorded in the AOVs of the render. The hits of the objects which are not
+// tagged have null IDs.
+type Tagged struct {
+	Ptr        Hitable
+	ObjectID   int
+	MaterialID int
+}
+
+// NewTagged instantiate a new Tagged
+func NewTagged(ptr Hitable, objectID, materialID int) *Tagged {
+	return &Tagged{
+		Ptr:        ptr,
+		ObjectID:   objectID,
+		MaterialID: materialID,
+	}
+}
+
+func (tg *Tagged) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	return tg.Ptr.BoundingBox(t0, t1, box)
+}
+
+func (tg *Tagged) Hit(r Ray, tMin, tMax float64, rec *HitRecord) bool {
+	if tg.Ptr.Hit(r, tMin, tMax, rec) {
+		rec.ObjectID = tg.ObjectID
+		rec.MaterialID = tg.MaterialID
+		return true
+	}
+	return false
+}
+
+func (tg *Tagged) PdfValue(o, v Vec3) float64 {
+	return tg.Ptr.PdfValue(o, v)
+}
+
+func (tg *Tagged) Random(o Vec3, s *Sampler) Vec3 {
+	return tg.Ptr.Random(o, s)
+}
@@ -0,0 +1,139 @@
+package geometry
+
+import "testing"
+
+// randomSpheres return a list of n spheres scattered in the cube [-10, 10]
+func randomSpheres(n int, s *Sampler) *HitableList {
+	list := make([]Hitable, n)
+	for i := 0; i < n; i++ {
+		center := NewVec3(20*s.Float64()-10, 20*s.Float64()-10, 20*s.Float64()-10)
+		list[i] = NewSphere(center, 0.1+0.5*s.Float64(), NewNoMaterial())
+	}
+	return NewHitableList(&list, n)
+}
+
+// randomRays return n rays from the sphere of radius 20 toward the cube
+// [-5, 5]
+func randomRays(n int, s *Sampler) []Ray {
+	rays := make([]Ray, n)
+	for i := 0; i < n; i++ {
+		origin := randomOnUnitSphere(s).TimesScalar(20)
+		target := NewVec3(10*s.Float64()-5, 10*s.Float64()-5, 10*s.Float64()-5)
+		rays[i] = NewRay(origin, target.Minus(origin))
+	}
+	return rays
+}
+
+// benchHitables return the objects whose Hit is measured, by name
+func benchHitables() map[string]Hitable {
+	s := NewSampler(1)
+	return map[string]Hitable{
+		"Sphere":      NewSphere(NewVec3(0, 0, 0), 3, NewNoMaterial()),
+		"XZRect":      NewXZRect(-5, 5, -5, 5, 0, NewNoMaterial()),
+		"HitableList": randomSpheres(64, s),
+		"BVHNode":     NewBVHNode(randomSpheres(1000, s), 0, 1),
+		"LinearBVH":   NewLinearBVH(randomSpheres(1000, s), 0, 1, BVHOptions{Strategy: BVHSAH}),
+	}
+}
+
+// TestHitAllocs check that no object allocates while it is hit, the
+// intersection being the hot path of the render
+func TestHitAllocs(t *testing.T) {
+	rays := randomRays(256, NewSampler(2))
+	for name, h := range benchHitables() {
+		var rec HitRecord
+		i := 0
+		allocs := testing.AllocsPerRun(1000, func() {
+			h.Hit(rays[i%len(rays)], 0.001, 1e9, &rec)
+			i++
+		})
+		if allocs != 0 {
+			t.Errorf("%v.Hit allocates %v times per ray", name, allocs)
+		}
+	}
+}
+
+func benchmarkHit(b *testing.B, name string) {
+	h := benchHitables()[name]
+	rays := randomRays(1024, NewSampler(2))
+	var rec HitRecord
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Hit(rays[i%len(rays)], 0.001, 1e9, &rec)
+	}
+}
+
+func BenchmarkSphereHit(b *testing.B)      { benchmarkHit(b, "Sphere") }
+func BenchmarkXZRectHit(b *testing.B)      { benchmarkHit(b, "XZRect") }
+func BenchmarkHitableListHit(b *testing.B) { benchmarkHit(b, "HitableList") }
+func BenchmarkBVHNodeHit(b *testing.B)     { benchmarkHit(b, "BVHNode") }
+func BenchmarkLinearBVHHit(b *testing.B)   { benchmarkHit(b, "LinearBVH") }
+
+// sink keeps the results of the benchmarks of Vec3 from being optimized out
+var sink Vec3
+
+func BenchmarkVec3Plus(b *testing.B) {
+	v, w := NewVec3(1, 2, 3), NewVec3(4, 5, 6)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v = v.Plus(w)
+	}
+	sink = v
+}
+
+func BenchmarkVec3Cross(b *testing.B) {
+	v, w := NewVec3(1, 2, 3), NewVec3(4, 5, 6)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v = Cross(v, w).UnitVector()
+	}
+	sink = v
+}
+
+func BenchmarkVec3Dot(b *testing.B) {
+	v, w := NewVec3(1, 2, 3), NewVec3(4, 5, 6)
+	d := 0.0
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d += Dot(v, w)
+	}
+	sink = NewVec3(d, 0, 0)
+}
+
+func BenchmarkRayPointAt(b *testing.B) {
+	r := NewRay(NewVec3(1, 2, 3), NewVec3(4, 5, 6))
+	var p Vec3
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p = p.Plus(r.PointAt(0.5))
+	}
+	sink = p
+}
+
+func TestTaggedIDs(t *testing.T) {
+	// the tagged sphere is hit first, then the closer untagged one
+	far := NewTagged(NewSphere(NewVec3(0, 0, 10), 1, NewNoMaterial()), 1, 2)
+	near := NewSphere(NewVec3(0, 0, 5), 1, NewNoMaterial())
+	list := []Hitable{far, near}
+	groups := map[string]Hitable{
+		"HitableList": NewHitableList(&list, 2),
+		"BVHNode":     &BVHNode{Left: far, Right: near, Box: NewAabb(NewVec3(-1, -1, 4), NewVec3(1, 1, 11))},
+		"LinearBVH":   FlattenBVH(NewHitableList(&list, 2), 0, 1),
+	}
+	r := NewRay(NewVec3(0, 0, 0), NewVec3(0, 0, 1))
+	for name, h := range groups {
+		var rec HitRecord
+		if !h.Hit(r, 0.001, 1e9, &rec) || rec.T != 4 {
+			t.Fatalf("%v : the near sphere is not hit", name)
+		}
+		if rec.ObjectID != 0 || rec.MaterialID != 0 {
+			t.Errorf("%v : the untagged sphere has the IDs %v and %v", name, rec.ObjectID, rec.MaterialID)
+		}
+		// a miss leaves the IDs of the record
+		rec.ObjectID, rec.MaterialID = 3, 4
+		if h.Hit(NewRay(NewVec3(5, 5, 0), NewVec3(0, 0, 1)), 0.001, 1e9, &rec) || rec.ObjectID != 3 || rec.MaterialID != 4 {
+			t.Errorf("%v : a miss changes the record", name)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package geometry
+
+// maxBVHDepth is the size of the traversal stack of LinearBVH kept on the
+// goroutine stack, the deeper hierarchies allocating theirs on each ray
+const maxBVHDepth = 64
+
+// linearBVHNode is a node of a LinearBVH.
+// For a leaf, count > 0 and offset is the index of its first object.
+// For an interior node, count == 0, the first child follows the node in the
+// array and offset is the index of the second child.
+type linearBVHNode struct {
+	box    Aabb
+	offset int32
+	count  int32
+	axis   int32
+}
+
+// LinearBVH is a bounding volume hierarchy compacted in a flat array of
+// nodes, traversed with an explicit stack, visiting the nearer child first
+// and skipping the nodes farther than the closest hit found so far.
+// It is a drop-in replacement of BVHNode.
+type LinearBVH struct {
+	nodes []linearBVHNode
+	prims []Hitable
+	opts  BVHOptions
+	// depth is the number of levels of the hierarchy
+	depth int
+}
+
+// NewLinearBVH build the hierarchy over the list with NewBVH and flatten it
+func NewLinearBVH(l *HitableList, time0, time1 float64, opts BVHOptions) *LinearBVH {
+	bvh := FlattenBVH(NewBVH(l, time0, time1, opts), time0, time1)
+	bvh.opts = opts
+	return bvh
+}
+
+// FlattenBVH compact a tree of *BVHNode, as built by NewBVHNode or NewBVH.
+// Any other Hitable in the tree is a leaf, the objects of the *HitableList
+// leaves being stored in the same leaf.
+func FlattenBVH(root Hitable, time0, time1 float64) *LinearBVH {
+	bvh := &LinearBVH{}
+	bvh.depth = bvh.flatten(root, time0, time1, 1)
+	return bvh
+}
+
+// flatten append the node and its children, returning the depth of the subtree
+func (bvh *LinearBVH) flatten(h Hitable, time0, time1 float64, depth int) int {
+	index := len(bvh.nodes)
+	bvh.nodes = append(bvh.nodes, linearBVHNode{})
+	switch node := h.(type) {
+	case *BVHNode:
+		bvh.nodes[index].box = node.Box
+		bvh.nodes[index].axis = int32(node.Axis)
+		leftDepth := bvh.flatten(node.Left, time0, time1, depth+1)
+		bvh.nodes[index].offset = int32(len(bvh.nodes))
+		rightDepth := bvh.flatten(node.Right, time0, time1, depth+1)
+		return max(leftDepth, rightDepth)
+	case *HitableList:
+		if !node.BoundingBox(time0, time1, &bvh.nodes[index].box) {
+			panic("no bounding box in bvh_node constructor")
+		}
+		bvh.nodes[index].offset = int32(len(bvh.prims))
+		bvh.nodes[index].count = int32(node.listSize)
+		bvh.prims = append(bvh.prims, node.list[:node.listSize]...)
+	default:
+		if !h.BoundingBox(time0, time1, &bvh.nodes[index].box) {
+			panic("no bounding box in bvh_node constructor")
+		}
+		bvh.nodes[index].offset = int32(len(bvh.prims))
+		bvh.nodes[index].count = 1
+		bvh.prims = append(bvh.prims, h)
+	}
+	return depth
+}
+
+// Primitives return the objects of the leaves of the hierarchy
+func (bvh *LinearBVH) Primitives() []Hitable {
+	return bvh.prims
+}
+
+// Options return the options the hierarchy was built with by NewLinearBVH
+func (bvh *LinearBVH) Options() BVHOptions {
+	return bvh.opts
+}
+
+func (bvh *LinearBVH) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+	return bvh.hitPrimitive(r, tMin, tMax, rec) >= 0
+}
+
+// hitPrimitive return the index in the primitives of the closest one hit, -1
+// if there is none
+func (bvh *LinearBVH) hitPrimitive(r Ray, tMin float64, tMax float64, rec *HitRecord) int {
+	origin := r.Origin()
+	var invDir Vec3
+	var dirIsNeg [3]bool
+	for a := 0; a < 3; a++ {
+		invDir.e[a] = 1.0 / r.b.e[a]
+		dirIsNeg[a] = invDir.e[a] < 0
+	}
+	// a node pushes at most one child per level
+	var fixed [maxBVHDepth]int32
+	stack := fixed[:]
+	if bvh.depth > maxBVHDepth {
+		stack = make([]int32, bvh.depth)
+	}
+	sp := 0
+	current := int32(0)
+	hit := -1
+	for {
+		node := &bvh.nodes[current]
+		if node.box.hitInv(&origin, &invDir, tMin, tMax) {
+			if node.count > 0 {
+				// rec is only written on a hit closer than tMax
+				for i := node.offset; i < node.offset+node.count; i++ {
+					if hitChild(bvh.prims[i], r, tMin, tMax, rec) {
+						hit = int(i)
+						tMax = rec.T
+					}
+				}
+			} else {
+				// visit the nearer child first, keep the other for later
+				if dirIsNeg[node.axis] {
+					stack[sp] = current + 1
+					current = node.offset
+				} else {
+					stack[sp] = node.offset
+					current = current + 1
+				}
+				sp++
+				continue
+			}
+		}
+		if sp == 0 {
+			break
+		}
+		sp--
+		current = stack[sp]
+	}
+	return hit
+}
+
+// crossPrimitives call cross with the index of every primitive whose leaf
+// is crossed by the ray between tMin and tMax, in no particular order
+func (bvh *LinearBVH) crossPrimitives(r Ray, tMin float64, tMax float64, cross func(i int)) {
+	origin := r.Origin()
+	var invDir Vec3
+	for a := 0; a < 3; a++ {
+		invDir.e[a] = 1.0 / r.b.e[a]
+	}
+	var fixed [maxBVHDepth]int32
+	stack := fixed[:]
+	if bvh.depth > maxBVHDepth {
+		stack = make([]int32, bvh.depth)
+	}
+	sp := 0
+	current := int32(0)
+	for {
+		node := &bvh.nodes[current]
+		if node.box.hitInv(&origin, &invDir, tMin, tMax) {
+			if node.count > 0 {
+				for i := node.offset; i < node.offset+node.count; i++ {
+					cross(int(i))
+				}
+			} else {
+				stack[sp] = node.offset
+				current = current + 1
+				sp++
+				continue
+			}
+		}
+		if sp == 0 {
+			break
+		}
+		sp--
+		current = stack[sp]
+	}
+}
+
+func (bvh *LinearBVH) BoundingBox(t0, t1 float64, b *Aabb) bool {
+	*b = bvh.nodes[0].box
+	return true
+}
+
+func (bvh *LinearBVH) PdfValue(o, v Vec3) float64 {
+	return 0.0
+}
+
+func (bvh *LinearBVH) Random(o Vec3, s *Sampler) Vec3 {
+	return NewVec3(1, 0, 0)
+}
@@ -0,0 +1,49 @@
+package geometry
+
+import "testing"
+
+func TestLinearBVHSameHits(t *testing.T) {
+	s := NewSampler(4)
+	rays := randomRays(2000, s)
+	list := randomSpheres(500, s)
+	tree := NewBVHNode(copyList(list), 0, 1)
+	flat := FlattenBVH(tree, 0, 1)
+	for i := 0; i < len(rays); i++ {
+		var want, got HitRecord
+		wantHit := tree.Hit(rays[i], 0.001, 1e9, &want)
+		gotHit := flat.Hit(rays[i], 0.001, 1e9, &got)
+		if wantHit != gotHit || (wantHit && want.T != got.T) {
+			t.Fatalf("ray %v hits the LinearBVH %v at %v, the BVHNode %v at %v", i, gotHit, got.T, wantHit, want.T)
+		}
+	}
+}
+
+func TestLinearBVHDeep(t *testing.T) {
+	// a chain of nodes, each one with a sphere on the left, deeper than the
+	// stack kept on the goroutine stack
+	n := 3 * maxBVHDepth
+	objects := make([]Hitable, n)
+	var node Hitable
+	for i := n - 1; i >= 0; i-- {
+		objects[i] = NewSphere(NewVec3(float64(i), 0, 0), 0.4, NewNoMaterial())
+		if node == nil {
+			node = objects[i]
+			continue
+		}
+		var left, right Aabb
+		objects[i].BoundingBox(0, 1, &left)
+		node.BoundingBox(0, 1, &right)
+		node = &BVHNode{Left: objects[i], Right: node, Box: SurroundingBox(left, right)}
+	}
+	flat := FlattenBVH(node, 0, 1)
+	if flat.depth != n {
+		t.Fatalf("depth %v instead of %v", flat.depth, n)
+	}
+	sameHits(t, "deep", flat, NewHitableList(&objects, n), randomRays(500, NewSampler(5)))
+	// the rays along the chain cross all the levels
+	list := NewHitableList(&objects, n)
+	for i := 0; i < n; i++ {
+		r := NewRay(NewVec3(float64(i), 0, -10), NewVec3(0, 0, 1))
+		sameHits(t, "deep", flat, list, []Ray{r})
+	}
+}
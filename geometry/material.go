@@ -2,22 +2,21 @@ package geometry
 
 import (
 	"math"
-	"math/rand"
 )
 
 /*
 * Utilitaries
  */
-func reflect(v *Vec3, n *Vec3) *Vec3 {
+func reflect(v Vec3, n Vec3) Vec3 {
 	return v.Minus(n.TimesScalar(2 * Dot(v, n)))
 }
 
-func refract(v *Vec3, n *Vec3, niOverNt float64, refracted *Vec3) bool {
+func refract(v Vec3, n Vec3, niOverNt float64, refracted *Vec3) bool {
 	var uv = v.UnitVector()
 	dt := Dot(uv, n)
 	discriminant := 1.0 - niOverNt*niOverNt*(1-dt*dt)
 	if discriminant > 0 {
-		*refracted = *uv.Minus(n.TimesScalar(dt)).TimesScalar(niOverNt).Minus(n.TimesScalar(math.Sqrt(discriminant)))
+		*refracted = uv.Minus(n.TimesScalar(dt)).TimesScalar(niOverNt).Minus(n.TimesScalar(math.Sqrt(discriminant)))
 		return true
 	}
 	return false
@@ -37,16 +36,16 @@ func schlick(cosine, refIdx float64) float64 {
 // Material interface
 // struct of scattered record
 type ScatterRecord struct {
-	SpecularRay *Ray
+	SpecularRay Ray
 	IsSpecular  bool
-	Attenuation *Vec3
+	Attenuation Vec3
 	PdfPtr      Pdf
 }
 
 type Material interface {
-	Scatter(rIn *Ray, rec *HitRecord, srec *ScatterRecord) bool
-	Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3
-	ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64
+	Scatter(rIn Ray, rec *HitRecord, srec *ScatterRecord, s *Sampler) bool
+	Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3
+	ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64
 }
 
 type noMaterial struct{}
@@ -55,15 +54,21 @@ func NewNoMaterial() *noMaterial {
 	return &noMaterial{}
 }
 
-func (noMat *noMaterial) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bool {
+// IsNoMaterial return true if m is the material of NewNoMaterial
+func IsNoMaterial(m Material) bool {
+	_, ok := m.(*noMaterial)
+	return ok
+}
+
+func (noMat *noMaterial) Scatter(rIn Ray, hrec *HitRecord, srec *ScatterRecord, s *Sampler) bool {
 	return false
 }
 
-func (noMat *noMaterial) Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3 {
+func (noMat *noMaterial) Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3 {
 	return NewVec3(0, 0, 0)
 }
 
-func (noMat *noMaterial) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64 {
+func (noMat *noMaterial) ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64 {
 	return 0.0
 }
 
@@ -76,18 +81,18 @@ type Lambertian struct {
 	Albedo Texture
 }
 
-func (lamb Lambertian) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bool {
+func (lamb Lambertian) Scatter(rIn Ray, hrec *HitRecord, srec *ScatterRecord, s *Sampler) bool {
 	srec.IsSpecular = false
 	srec.Attenuation = lamb.Albedo.Value(hrec.U, hrec.V, hrec.P)
 	srec.PdfPtr = NewCosinePdf(hrec.Normal)
 	return true
 }
 
-func (lamb Lambertian) Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3 {
+func (lamb Lambertian) Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3 {
 	return NewVec3(0, 0, 0)
 }
 
-func (lamb Lambertian) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64 {
+func (lamb Lambertian) ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64 {
 	cosine := Dot(rec.Normal, scattered.Direction().UnitVector())
 	if cosine < 0 {
 		cosine = 0
@@ -97,24 +102,24 @@ func (lamb Lambertian) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) f
 
 // Metal material
 type Metal struct {
-	Albedo *Vec3
+	Albedo Vec3
 	Fuzz   float64
 }
 
-func (met Metal) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bool {
+func (met Metal) Scatter(rIn Ray, hrec *HitRecord, srec *ScatterRecord, s *Sampler) bool {
 	var reflected = reflect(rIn.Direction().UnitVector(), hrec.Normal)
-	srec.SpecularRay = NewRay(hrec.P, reflected.Plus(randomInUnitSphere().TimesScalar(met.Fuzz)))
+	srec.SpecularRay = NewRay(hrec.P, reflected.Plus(randomInUnitSphere(s).TimesScalar(met.Fuzz)))
 	srec.Attenuation = met.Albedo
 	srec.IsSpecular = true
 	srec.PdfPtr = NewNoPdf()
 	return true
 }
 
-func (met Metal) Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3 {
+func (met Metal) Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3 {
 	return NewVec3(0, 0, 0)
 }
 
-func (met Metal) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64 {
+func (met Metal) ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64 {
 	return 0.0
 }
 
@@ -123,14 +128,14 @@ type Dielectric struct {
 	RefIdx float64
 }
 
-func (die Dielectric) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bool {
+func (die Dielectric) Scatter(rIn Ray, hrec *HitRecord, srec *ScatterRecord, s *Sampler) bool {
 	srec.IsSpecular = true
 	srec.PdfPtr = NewNoPdf()
 	srec.Attenuation = NewVec3(1.0, 1.0, 1.0)
 
-	outwardNormal := new(Vec3)
+	var outwardNormal Vec3
 	reflected := reflect(rIn.Direction(), hrec.Normal)
-	refracted := new(Vec3)
+	var refracted Vec3
 
 	niOverNt := 0.0
 	reflectProb := 0.0
@@ -145,12 +150,12 @@ func (die Dielectric) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bo
 		niOverNt = 1.0 / die.RefIdx
 		cosine = -Dot(rIn.Direction(), hrec.Normal) / rIn.Direction().Length()
 	}
-	if refract(rIn.Direction(), outwardNormal, niOverNt, refracted) {
+	if refract(rIn.Direction(), outwardNormal, niOverNt, &refracted) {
 		reflectProb = schlick(cosine, die.RefIdx)
 	} else {
 		reflectProb = 1.0
 	}
-	if rand.Float64() < reflectProb {
+	if s.Float64() < reflectProb {
 		srec.SpecularRay = NewRay(hrec.P, reflected)
 	} else {
 		srec.SpecularRay = NewRay(hrec.P, refracted)
@@ -158,11 +163,11 @@ func (die Dielectric) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bo
 	return true
 }
 
-func (die Dielectric) Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3 {
+func (die Dielectric) Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3 {
 	return NewVec3(0, 0, 0)
 }
 
-func (die Dielectric) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64 {
+func (die Dielectric) ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64 {
 	return 0.0
 }
 
@@ -177,15 +182,15 @@ func NewDiffuseLight(emit Texture) *DiffuseLight {
 	}
 }
 
-func (dl DiffuseLight) Scatter(rIn *Ray, hrec *HitRecord, srec *ScatterRecord) bool {
+func (dl DiffuseLight) Scatter(rIn Ray, hrec *HitRecord, srec *ScatterRecord, s *Sampler) bool {
 	return false
 }
 
-func (dl DiffuseLight) ScatteringPdf(rIn *Ray, rec *HitRecord, scattered *Ray) float64 {
+func (dl DiffuseLight) ScatteringPdf(rIn Ray, rec *HitRecord, scattered Ray) float64 {
 	return 0.0
 }
 
-func (dl DiffuseLight) Emitted(rIn *Ray, rec *HitRecord, u, v float64, p *Vec3) *Vec3 {
+func (dl DiffuseLight) Emitted(rIn Ray, rec *HitRecord, u, v float64, p Vec3) Vec3 {
 	if Dot(rec.Normal, rIn.Direction()) < 0.0 {
 		return dl.Emit.Value(u, v, p)
 	}
@@ -204,12 +209,12 @@ func NewIsotropic(a Texture) *Isotropic {
 	}
 }
 
-func (iso Isotropic) Scatter(rIn *Ray, rec *HitRecord, attenuation *Vec3, scattered *Ray) bool {
-	*scattered = *NewRay(rec.P, randomInUnitSphere())
-	*attenuation = *iso.Albedo.Value(rec.U, rec.V, rec.P)
+func (iso Isotropic) Scatter(rIn Ray, rec *HitRecord, attenuation *Vec3, scattered *Ray, s *Sampler) bool {
+	*scattered = NewRay(rec.P, randomInUnitSphere(s))
+	*attenuation = iso.Albedo.Value(rec.U, rec.V, rec.P)
 	return true
 }
 
-func (iso Isotropic) Emitted(u, v float64, p *Vec3) *Vec3 {
+func (iso Isotropic) Emitted(u, v float64, p Vec3) Vec3 {
 	return NewVec3(0, 0, 0)
 }
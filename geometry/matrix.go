@@ -0,0 +1,140 @@
+package geometry
+
+import "math"
+
+// Matrix is a 4x4 matrix by row, applied to the column vectors. The
+// transforms of the objects are affine, their last row being 0, 0, 0, 1.
+type Matrix [4][4]float64
+
+// IdentityMatrix return the transform which changes nothing
+func IdentityMatrix() Matrix {
+	return Matrix{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+// Translation return the transform moving the points by offset
+func Translation(offset Vec3) Matrix {
+	m := IdentityMatrix()
+	for i := 0; i < 3; i++ {
+		m[i][3] = offset.e[i]
+	}
+	return m
+}
+
+// Scaling return the transform multiplying the coordinates by factors
+func Scaling(factors Vec3) Matrix {
+	m := IdentityMatrix()
+	for i := 0; i < 3; i++ {
+		m[i][i] = factors.e[i]
+	}
+	return m
+}
+
+// Rotation return the rotation of angle degrees around axis, in the direction
+// of RotateY around the Y axis
+func Rotation(axis Vec3, angle float64) Matrix {
+	a := axis.UnitVector()
+	radians := (math.Pi / 180.0) * angle
+	sin := math.Sin(radians)
+	cos := math.Cos(radians)
+	x, y, z := a.X(), a.Y(), a.Z()
+	return Matrix{
+		{cos + x*x*(1-cos), x*y*(1-cos) - z*sin, x*z*(1-cos) + y*sin, 0},
+		{y*x*(1-cos) + z*sin, cos + y*y*(1-cos), y*z*(1-cos) - x*sin, 0},
+		{z*x*(1-cos) - y*sin, z*y*(1-cos) + x*sin, cos + z*z*(1-cos), 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// LookAt return the transform placing an object at from, its Z axis toward
+// to and its Y axis toward up, as the cameras of view
+func LookAt(from, to, up Vec3) Matrix {
+	w := to.Minus(from).UnitVector()
+	u := Cross(up, w).UnitVector()
+	v := Cross(w, u)
+	m := IdentityMatrix()
+	for i := 0; i < 3; i++ {
+		m[i][0] = u.e[i]
+		m[i][1] = v.e[i]
+		m[i][2] = w.e[i]
+		m[i][3] = from.e[i]
+	}
+	return m
+}
+
+// Compose return the transform applying the matrices in order, the first
+// one first
+func Compose(matrices ...Matrix) Matrix {
+	m := IdentityMatrix()
+	for i := 0; i < len(matrices); i++ {
+		m = matrices[i].Times(m)
+	}
+	return m
+}
+
+// Times return the product m x b, the transform applying b then m
+func (m Matrix) Times(b Matrix) Matrix {
+	var r Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				r[i][j] += m[i][k] * b[k][j]
+			}
+		}
+	}
+	return r
+}
+
+// Transpose return the transposed matrix
+func (m Matrix) Transpose() Matrix {
+	var r Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			r[i][j] = m[j][i]
+		}
+	}
+	return r
+}
+
+// Point transform a position
+func (m Matrix) Point(p Vec3) Vec3 {
+	return m.Vector(p).Plus(NewVec3(m[0][3], m[1][3], m[2][3]))
+}
+
+// Vector transform a direction, which is not moved by the translation
+func (m Matrix) Vector(v Vec3) Vec3 {
+	return NewVec3(
+		m[0][0]*v.e[0]+m[0][1]*v.e[1]+m[0][2]*v.e[2],
+		m[1][0]*v.e[0]+m[1][1]*v.e[1]+m[1][2]*v.e[2],
+		m[2][0]*v.e[0]+m[2][1]*v.e[1]+m[2][2]*v.e[2])
+}
+
+// Determinant return the determinant of the linear part of an affine
+// transform, negative for the transforms mirroring the objects
+func (m Matrix) Determinant() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// Inverse return the inverse of an affine transform, false if it is not
+// invertible
+func (m Matrix) Inverse() (Matrix, bool) {
+	det := m.Determinant()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Matrix{}, false
+	}
+	// the inverse of the linear part is its adjugate over its determinant
+	r := IdentityMatrix()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			i1, i2 := (j+1)%3, (j+2)%3
+			j1, j2 := (i+1)%3, (i+2)%3
+			r[i][j] = (m[i1][j1]*m[i2][j2] - m[i1][j2]*m[i2][j1]) / det
+		}
+	}
+	t := r.Vector(NewVec3(m[0][3], m[1][3], m[2][3]))
+	for i := 0; i < 3; i++ {
+		r[i][3] = -t.e[i]
+	}
+	return r, true
+}
@@ -0,0 +1,100 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+// nearVec3 tell whether two vectors are equal up to eps
+func nearVec3(a, b Vec3, eps float64) bool {
+	return a.Minus(b).Length() <= eps
+}
+
+// testMatrices are affine transforms mixing rotations, scales, mirrors and
+// translations
+func testMatrices() map[string]Matrix {
+	return map[string]Matrix{
+		"identity":  IdentityMatrix(),
+		"composed":  Compose(Scaling(NewVec3(1, 2, 3)), Rotation(NewVec3(1, 1, 0), 30), Translation(NewVec3(4, 5, 6))),
+		"mirror":    Compose(Scaling(NewVec3(-1, 1, 1)), Translation(NewVec3(0, -2, 0))),
+		"lookAt":    LookAt(NewVec3(1, 2, 3), NewVec3(-4, 0, 2), NewVec3(0, 1, 0)),
+		"sheared":   {{1, 0.5, 0, 1}, {0, 1, 0, 2}, {0.25, 0, 2, 3}, {0, 0, 0, 1}},
+		"rotationY": Rotation(NewVec3(0, 1, 0), 15),
+	}
+}
+
+func TestMatrixInverse(t *testing.T) {
+	for name, m := range testMatrices() {
+		inverse, ok := m.Inverse()
+		if !ok {
+			t.Errorf("%v is not invertible", name)
+			continue
+		}
+		for _, product := range []Matrix{m.Times(inverse), inverse.Times(m)} {
+			id := IdentityMatrix()
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 4; j++ {
+					if math.Abs(product[i][j]-id[i][j]) > 1e-12 {
+						t.Fatalf("%v times its inverse is %v", name, product)
+					}
+				}
+			}
+		}
+	}
+	if _, ok := Scaling(NewVec3(1, 0, 1)).Inverse(); ok {
+		t.Error("a flattening scale is invertible")
+	}
+}
+
+func TestMatrixDeterminant(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+		det  float64
+	}{
+		{"scale", Scaling(NewVec3(2, 3, 4)), 24},
+		{"mirror", Scaling(NewVec3(-1, 1, 1)), -1},
+		{"rotation", Rotation(NewVec3(1, 2, 3), 40), 1},
+		{"translation", Translation(NewVec3(1, 2, 3)), 1},
+		{"composed", Compose(Scaling(NewVec3(2, 2, 2)), Rotation(NewVec3(0, 0, 1), 70)), 8},
+	}
+	for _, test := range tests {
+		if det := test.m.Determinant(); math.Abs(det-test.det) > 1e-12 {
+			t.Errorf("%v : determinant %v instead of %v", test.name, det, test.det)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	// the first transform is applied first
+	p := NewVec3(1, 0, 0)
+	if q := Compose(Scaling(NewVec3(2, 2, 2)), Translation(NewVec3(1, 0, 0))).Point(p); q != NewVec3(3, 0, 0) {
+		t.Errorf("scaled then moved to %v instead of [3 0 0]", q)
+	}
+	if q := Compose(Translation(NewVec3(1, 0, 0)), Scaling(NewVec3(2, 2, 2))).Point(p); q != NewVec3(4, 0, 0) {
+		t.Errorf("moved then scaled to %v instead of [4 0 0]", q)
+	}
+	// the rotations turn as RotateY used to, X toward -Z
+	if q := Rotation(NewVec3(0, 1, 0), 90).Point(p); !nearVec3(q, NewVec3(0, 0, -1), 1e-15) {
+		t.Errorf("rotated to %v instead of [0 0 -1]", q)
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	from, to, up := NewVec3(1, 2, 3), NewVec3(4, 6, 3), NewVec3(0, 0, 1)
+	m := LookAt(from, to, up)
+	if q := m.Point(NewVec3(0, 0, 0)); q != from {
+		t.Errorf("the origin is placed at %v instead of %v", q, from)
+	}
+	// to is at 5 along the Z axis
+	if q := m.Point(NewVec3(0, 0, 5)); !nearVec3(q, to, 1e-12) {
+		t.Errorf("the Z axis leads to %v instead of %v", q, to)
+	}
+	y := m.Vector(NewVec3(0, 1, 0))
+	if math.Abs(Dot(y, to.Minus(from))) > 1e-12 || Dot(y, up) < 0.99 {
+		t.Errorf("the Y axis is %v, up being %v", y, up)
+	}
+	if det := m.Determinant(); math.Abs(det-1) > 1e-12 {
+		t.Errorf("the transform of determinant %v is not a rotation", det)
+	}
+}
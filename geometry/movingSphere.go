@@ -4,15 +4,15 @@ import "math"
 
 // Sphere is the type of Sphere
 type MovingSphere struct {
-	Center0 *Vec3
-	Center1 *Vec3
+	Center0 Vec3
+	Center1 Vec3
 	Radius  float64
 	Mat     Material
 	Time0   float64
 	Time1   float64
 }
 
-func NewMovingSphere(cen0, cen1 *Vec3, radius, t0, t1 float64, mat Material) *MovingSphere {
+func NewMovingSphere(cen0, cen1 Vec3, radius, t0, t1 float64, mat Material) *MovingSphere {
 	return &MovingSphere{
 		Center0: cen0,
 		Center1: cen1,
@@ -23,12 +23,12 @@ func NewMovingSphere(cen0, cen1 *Vec3, radius, t0, t1 float64, mat Material) *Mo
 	}
 }
 
-func (sph *MovingSphere) Center(time float64) *Vec3 {
+func (sph *MovingSphere) Center(time float64) Vec3 {
 	return sph.Center0.Plus(sph.Center1.Minus(sph.Center0).TimesScalar((time - sph.Time0) / (sph.Time1 - sph.Time0)))
 }
 
 // Hit test if the sphere is hit
-func (sph MovingSphere) Hit(r *Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+func (sph *MovingSphere) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
 	var oc = r.Origin().Minus(sph.Center(r.Time()))
 	var a = Dot(r.Direction(), r.Direction())
 	var b = 2 * Dot(oc, r.Direction())
@@ -56,9 +56,17 @@ func (sph MovingSphere) Hit(r *Ray, tMin float64, tMax float64, rec *HitRecord)
 	return false
 }
 
-func (sph MovingSphere) BoundingBox(t0, t1 float64, box *Aabb) bool {
+func (sph *MovingSphere) BoundingBox(t0, t1 float64, box *Aabb) bool {
 	var box0 = NewAabb(sph.Center(t0).Minus(NewVec3(sph.Radius, sph.Radius, sph.Radius)), sph.Center(t0).Plus(NewVec3(sph.Radius, sph.Radius, sph.Radius)))
 	var box1 = NewAabb(sph.Center(t1).Minus(NewVec3(sph.Radius, sph.Radius, sph.Radius)), sph.Center(t1).Plus(NewVec3(sph.Radius, sph.Radius, sph.Radius)))
-	*box = *SurroundingBox(box0, box1)
+	*box = SurroundingBox(box0, box1)
 	return true
 }
+
+func (sph *MovingSphere) PdfValue(o, v Vec3) float64 {
+	return 0.0
+}
+
+func (sph *MovingSphere) Random(o Vec3, s *Sampler) Vec3 {
+	return NewVec3(1, 0, 0)
+}
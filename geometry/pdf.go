@@ -2,42 +2,40 @@ package geometry
 
 import (
 	"math"
-	"math/rand"
 )
 
 type Pdf interface {
-	Value(direction *Vec3) float64
-	Generate() *Vec3
+	Value(direction Vec3) float64
+	Generate(s *Sampler) Vec3
 }
 
-//
+// noPdf is the Pdf of specular materials, it is never sampled
 type noPdf struct{}
 
 func NewNoPdf() *noPdf {
 	return &noPdf{}
 }
 
-func (cpdf noPdf) Value(direction *Vec3) float64 {
+func (cpdf noPdf) Value(direction Vec3) float64 {
 	return 0.0
 }
 
-func (cpdf noPdf) Generate() *Vec3 {
+func (cpdf noPdf) Generate(s *Sampler) Vec3 {
 	return NewVec3(0, 0, 0)
 }
 
-//
-
+// CosinePdf samples directions around W with a cosine distribution
 type CosinePdf struct {
-	Uvw *Onb
+	Uvw Onb
 }
 
-func NewCosinePdf(w *Vec3) *CosinePdf {
+func NewCosinePdf(w Vec3) *CosinePdf {
 	return &CosinePdf{
 		Uvw: BuildFromW(w),
 	}
 }
 
-func (cpdf CosinePdf) Value(direction *Vec3) float64 {
+func (cpdf CosinePdf) Value(direction Vec3) float64 {
 	cosine := Dot(direction.UnitVector(), cpdf.Uvw.W())
 	if cosine > 0 {
 		return cosine / math.Pi
@@ -45,32 +43,32 @@ func (cpdf CosinePdf) Value(direction *Vec3) float64 {
 	return 0.0
 }
 
-func (cpdf CosinePdf) Generate() *Vec3 {
-	return cpdf.Uvw.LocalVector(RandomCosineDirection())
+func (cpdf CosinePdf) Generate(s *Sampler) Vec3 {
+	return cpdf.Uvw.LocalVector(RandomCosineDirection(s))
 }
 
-//
+// HitablePdf samples directions from O toward a Hitable
 type HitablePdf struct {
-	O   *Vec3
+	O   Vec3
 	Ptr Hitable
 }
 
-func NewHitablePdf(p Hitable, origin *Vec3) *HitablePdf {
+func NewHitablePdf(p Hitable, origin Vec3) *HitablePdf {
 	return &HitablePdf{
 		O:   origin,
 		Ptr: p,
 	}
 }
 
-func (hpdf HitablePdf) Value(direction *Vec3) float64 {
+func (hpdf HitablePdf) Value(direction Vec3) float64 {
 	return hpdf.Ptr.PdfValue(hpdf.O, direction)
 }
 
-func (hpdf HitablePdf) Generate() *Vec3 {
-	return hpdf.Ptr.Random(hpdf.O)
+func (hpdf HitablePdf) Generate(s *Sampler) Vec3 {
+	return hpdf.Ptr.Random(hpdf.O, s)
 }
 
-//
+// MixturePdf samples evenly one of two Pdf
 type MixturePdf struct {
 	P [2]Pdf
 }
@@ -81,13 +79,13 @@ func NewMixturePdf(p0, p1 Pdf) *MixturePdf {
 	}
 }
 
-func (mpdf MixturePdf) Value(direction *Vec3) float64 {
+func (mpdf MixturePdf) Value(direction Vec3) float64 {
 	return 0.5*mpdf.P[0].Value(direction) + 0.5*mpdf.P[1].Value(direction)
 }
 
-func (mpdf MixturePdf) Generate() *Vec3 {
-	if rand.Float64() < 0.5 {
-		return mpdf.P[0].Generate()
+func (mpdf MixturePdf) Generate(s *Sampler) Vec3 {
+	if s.Float64() < 0.5 {
+		return mpdf.P[0].Generate(s)
 	}
-	return mpdf.P[1].Generate()
+	return mpdf.P[1].Generate(s)
 }
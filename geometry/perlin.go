@@ -2,26 +2,29 @@ package geometry
 
 import (
 	"math"
-	"math/rand"
 )
 
+// Perlin is a Perlin noise generator, the random tables only depend on Seed
 type Perlin struct {
+	Seed   uint64
 	RanVec []Vec3
 	PermX  []int
 	PermY  []int
 	PermZ  []int
 }
 
-func NewPerlin() *Perlin {
+func NewPerlin(seed uint64) *Perlin {
+	s := NewSampler(seed)
 	return &Perlin{
-		RanVec: perlinGenerate(),
-		PermX:  perlinGeneratePerm(),
-		PermY:  perlinGeneratePerm(),
-		PermZ:  perlinGeneratePerm(),
+		Seed:   seed,
+		RanVec: perlinGenerate(s),
+		PermX:  perlinGeneratePerm(s),
+		PermY:  perlinGeneratePerm(s),
+		PermZ:  perlinGeneratePerm(s),
 	}
 }
 
-func (perlin *Perlin) Noise(p *Vec3) float64 {
+func (perlin *Perlin) Noise(p Vec3) float64 {
 	u := p.X() - math.Floor(p.X())
 	v := p.Y() - math.Floor(p.Y())
 	w := p.Z() - math.Floor(p.Z())
@@ -40,41 +43,40 @@ func (perlin *Perlin) Noise(p *Vec3) float64 {
 	return PerlinInterp(c, u, v, w)
 }
 
-func (perlin *Perlin) Turb(p *Vec3, depth int) float64 {
+func (perlin *Perlin) Turb(p Vec3, depth int) float64 {
 	accum := 0.0
-	tempP := new(Vec3)
-	*tempP = *p
+	tempP := p
 	weight := 1.0
 	for i := 0; i < depth; i++ {
 		accum += weight * perlin.Noise(tempP)
 		weight = weight * 0.5
-		*tempP = *tempP.TimesScalar(2)
+		tempP = tempP.TimesScalar(2)
 	}
 	return math.Abs(accum)
 }
 
 //
-func perlinGenerate() []Vec3 {
+func perlinGenerate(s *Sampler) []Vec3 {
 	p := make([]Vec3, 256)
 	for i := 0; i < 256; i++ {
-		p[i] = *NewVec3(2*rand.Float64()-1, 2*rand.Float64()-1, 2*rand.Float64()-1).UnitVector()
+		p[i] = NewVec3(2*s.Float64()-1, 2*s.Float64()-1, 2*s.Float64()-1).UnitVector()
 	}
 	return p
 }
 
-func permute(p []int, n int) {
+func permute(p []int, n int, s *Sampler) {
 	for i := n - 1; i > 0; i-- {
-		target := int(rand.Float64() * (float64(i) + 1))
+		target := int(s.Float64() * (float64(i) + 1))
 		p[i], p[target] = p[target], p[i]
 	}
 }
 
-func perlinGeneratePerm() []int {
+func perlinGeneratePerm(s *Sampler) []int {
 	p := make([]int, 256)
 	for i := 0; i < 256; i++ {
 		p[i] = i
 	}
-	permute(p, 256)
+	permute(p, 256, s)
 	return p
 }
 
@@ -103,7 +105,7 @@ func PerlinInterp(c *[2][2][2]Vec3, u, v, w float64) float64 {
 				weightV := NewVec3(u-float64(i), v-float64(j), w-float64(k))
 				newAccum := (float64(i)*uu + (1-float64(i))*(1-uu)) *
 					(float64(j)*vv + (1-float64(j))*(1-vv)) *
-					(float64(k)*ww + (1-float64(k))*(1-ww)) * 0.5 * (1 - Dot(&c[i][j][k], weightV))
+					(float64(k)*ww + (1-float64(k))*(1-ww)) * 0.5 * (1 - Dot(c[i][j][k], weightV))
 				accum += newAccum
 			}
 		}
@@ -2,7 +2,6 @@ package geometry
 
 import (
 	"math"
-	"math/rand"
 )
 
 //TODO: Complete Hitable funcs to YZ and XY
@@ -27,12 +26,12 @@ func NewXYRect(_x0, _x1, _y0, _y1, _k float64, _mat Material) *XYRect {
 	}
 }
 
-func (rect XYRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	*box = *NewAabb(NewVec3(rect.X0, rect.Y0, rect.K-0.0001), NewVec3(rect.X1, rect.Y1, rect.K+0.0001))
+func (rect *XYRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	*box = NewAabb(NewVec3(rect.X0, rect.Y0, rect.K-0.0001), NewVec3(rect.X1, rect.Y1, rect.K+0.0001))
 	return true
 }
 
-func (rect XYRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
+func (rect *XYRect) Hit(r Ray, t0, t1 float64, rec *HitRecord) bool {
 	t := (rect.K - r.Origin().Z()) / r.Direction().Z()
 	if t < t0 || t > t1 {
 		return false
@@ -52,11 +51,11 @@ func (rect XYRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
 	return true
 }
 
-func (rect XYRect) PdfValue(o, v *Vec3) float64 {
+func (rect *XYRect) PdfValue(o, v Vec3) float64 {
 	return 0.0
 }
 
-func (rect XYRect) Random(o *Vec3) *Vec3 {
+func (rect *XYRect) Random(o Vec3, s *Sampler) Vec3 {
 	return NewVec3(1, 0, 0)
 }
 
@@ -81,12 +80,12 @@ func NewXZRect(_x0, _x1, _z0, _z1, _k float64, _mat Material) *XZRect {
 	}
 }
 
-func (rect XZRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	*box = *NewAabb(NewVec3(rect.X0, rect.K-0.0001, rect.Z0), NewVec3(rect.X1, rect.K+0.0001, rect.Z1))
+func (rect *XZRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	*box = NewAabb(NewVec3(rect.X0, rect.K-0.0001, rect.Z0), NewVec3(rect.X1, rect.K+0.0001, rect.Z1))
 	return true
 }
 
-func (rect XZRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
+func (rect *XZRect) Hit(r Ray, t0, t1 float64, rec *HitRecord) bool {
 	t := (rect.K - r.Origin().Y()) / r.Direction().Y()
 	if t < t0 || t > t1 {
 		return false
@@ -106,7 +105,7 @@ func (rect XZRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
 	return true
 }
 
-func (rect XZRect) PdfValue(o, v *Vec3) float64 {
+func (rect *XZRect) PdfValue(o, v Vec3) float64 {
 	rec := new(HitRecord)
 	if rect.Hit(NewRay(o, v), 0.001, math.MaxFloat64, rec) {
 		area := (rect.X1 - rect.X0) * (rect.Z1 - rect.Z0)
@@ -117,8 +116,8 @@ func (rect XZRect) PdfValue(o, v *Vec3) float64 {
 	return 0.0
 }
 
-func (rect XZRect) Random(o *Vec3) *Vec3 {
-	randomPoint := NewVec3(rect.X0+rand.Float64()*(rect.X1-rect.X0), rect.K, rect.Z0+rand.Float64()*(rect.Z1-rect.Z0))
+func (rect *XZRect) Random(o Vec3, s *Sampler) Vec3 {
+	randomPoint := NewVec3(rect.X0+s.Float64()*(rect.X1-rect.X0), rect.K, rect.Z0+s.Float64()*(rect.Z1-rect.Z0))
 	return randomPoint.Minus(o)
 }
 
@@ -143,12 +142,12 @@ func NewYZRect(_y0, _y1, _z0, _z1, _k float64, _mat Material) *YZRect {
 	}
 }
 
-func (rect YZRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
-	*box = *NewAabb(NewVec3(rect.K-0.0001, rect.Y0, rect.Z0), NewVec3(rect.K+0.0001, rect.Y1, rect.Z1))
+func (rect *YZRect) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	*box = NewAabb(NewVec3(rect.K-0.0001, rect.Y0, rect.Z0), NewVec3(rect.K+0.0001, rect.Y1, rect.Z1))
 	return true
 }
 
-func (rect YZRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
+func (rect *YZRect) Hit(r Ray, t0, t1 float64, rec *HitRecord) bool {
 	t := (rect.K - r.Origin().X()) / r.Direction().X()
 	if t < t0 || t > t1 {
 		return false
@@ -168,10 +167,10 @@ func (rect YZRect) Hit(r *Ray, t0, t1 float64, rec *HitRecord) bool {
 	return true
 }
 
-func (rect YZRect) PdfValue(o, v *Vec3) float64 {
+func (rect *YZRect) PdfValue(o, v Vec3) float64 {
 	return 0.0
 }
 
-func (rect YZRect) Random(o *Vec3) *Vec3 {
+func (rect *YZRect) Random(o Vec3, s *Sampler) Vec3 {
 	return NewVec3(1, 0, 0)
 }
@@ -0,0 +1,47 @@
+package geometry
+
+// Sampler is a small deterministic random number generator (SplitMix64).
+// It is not safe for concurrent use : each goroutine owns its own Sampler and
+// passes it down to everything that needs random numbers.
+type Sampler struct {
+	state uint64
+}
+
+// NewSampler instantiate a new Sampler from a seed
+func NewSampler(seed uint64) *Sampler {
+	return &Sampler{state: seed}
+}
+
+// Seed reset the Sampler to the given seed
+func (s *Sampler) Seed(seed uint64) {
+	s.state = seed
+}
+
+// SeedPixel reset the Sampler to a state depending only on the image seed,
+// the pixel (x, y) and the index of the sample, so that every sample of an
+// image is reproducible whatever the order in which pixels are computed
+func (s *Sampler) SeedPixel(imageSeed uint64, x, y, sample int) {
+	h := mix64(imageSeed)
+	h = mix64(h ^ uint64(x))
+	h = mix64(h ^ uint64(y))
+	h = mix64(h ^ uint64(sample))
+	s.state = h
+}
+
+// Uint64 return a pseudo-random 64 bits integer
+func (s *Sampler) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return mix64(s.state)
+}
+
+// Float64 return a pseudo-random number in [0.0, 1.0)
+func (s *Sampler) Float64() float64 {
+	return float64(s.Uint64()>>11) / (1 << 53)
+}
+
+// mix64 is the finalizer of SplitMix64
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -4,22 +4,22 @@ import "math"
 
 // Interface
 type Texture interface {
-	Value(u, v float64, p *Vec3) *Vec3
+	Value(u, v float64, p Vec3) Vec3
 }
 
 // Structs
 // Constant
 type ConstantTexture struct {
-	Color *Vec3
+	Color Vec3
 }
 
-func NewConstantTexture(color *Vec3) *ConstantTexture {
+func NewConstantTexture(color Vec3) *ConstantTexture {
 	return &ConstantTexture{
 		Color: color,
 	}
 }
 
-func (tex ConstantTexture) Value(u, v float64, p *Vec3) *Vec3 {
+func (tex ConstantTexture) Value(u, v float64, p Vec3) Vec3 {
 	return tex.Color
 }
 
@@ -36,7 +36,7 @@ func NewCheckerTexture(t0, t1 Texture) *CheckerTexture {
 	}
 }
 
-func (tex CheckerTexture) Value(u, v float64, p *Vec3) *Vec3 {
+func (tex CheckerTexture) Value(u, v float64, p Vec3) Vec3 {
 	sines := math.Sin(10*p.X()) * math.Sin(10*p.Y()) * math.Sin(10*p.Z())
 	if sines < 0 {
 		return tex.Odd.Value(u, v, p)
@@ -48,17 +48,70 @@ func (tex CheckerTexture) Value(u, v float64, p *Vec3) *Vec3 {
 type NoiseTexture struct {
 	Noise *Perlin
 	Scale float64
+	// Seed is the seed of Noise
+	Seed uint64
 }
 
-func NewNoiseTexture(scale float64) *NoiseTexture {
+// NewNoiseTexture instantiate a marble-like texture, the noise being fully
+// determined by the seed
+func NewNoiseTexture(scale float64, seed uint64) *NoiseTexture {
 	return &NoiseTexture{
-		Noise: NewPerlin(),
+		Noise: NewPerlin(seed),
 		Scale: scale,
+		Seed:  seed,
 	}
 }
 
-func (tex NoiseTexture) Value(u, v float64, p *Vec3) *Vec3 {
+func (tex NoiseTexture) Value(u, v float64, p Vec3) Vec3 {
 	//return NewVec3(1, 1, 1).TimesScalar(0.5 * (1 + tex.Noise.Turb(p, 7)))
 	//return NewVec3(1, 1, 1).TimesScalar(tex.Noise.Turb(p.TimesScalar(tex.Scale), 7))
 	return NewVec3(1, 1, 1).TimesScalar(0.5 * (1 + math.Sin(tex.Scale*p.Z()+10*tex.Noise.Turb(p.TimesScalar(tex.Scale), 7))))
 }
+
+// ImageTexture maps an image on the texture coordinates, (0, 0) being the
+// bottom left corner of the image and (1, 1) the top right one. The image is
+// repeated outside and its pixels are bilinearly interpolated.
+type ImageTexture struct {
+	Width  int
+	Height int
+	// Pixels are the linear colors of the image, the first row being the top
+	Pixels []Vec3
+}
+
+// NewImageTexture instantiate a new ImageTexture
+func NewImageTexture(width, height int, pixels []Vec3) *ImageTexture {
+	if width <= 0 || height <= 0 || len(pixels) != width*height {
+		panic("the pixels do not match the size of the image texture")
+	}
+	return &ImageTexture{
+		Width:  width,
+		Height: height,
+		Pixels: pixels,
+	}
+}
+
+func (tex ImageTexture) Value(u, v float64, p Vec3) Vec3 {
+	// the centers of the pixels are at the half coordinates
+	x := (u-math.Floor(u))*float64(tex.Width) - 0.5
+	y := (1-(v-math.Floor(v)))*float64(tex.Height) - 0.5
+	x0 := math.Floor(x)
+	y0 := math.Floor(y)
+	fx := x - x0
+	fy := y - y0
+	top := tex.pixel(int(x0), int(y0)).TimesScalar(1 - fx).Plus(tex.pixel(int(x0)+1, int(y0)).TimesScalar(fx))
+	bottom := tex.pixel(int(x0), int(y0)+1).TimesScalar(1 - fx).Plus(tex.pixel(int(x0)+1, int(y0)+1).TimesScalar(fx))
+	return top.TimesScalar(1 - fy).Plus(bottom.TimesScalar(fy))
+}
+
+// pixel return the pixel (x, y) of the repeated image
+func (tex ImageTexture) pixel(x, y int) Vec3 {
+	x %= tex.Width
+	if x < 0 {
+		x += tex.Width
+	}
+	y %= tex.Height
+	if y < 0 {
+		y += tex.Height
+	}
+	return tex.Pixels[y*tex.Width+x]
+}
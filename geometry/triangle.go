@@ -0,0 +1,166 @@
+package geometry
+
+import (
+	"math"
+)
+
+// Triangle is a triangle of vertices P0, P1, P2, whose front side is the one
+// seen with the vertices counterclockwise. The normals and texture
+// coordinates of the vertices are optional.
+type Triangle struct {
+	P0  Vec3
+	P1  Vec3
+	P2  Vec3
+	Mat Material
+	// N0, N1, N2 are the normals at the vertices, interpolated for smooth
+	// shading when HasNormals
+	N0         Vec3
+	N1         Vec3
+	N2         Vec3
+	HasNormals bool
+	// UV0, UV1, UV2 are the texture coordinates (u, v, 0) of the vertices,
+	// interpolated when HasUVs. Otherwise U and V are the barycentric
+	// coordinates of the hit point.
+	UV0    Vec3
+	UV1    Vec3
+	UV2    Vec3
+	HasUVs bool
+}
+
+// NewTriangle instantiate a new flat Triangle
+func NewTriangle(p0, p1, p2 Vec3, mat Material) *Triangle {
+	return &Triangle{
+		P0:  p0,
+		P1:  p1,
+		P2:  p2,
+		Mat: mat,
+	}
+}
+
+// SetNormals set the normals at the vertices for smooth shading
+func (tri *Triangle) SetNormals(n0, n1, n2 Vec3) {
+	tri.N0 = n0
+	tri.N1 = n1
+	tri.N2 = n2
+	tri.HasNormals = true
+}
+
+// SetUVs set the texture coordinates of the vertices
+func (tri *Triangle) SetUVs(uv0, uv1, uv2 Vec3) {
+	tri.UV0 = uv0
+	tri.UV1 = uv1
+	tri.UV2 = uv2
+	tri.HasUVs = true
+}
+
+// Hit test if the triangle is hit
+func (tri *Triangle) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+	t, b1, b2, ok := intersectTriangle(tri.P0, tri.P1, tri.P2, r, tMin, tMax)
+	if !ok {
+		return false
+	}
+	b0 := 1 - b1 - b2
+	rec.T = t
+	rec.P = r.PointAt(t)
+	rec.MatPtr = tri.Mat
+	if tri.HasUVs {
+		uv := tri.UV0.TimesScalar(b0).Plus(tri.UV1.TimesScalar(b1)).Plus(tri.UV2.TimesScalar(b2))
+		rec.U = uv.X()
+		rec.V = uv.Y()
+	} else {
+		rec.U = b1
+		rec.V = b2
+	}
+	if tri.HasNormals {
+		rec.Normal = tri.N0.TimesScalar(b0).Plus(tri.N1.TimesScalar(b1)).Plus(tri.N2.TimesScalar(b2)).UnitVector()
+	} else {
+		rec.Normal = Cross(tri.P1.Minus(tri.P0), tri.P2.Minus(tri.P0)).UnitVector()
+	}
+	return true
+}
+
+func (tri *Triangle) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	*box = triangleBox(tri.P0, tri.P1, tri.P2)
+	return true
+}
+
+func (tri *Triangle) PdfValue(o, v Vec3) float64 {
+	t, _, _, ok := intersectTriangle(tri.P0, tri.P1, tri.P2, NewRay(o, v), 0.001, math.MaxFloat64)
+	if !ok {
+		return 0.0
+	}
+	return trianglePdf(tri.P0, tri.P1, tri.P2, v, t, triangleArea(tri.P0, tri.P1, tri.P2))
+}
+
+func (tri *Triangle) Random(o Vec3, s *Sampler) Vec3 {
+	return randomInTriangle(tri.P0, tri.P1, tri.P2, s).Minus(o)
+}
+
+// intersectTriangle return the distance and the barycentric coordinates b1,
+// b2 of the hit of the triangle p0, p1, p2 between tMin and tMax, computed
+// with the Möller–Trumbore algorithm
+func intersectTriangle(p0, p1, p2 Vec3, r Ray, tMin, tMax float64) (float64, float64, float64, bool) {
+	e1 := p1.Minus(p0)
+	e2 := p2.Minus(p0)
+	pvec := Cross(r.Direction(), e2)
+	det := Dot(e1, pvec)
+	// the ray is parallel to the triangle
+	if math.Abs(det) < 1e-12 {
+		return 0, 0, 0, false
+	}
+	invDet := 1 / det
+	tvec := r.Origin().Minus(p0)
+	b1 := Dot(tvec, pvec) * invDet
+	if b1 < 0 || b1 > 1 {
+		return 0, 0, 0, false
+	}
+	qvec := Cross(tvec, e1)
+	b2 := Dot(r.Direction(), qvec) * invDet
+	if b2 < 0 || b1+b2 > 1 {
+		return 0, 0, 0, false
+	}
+	t := Dot(e2, qvec) * invDet
+	if t < tMin || t > tMax {
+		return 0, 0, 0, false
+	}
+	return t, b1, b2, true
+}
+
+// triangleBox return the bounding box of a triangle, padded as the rects
+// when it is aligned with an axis
+func triangleBox(p0, p1, p2 Vec3) Aabb {
+	min := NewVec3(0, 0, 0)
+	max := NewVec3(0, 0, 0)
+	for i := 0; i < 3; i++ {
+		lo := math.Min(p0.At(i), math.Min(p1.At(i), p2.At(i)))
+		hi := math.Max(p0.At(i), math.Max(p1.At(i), p2.At(i)))
+		if hi-lo < 0.0002 {
+			lo -= 0.0001
+			hi += 0.0001
+		}
+		min.SetAt(i, lo)
+		max.SetAt(i, hi)
+	}
+	return NewAabb(min, max)
+}
+
+func triangleArea(p0, p1, p2 Vec3) float64 {
+	return Cross(p1.Minus(p0), p2.Minus(p0)).Length() / 2
+}
+
+// trianglePdf return the density in solid angle of the direction v hitting
+// the triangle p0, p1, p2 at the distance t, for points sampled uniformly on
+// an area
+func trianglePdf(p0, p1, p2, v Vec3, t, area float64) float64 {
+	normal := Cross(p1.Minus(p0), p2.Minus(p0)).UnitVector()
+	distanceSquared := t * t * v.SquaredLength()
+	cosine := math.Abs(Dot(v, normal) / v.Length())
+	return distanceSquared / (cosine * area)
+}
+
+// randomInTriangle return a point uniformly distributed on a triangle
+func randomInTriangle(p0, p1, p2 Vec3, s *Sampler) Vec3 {
+	r1 := math.Sqrt(s.Float64())
+	r2 := s.Float64()
+	return p0.TimesScalar(1 - r1).Plus(p1.TimesScalar(r1 * (1 - r2))).Plus(p2.TimesScalar(r1 * r2))
+}
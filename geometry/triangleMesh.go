@@ -0,0 +1,203 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// TriangleMesh is a mesh of triangles sharing the buffers of their vertices,
+// hit through its own bounding volume hierarchy. As a light, its points are
+// sampled uniformly over its area.
+type TriangleMesh struct {
+	Positions []Vec3
+	// Normals are the normals of the vertices, interpolated for smooth
+	// shading, nil for flat triangles. The triangles with a null normal at
+	// one of their vertices are flat.
+	Normals []Vec3
+	// UVs are the texture coordinates (u, v, 0) of the vertices, nil for the
+	// barycentric coordinates of the hit point
+	UVs []Vec3
+	// Indices are the indices of the three vertices of each triangle
+	Indices []int
+	// Materials are the materials of the triangles, MaterialIndices being
+	// the index of the material of each triangle. All the triangles have the
+	// first material when MaterialIndices is nil.
+	Materials       []Material
+	MaterialIndices []int
+	bvh             *LinearBVH
+	// cdf are the cumulated areas of the triangles of the hierarchy
+	cdf []float64
+}
+
+// meshTriangle is a triangle of a mesh, a primitive of its hierarchy
+type meshTriangle struct {
+	mesh  *TriangleMesh
+	index int
+}
+
+// NewTriangleMesh instantiate a new TriangleMesh of a single material, the
+// normals and uvs being nil or given for each vertex. The degenerate
+// triangles are left out of the hierarchy.
+func NewTriangleMesh(positions, normals, uvs []Vec3, indices []int, mat Material) *TriangleMesh {
+	if len(indices)%3 != 0 {
+		panic("the number of indices of a mesh must be a multiple of 3")
+	}
+	if (normals != nil && len(normals) != len(positions)) || (uvs != nil && len(uvs) != len(positions)) {
+		panic("the normals and uvs of a mesh must match its positions")
+	}
+	for i := 0; i < len(indices); i++ {
+		if indices[i] < 0 || indices[i] >= len(positions) {
+			panic("index out of range in the triangle mesh")
+		}
+	}
+	mesh := &TriangleMesh{
+		Positions: positions,
+		Normals:   normals,
+		UVs:       uvs,
+		Indices:   indices,
+		Materials: []Material{mat},
+	}
+	var list []Hitable
+	area := 0.0
+	for i := 0; i < len(indices)/3; i++ {
+		p0, p1, p2 := mesh.vertices(i)
+		a := triangleArea(p0, p1, p2)
+		if a == 0 {
+			continue
+		}
+		area += a
+		list = append(list, &meshTriangle{mesh: mesh, index: i})
+	}
+	if len(list) == 0 {
+		panic("the mesh has no triangle")
+	}
+	mesh.bvh = NewLinearBVH(NewHitableList(&list, len(list)), 0, 1, BVHOptions{Strategy: BVHSAH})
+	// the cumulated areas in the order of the primitives of the hierarchy
+	prims := mesh.bvh.Primitives()
+	mesh.cdf = make([]float64, len(prims))
+	area = 0
+	for i := 0; i < len(prims); i++ {
+		p0, p1, p2 := mesh.vertices(prims[i].(*meshTriangle).index)
+		area += triangleArea(p0, p1, p2)
+		mesh.cdf[i] = area
+	}
+	return mesh
+}
+
+// SetMaterials set the materials of the triangles, materialIndices giving
+// the index in materials of the material of each triangle
+func (mesh *TriangleMesh) SetMaterials(materials []Material, materialIndices []int) {
+	if len(materialIndices) != len(mesh.Indices)/3 {
+		panic("the mesh needs a material index for each triangle")
+	}
+	for i := 0; i < len(materialIndices); i++ {
+		if materialIndices[i] < 0 || materialIndices[i] >= len(materials) {
+			panic("material index out of range in the triangle mesh")
+		}
+	}
+	mesh.Materials = materials
+	mesh.MaterialIndices = materialIndices
+}
+
+// Len return the number of triangles of the mesh
+func (mesh *TriangleMesh) Len() int {
+	return len(mesh.Indices) / 3
+}
+
+// Area return the area of the triangles of the mesh
+func (mesh *TriangleMesh) Area() float64 {
+	return mesh.cdf[len(mesh.cdf)-1]
+}
+
+// vertices return the positions of the vertices of the i-th triangle
+func (mesh *TriangleMesh) vertices(i int) (Vec3, Vec3, Vec3) {
+	return mesh.Positions[mesh.Indices[3*i]], mesh.Positions[mesh.Indices[3*i+1]], mesh.Positions[mesh.Indices[3*i+2]]
+}
+
+func (mesh *TriangleMesh) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+	return mesh.bvh.Hit(r, tMin, tMax, rec)
+}
+
+func (mesh *TriangleMesh) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	return mesh.bvh.BoundingBox(t0, t1, box)
+}
+
+func (mesh *TriangleMesh) PdfValue(o, v Vec3) float64 {
+	// a direction can reach the mesh through several triangles, the density
+	// of each point of the mesh seen along it adds up
+	r := NewRay(o, v)
+	area := mesh.Area()
+	pdf := 0.0
+	mesh.bvh.crossPrimitives(r, 0.001, math.MaxFloat64, func(i int) {
+		p0, p1, p2 := mesh.vertices(mesh.bvh.prims[i].(*meshTriangle).index)
+		if t, _, _, ok := intersectTriangle(p0, p1, p2, r, 0.001, math.MaxFloat64); ok {
+			pdf += trianglePdf(p0, p1, p2, v, t, area)
+		}
+	})
+	return pdf
+}
+
+func (mesh *TriangleMesh) Random(o Vec3, s *Sampler) Vec3 {
+	// the triangles are chosen in proportion to their area
+	target := s.Float64() * mesh.Area()
+	i := sort.SearchFloat64s(mesh.cdf, target)
+	if i == len(mesh.cdf) {
+		i--
+	}
+	p0, p1, p2 := mesh.vertices(mesh.bvh.prims[i].(*meshTriangle).index)
+	return randomInTriangle(p0, p1, p2, s).Minus(o)
+}
+
+func (tri *meshTriangle) Hit(r Ray, tMin float64, tMax float64, rec *HitRecord) bool {
+	mesh := tri.mesh
+	i0 := mesh.Indices[3*tri.index]
+	i1 := mesh.Indices[3*tri.index+1]
+	i2 := mesh.Indices[3*tri.index+2]
+	p0 := mesh.Positions[i0]
+	p1 := mesh.Positions[i1]
+	p2 := mesh.Positions[i2]
+	t, b1, b2, ok := intersectTriangle(p0, p1, p2, r, tMin, tMax)
+	if !ok {
+		return false
+	}
+	b0 := 1 - b1 - b2
+	rec.T = t
+	rec.P = r.PointAt(t)
+	if mesh.MaterialIndices != nil {
+		rec.MatPtr = mesh.Materials[mesh.MaterialIndices[tri.index]]
+	} else {
+		rec.MatPtr = mesh.Materials[0]
+	}
+	if mesh.UVs != nil {
+		uv := mesh.UVs[i0].TimesScalar(b0).Plus(mesh.UVs[i1].TimesScalar(b1)).Plus(mesh.UVs[i2].TimesScalar(b2))
+		rec.U = uv.X()
+		rec.V = uv.Y()
+	} else {
+		rec.U = b1
+		rec.V = b2
+	}
+	rec.Normal = Cross(p1.Minus(p0), p2.Minus(p0)).UnitVector()
+	if mesh.Normals != nil {
+		n0 := mesh.Normals[i0]
+		n1 := mesh.Normals[i1]
+		n2 := mesh.Normals[i2]
+		if n0.SquaredLength() > 0 && n1.SquaredLength() > 0 && n2.SquaredLength() > 0 {
+			rec.Normal = n0.TimesScalar(b0).Plus(n1.TimesScalar(b1)).Plus(n2.TimesScalar(b2)).UnitVector()
+		}
+	}
+	return true
+}
+
+func (tri *meshTriangle) BoundingBox(t0, t1 float64, box *Aabb) bool {
+	p0, p1, p2 := tri.mesh.vertices(tri.index)
+	*box = triangleBox(p0, p1, p2)
+	return true
+}
+
+func (tri *meshTriangle) PdfValue(o, v Vec3) float64 {
+	return 0.0
+}
+
+func (tri *meshTriangle) Random(o Vec3, s *Sampler) Vec3 {
+	return NewVec3(1, 0, 0)
+}
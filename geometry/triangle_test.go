@@ -0,0 +1,119 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTriangleHit(t *testing.T) {
+	tri := NewTriangle(NewVec3(0, 0, 0), NewVec3(1, 0, 0), NewVec3(0, 1, 0), Lambertian{})
+	var rec HitRecord
+	if !tri.Hit(NewRay(NewVec3(0.25, 0.5, -1), NewVec3(0, 0, 1)), 0.001, 10, &rec) {
+		t.Fatal("the triangle is missed")
+	}
+	// without texture coordinates U and V are the barycentric ones
+	if rec.T != 1 || rec.U != 0.25 || rec.V != 0.5 || rec.Normal != NewVec3(0, 0, 1) {
+		t.Errorf("hit at %v, uv %v %v, normal %v", rec.T, rec.U, rec.V, rec.Normal)
+	}
+
+	tri.SetUVs(NewVec3(0, 0, 0), NewVec3(2, 0, 0), NewVec3(0, 4, 0))
+	tri.SetNormals(NewVec3(0, 0, 1), NewVec3(1, 0, 0), NewVec3(0, 0, 1))
+	tri.Hit(NewRay(NewVec3(0.5, 0.25, -1), NewVec3(0, 0, 1)), 0.001, 10, &rec)
+	want := NewVec3(1, 0, 1).UnitVector()
+	if rec.U != 1 || rec.V != 1 || rec.Normal.Minus(want).Length() > 1e-12 {
+		t.Errorf("uv %v %v, normal %v instead of 1 1 %v", rec.U, rec.V, rec.Normal, want)
+	}
+
+	// a miss leaves the record untouched
+	before := rec
+	misses := []Ray{
+		NewRay(NewVec3(0.75, 0.75, -1), NewVec3(0, 0, 1)),
+		NewRay(NewVec3(0.25, 0.25, -1), NewVec3(1, 0, 0)),
+		NewRay(NewVec3(0.25, 0.25, 1), NewVec3(0, 0, 1)),
+	}
+	for i := 0; i < len(misses); i++ {
+		if tri.Hit(misses[i], 0.001, 10, &rec) || rec != before {
+			t.Errorf("ray %v hits the triangle", i)
+		}
+	}
+}
+
+func TestTriangleMeshHit(t *testing.T) {
+	s := NewSampler(5)
+	// a grid of quads, two triangles each, sharing their vertices
+	const n = 6
+	var positions []Vec3
+	for y := 0; y <= n; y++ {
+		for x := 0; x <= n; x++ {
+			positions = append(positions, NewVec3(float64(x), float64(y), 0.3*math.Sin(float64(x+2*y))))
+		}
+	}
+	var indices []int
+	var triangles []Hitable
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			i := y*(n+1) + x
+			quad := []int{i, i + 1, i + n + 2, i, i + n + 2, i + n + 1}
+			indices = append(indices, quad...)
+			for j := 0; j < 6; j += 3 {
+				triangles = append(triangles, NewTriangle(positions[quad[j]], positions[quad[j+1]], positions[quad[j+2]], Lambertian{}))
+			}
+		}
+	}
+	list := NewHitableList(&triangles, len(triangles))
+	mesh := NewTriangleMesh(positions, nil, nil, indices, Lambertian{})
+	if math.Abs(mesh.Area()-triangleListArea(list)) > 1e-9 {
+		t.Errorf("area %v instead of %v", mesh.Area(), triangleListArea(list))
+	}
+	for i := 0; i < 2000; i++ {
+		origin := NewVec3(n*s.Float64(), n*s.Float64(), -2)
+		r := NewRay(origin, NewVec3(s.Float64()-0.5, s.Float64()-0.5, 1))
+		var want, got HitRecord
+		wantHit := list.Hit(r, 0.001, 1e9, &want)
+		gotHit := mesh.Hit(r, 0.001, 1e9, &got)
+		if wantHit != gotHit || (wantHit && (math.Abs(want.T-got.T) > 1e-9 || want.Normal.Minus(got.Normal).Length() > 1e-9)) {
+			t.Fatalf("ray %v hits the mesh %v at %v, the triangles %v at %v", i, gotHit, got.T, wantHit, want.T)
+		}
+	}
+}
+
+// triangleListArea return the total area of a list of triangles
+func triangleListArea(l *HitableList) float64 {
+	area := 0.0
+	for i := 0; i < l.listSize; i++ {
+		tri := l.list[i].(*Triangle)
+		area += triangleArea(tri.P0, tri.P1, tri.P2)
+	}
+	return area
+}
+
+func TestTriangleMeshPdf(t *testing.T) {
+	s := NewSampler(3)
+	// two squares one above the other, the upper one partly seen through the
+	// lower one from the origin
+	positions := []Vec3{
+		NewVec3(-2, -2, 1), NewVec3(2, -2, 1), NewVec3(2, 2, 1), NewVec3(-2, 2, 1),
+		NewVec3(-2, -2, 2), NewVec3(2, -2, 2), NewVec3(2, 2, 2), NewVec3(-2, 2, 2),
+	}
+	indices := []int{0, 1, 2, 0, 2, 3, 4, 5, 6, 4, 6, 7}
+	mesh := NewTriangleMesh(positions, nil, nil, indices, Lambertian{})
+	o := NewVec3(0, 0, 0)
+	// the density integrates to 1 over the directions, estimated with
+	// uniform directions of pdf 1/4π
+	const n = 400000
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		sum += mesh.PdfValue(o, randomOnUnitSphere(s)) * 4 * math.Pi
+	}
+	if integral := sum / n; math.Abs(integral-1) > 0.03 {
+		t.Errorf("the density integrates to %v", integral)
+	}
+	// the directions toward the upper square through the lower one have the
+	// density of both squares
+	v := NewVec3(0.1, 0.2, 1)
+	want := trianglePdf(positions[0], positions[1], positions[2], v, 1, mesh.Area()) +
+		trianglePdf(positions[4], positions[5], positions[6], v, 2, mesh.Area())
+	if got := mesh.PdfValue(o, v); math.Abs(got-want) > 1e-9 {
+		t.Errorf("density %v instead of %v", got, want)
+	}
+}
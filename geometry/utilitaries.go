@@ -1,7 +1,5 @@
 package geometry
 
-import "math/rand"
-
 func Fmin(a, b float64) float64 {
 	if a < b {
 		return a
@@ -15,7 +13,3 @@ func Fmax(a, b float64) float64 {
 	}
 	return b
 }
-
-func drand48() float64 {
-	return rand.Float64()
-}
@@ -0,0 +1,3 @@
+module github.com/AureClai/RayTracingGoTest
+
+go 1.22
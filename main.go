@@ -1,233 +1,133 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"math"
-	"math/rand"
+	"io"
 	"os"
-	"time"
+	"strconv"
+	"strings"
 
-	geom "./geometry"
-	"./view"
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
 )
 
-/*
-	WIDTH is the number of pixel on the X-Axis > 200 recommended
-	HEIGHT is the number of pixels on the Y-Axis > 200 recommended
-	SAMPLES is the number of random rays used to estimate the color of one pixel :
-		- > 10 for first test
-		- > 100 for pretty good render
-		- > 1000 for very good render - VERY lONG
-*/
-// WIDTH unexported
-const WIDTH int = 400
-
-// HEIGHT unexported
-const HEIGHT int = 400
-
-// SAMPLES unexported
-const SAMPLES int = 100
-
-// WALL COLORS (float64 beetween 0.0 and 1.0)
-// initial red(0.65,0.05,0.5) for left and green(0.12,0.45,0.15) for right
-// Left Wall
-const LeftWallR float64 = 0.65
-const LeftWallG float64 = 0.05
-const LeftWallB float64 = 0.05
-
-//Right Wall
-const RightWallR float64 = 0.45
-const RightWallG float64 = 0.45
-const RightWallB float64 = 0.45
-
-func checkColors() {
-	if LeftWallR > 1 || LeftWallR < 0 ||
-		LeftWallG > 1 || LeftWallG < 0 ||
-		LeftWallB > 1 || LeftWallB < 0 ||
-		RightWallR > 1 || RightWallR < 0 ||
-		RightWallG > 1 || RightWallG < 0 ||
-		RightWallB > 1 || RightWallB < 0 {
-		panic("Invalid colors for walls")
-	}
+// usage is the help of the program, the flags of each command being listed
+// by its -h flag
+const usage = `RayTracingGoTest renders scenes with a path tracer.
 
-}
+Usage:
 
-func drand48() float64 {
-	return rand.Float64()
-}
+	RayTracingGoTest <command> [flags] [arguments]
 
-func writeLines(lines []string, file *os.File) error {
-	for i := 0; i < len(lines); i++ {
-		_, err := fmt.Fprintln(file, lines[i])
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
+Commands:
 
-func color(r *geom.Ray, world geom.Hitable, lightShape geom.Hitable, depth int) *geom.Vec3 {
-	var hrec = geom.HitRecord{}
-
-	if world.Hit(r, 0.001, math.MaxFloat64, &hrec) {
-		srec := geom.ScatterRecord{}
-		var emitted = new(geom.Vec3)
-		var scattered = new(geom.Ray)
-		pdfVal := 0.0
-		*emitted = *hrec.MatPtr.Emitted(r, &hrec, hrec.U, hrec.V, hrec.P)
-		if depth < 50 && hrec.MatPtr.Scatter(r, &hrec, &srec) {
-			if srec.IsSpecular {
-				return srec.Attenuation.Times(color(srec.SpecularRay, world, lightShape, depth+1))
-			} else {
-				pLight := geom.NewHitablePdf(lightShape, hrec.P)
-				p := geom.NewMixturePdf(pLight, srec.PdfPtr)
-				*scattered = *geom.NewRayWithTime(hrec.P, p.Generate(), r.Time())
-				pdfVal = p.Value(scattered.Direction())
-				//fmt.Println("Other")
-				return ((color(scattered, world, lightShape, depth+1).Times(srec.Attenuation.TimesScalar(hrec.MatPtr.ScatteringPdf(r, &hrec, scattered)))).Plus(emitted)).ByScalar(pdfVal)
-			}
-		}
-		return emitted
-	}
-	return geom.NewVec3(0.0, 0.0, 0.0)
-}
+	render       render a scene into an image
+	denoise      denoise an image rendered with its AOVs
+	list-scenes  list the built-in scenes
+	info         describe a scene and the machine
+
+Run "RayTracingGoTest <command> -h" for the flags of a command.
+`
 
-func MakecornellBoxObjects() *geom.HitableList {
-	list := make([]geom.Hitable, 50)
-	leftWall := geom.Lambertian{geom.NewConstantTexture(geom.NewVec3(LeftWallR, LeftWallG, LeftWallB))}
-	white := geom.Lambertian{geom.NewConstantTexture(geom.NewVec3(0.73, 0.73, 0.73))}
-	rightWall := geom.Lambertian{geom.NewConstantTexture(geom.NewVec3(RightWallR, RightWallG, RightWallB))}
-	light := geom.DiffuseLight{Emit: geom.NewConstantTexture(geom.NewVec3(15, 15, 15))}
-	list[0] = geom.NewFlipNormals(geom.NewYZRect(0, 555, 0, 555, 555, rightWall))
-	list[1] = geom.NewYZRect(0, 555, 0, 555, 0, leftWall)
-	list[2] = geom.NewFlipNormals(geom.NewXZRect(213, 343, 227, 332, 554, light))
-	list[3] = geom.NewFlipNormals(geom.NewXZRect(0, 555, 0, 555, 555, white))
-	list[4] = geom.NewXZRect(0, 555, 0, 555, 0, white)
-	list[5] = geom.NewFlipNormals(geom.NewXYRect(0, 555, 0, 555, 555, white))
-	// boxes
-	glass := geom.Dielectric{1.5}
-	//list[6] = geom.NewTranslate(geom.NewRotateY(geom.NewBox(geom.NewVec3(0, 0, 0), geom.NewVec3(165, 165, 165), white), -18), geom.NewVec3(130, 0, 65))
-	list[6] = geom.NewSphere(geom.NewVec3(190, 90, 190), 90, glass)
-	//aluminium := geom.Metal{Albedo: geom.NewVec3(0.8, 0.85, 0.88), Fuzz: 0.0}
-	list[7] = geom.NewTranslate(geom.NewRotateY(geom.NewBox(geom.NewVec3(0, 0, 0), geom.NewVec3(165, 330, 165), white), 15), geom.NewVec3(265, 0, 295))
-	return geom.NewHitableList(&list, 8)
+// errUsage is returned by the commands whose arguments are invalid, the
+// error being already printed with the usage of the command
+var errUsage = errors.New("invalid arguments")
+
+// commands are the commands of the program by name
+var commands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"render":      runRender,
+	"denoise":     runDenoise,
+	"list-scenes": runListScenes,
+	"info":        runInfo,
 }
 
-type Scene struct {
-	Objects  *geom.HitableList
-	Camera   *view.Camera
-	Settings *SceneSettings
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func cornellBox() *Scene {
-	settings := &SceneSettings{
-		Width:   WIDTH,
-		Height:  HEIGHT,
-		Samples: SAMPLES,
+// run execute the command line and return the exit code of the program
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%v", args[0], usage)
+		return 2
 	}
-	var lookFrom = geom.NewVec3(278, 278, -800)
-	var lookAt = geom.NewVec3(278, 278, 0)
-	distToFocus := 10.0
-	aperture := 0.0
-	vfov := 40.0
-	aspect := float64(settings.Width) / float64(settings.Height)
-	var cam = view.NewCamera(lookFrom, lookAt, geom.NewVec3(0, 1, 0), vfov, aspect, aperture, distToFocus, 0.0, 1.0)
-	scene := MakecornellBoxObjects()
-	return &Scene{
-		Objects:  scene,
-		Camera:   cam,
-		Settings: settings,
+	err := cmd(args[1:], stdout, stderr)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
 	}
+	fmt.Fprintf(stderr, "%v: %v\n", args[0], err)
+	return 1
 }
 
-type SceneSettings struct {
-	Width   int
-	Height  int
-	Samples int
+// newFlagSet instantiate the flag set of a command, the usage listing its
+// arguments and flags, which are written with the errors of the flags to
+// stderr
+func newFlagSet(name, arguments, description string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: RayTracingGoTest %v\n\n%v\n\nFlags:\n", strings.TrimSpace(name+" [flags] "+arguments), description)
+		fs.PrintDefaults()
+	}
+	return fs
 }
 
-func deNan(v *geom.Vec3) *geom.Vec3 {
-	x := v.X()
-	y := v.Y()
-	z := v.Z()
-	if x < 0 || math.IsNaN(x) {
-		x = 0.0
-	}
-	if y < 0 || math.IsNaN(y) {
-		y = 0.0
+// parseFlags parse the arguments of a command, returning flag.ErrHelp for -h
+// and errUsage for invalid flags
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
 	}
-	if y < 0 || math.IsNaN(y) {
-		y = 0.0
-	}
-	return geom.NewVec3(x, y, z)
+	return errUsage
 }
 
-func main() {
-	checkColors()
-	// time management
-	start := time.Now()
-	percentage := 0.0
-
-	f, err := os.Create("outputImage.ppm")
-	if err != nil {
-		fmt.Println(err)
-		return
+// colorFlag is a color given as r,g,b with components between 0 and 1
+type colorFlag struct {
+	color geom.Vec3
+	set   bool
+}
+
+func (f *colorFlag) String() string {
+	return fmt.Sprintf("%g,%g,%g", f.color.R(), f.color.G(), f.color.B())
+}
+
+func (f *colorFlag) Set(s string) error {
+	fields := strings.Split(s, ",")
+	if len(fields) != 3 {
+		return fmt.Errorf("a color is three components r,g,b")
 	}
-	//
-	//
-	scene := cornellBox()
-	//
-	var lines = make([]string, scene.Settings.Width*scene.Settings.Height+3)
-	// Header of Picture
-	lines[0] = "P3"
-	lines[1] = fmt.Sprintf("%v %v", scene.Settings.Width, scene.Settings.Height)
-	lines[2] = fmt.Sprintf("%v", 255)
-
-	// Lines
-	for j := scene.Settings.Height - 1; j >= 0; j-- {
-		// Columns
-		for i := 0; i < scene.Settings.Width; i++ {
-			percentage = 100.0 * float64(scene.Settings.Width*(scene.Settings.Height-1-j)+i) / float64(scene.Settings.Width*scene.Settings.Height)
-			fmt.Printf("\r%5.2f %%", percentage)
-			var col = geom.NewVec3(0, 0, 0)
-			lightShape := geom.NewXZRect(213, 343, 227, 332, 554, geom.NewNoMaterial())
-			glassSphere := geom.NewSphere(geom.NewVec3(190, 90, 190), 90, geom.NewNoMaterial())
-			list := make([]geom.Hitable, 2)
-			list[0] = lightShape
-			list[1] = glassSphere
-			hList := geom.NewHitableList(&list, 2)
-			// Samples
-			for s := 0; s < SAMPLES; s++ {
-				u := (float64(i) + rand.Float64()) / float64(scene.Settings.Width)
-				v := (float64(j) + rand.Float64()) / float64(scene.Settings.Height-1)
-				var r = scene.Camera.GetRay(u, v)
-				col = col.Plus(deNan(color(r, scene.Objects, hList, 0)))
-			}
-			col = col.ByScalar(float64(SAMPLES))
-			col = geom.NewVec3(math.Sqrt(col.R()), math.Sqrt(col.G()), math.Sqrt(col.B()))
-			ir := int(255.99 * col.R())
-			ig := int(255.99 * col.G())
-			ib := int(255.99 * col.B())
-			lines[3+scene.Settings.Width*(scene.Settings.Height-1-j)+i] = fmt.Sprintf("%v %v %v", ir, ig, ib)
+	var c [3]float64
+	for i := 0; i < 3; i++ {
+		v, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil {
+			return fmt.Errorf("invalid component %q", fields[i])
 		}
+		if v < 0 || v > 1 {
+			return fmt.Errorf("the components of a color must be between 0 and 1")
+		}
+		c[i] = v
 	}
+	f.color = geom.NewVec3(c[0], c[1], c[2])
+	f.set = true
+	return nil
+}
 
-	// Actually writying the file
-	err = writeLines(lines, f)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	// Closing the file
-	err = f.Close()
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	// Time management and display of end
-	t := time.Now()
-	elapsed := t.Sub(start)
-	fmt.Printf("Calculations made in %v\n", elapsed)
-	fmt.Println("file written successfully")
+// formatVec return the components of a vector as (x, y, z)
+func formatVec(v geom.Vec3) string {
+	return fmt.Sprintf("(%.4g, %.4g, %.4g)", v.X(), v.Y(), v.Z())
 }
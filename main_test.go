@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	exr := filepath.Join(dir, "image.exr")
+	small := []string{"-width", "8", "-height", "6", "-spp", "2"}
+	// the commands run in order, denoise reads the image of the render
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"no command", nil, 2, "", "Usage:"},
+		{"help", []string{"help"}, 0, "Commands:", ""},
+		{"unknown command", []string{"draw"}, 2, "", `unknown command "draw"`},
+		{"list-scenes", []string{"list-scenes"}, 0, "cornell", ""},
+		{"list-scenes argument", []string{"list-scenes", "-all"}, 2, "", "flag provided but not defined: -all"},
+		{"render help", []string{"render", "-h"}, 0, "", "-spp int"},
+		{"render", append([]string{"render", "-o", image}, small...), 0, "written successfully", ""},
+		{"render progressive", append([]string{"render", "-noise-target", "0.5", "-o", image}, small...), 0, "pass 1, 2 samples", ""},
+		{"render aovs", append([]string{"render", "-aovs", "-o", exr}, small...), 0, "written successfully", ""},
+		{"render invalid size", []string{"render", "-width", "0", "-o", image}, 1, "", "render: invalid image size 0x400"},
+		{"render invalid number", []string{"render", "-spp", "many"}, 2, "", `invalid value "many" for flag -spp`},
+		{"render invalid color", []string{"render", "-left-wall", "2,0,0"}, 2, "", "between 0 and 1"},
+		{"render unknown tone mapping", []string{"render", "-tonemap", "filmic"}, 1, "", `render: unknown tone mapping operator "filmic"`},
+		{"render unknown scene", []string{"render", "-scene", "nowhere"}, 1, "", "render: "},
+		{"info", []string{"info", "-width", "8", "-height", "8"}, 0, "objects", ""},
+		{"denoise", []string{"denoise", "-o", filepath.Join(dir, "denoised.png"), exr}, 0, "Denoised in", ""},
+		{"denoise without image", []string{"denoise"}, 2, "", "Usage: RayTracingGoTest denoise"},
+		{"denoise missing image", []string{"denoise", filepath.Join(dir, "missing.exr")}, 1, "", "denoise: "},
+		{"denoise invalid strength", []string{"denoise", "-strength", "0", exr}, 1, "", "invalid denoising strength"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(test.args, &stdout, &stderr)
+		if code != test.code {
+			t.Errorf("%v : exit code %v instead of %v, stderr %q", test.name, code, test.code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), test.stdout) || (test.stdout == "" && stdout.Len() > 0) {
+			t.Errorf("%v : stdout %q instead of %q", test.name, stdout.String(), test.stdout)
+		}
+		if !strings.Contains(stderr.String(), test.stderr) || (test.stderr == "" && stderr.Len() > 0) {
+			t.Errorf("%v : stderr %q instead of %q", test.name, stderr.String(), test.stderr)
+		}
+	}
+
+	f, err := os.Open(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 8 || b.Dy() != 6 {
+		t.Errorf("image of %vx%v instead of 8x6", b.Dx(), b.Dy())
+	}
+}
@@ -0,0 +1,856 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/output"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// GLTF is the content of a glTF file : the meshes of the nodes of its scene
+// placed in the world, and its cameras
+type GLTF struct {
+	// Model has a group for each node with a mesh, of the name of the node
+	Model   *Model
+	Cameras []*Camera
+}
+
+// Camera is a perspective camera of a glTF file, placed in the world
+type Camera struct {
+	Name     string
+	LookFrom geom.Vec3
+	LookAt   geom.Vec3
+	Vup      geom.Vec3
+	// Vfov is the vertical field of view in degrees
+	Vfov float64
+	// Aspect is the aspect ratio of the camera, 0 when the file gives none
+	Aspect float64
+}
+
+// View return the camera, of the given aspect ratio when the file gives none
+func (c *Camera) View(aspect float64) *view.Camera {
+	if c.Aspect != 0 {
+		aspect = c.Aspect
+	}
+	return view.NewCamera(c.LookFrom, c.LookAt, c.Vup, c.Vfov, aspect, 0, c.LookAt.Minus(c.LookFrom).Length(), 0, 1)
+}
+
+// LoadGLTF read the glTF file at path, see ReadGLTF
+func LoadGLTF(path string) (*GLTF, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGLTF(f, path)
+}
+
+// ReadGLTF read a glTF 2.0 file, JSON (.gltf) or binary (.glb). The nodes of
+// its scene are placed by their transforms, the Z axis being reversed for the
+// left-handed cameras of view, and their triangles are read with their
+// normals and texture coordinates. The metallic-roughness materials
+// are approximated by the ones of geometry, with their PNG textures. The
+// animations, skins and morph targets are ignored, as the lights, which
+// geometry has no equivalent of. path names the file in the errors and
+// locates its external buffers and images.
+func ReadGLTF(r io.Reader, path string) (*GLTF, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &gltfReader{
+		path:       path,
+		model:      &Model{},
+		buffers:    map[int][]byte{},
+		images:     map[int]*geom.ImageTexture{},
+		materials:  map[int]int{},
+		noMaterial: -1,
+		visited:    map[int]bool{},
+	}
+	if err := p.read(data); err != nil {
+		return nil, err
+	}
+	return &GLTF{Model: p.model, Cameras: p.cameras}, nil
+}
+
+// gltfDocument is the JSON of a glTF file, of which only the properties
+// used are decoded
+type gltfDocument struct {
+	ExtensionsRequired []string
+	Scene              *int
+	Scenes             []struct {
+		Nodes []int
+	}
+	Nodes     []gltfNode
+	Meshes    []gltfMesh
+	Cameras   []gltfCamera
+	Materials []gltfMaterial
+	Textures  []struct {
+		Source *int
+	}
+	Images      []gltfImage
+	Accessors   []gltfAccessor
+	BufferViews []gltfBufferView
+	Buffers     []gltfBuffer
+}
+
+type gltfNode struct {
+	Name        string
+	Mesh        *int
+	Camera      *int
+	Children    []int
+	Matrix      []float64
+	Translation []float64
+	Rotation    []float64
+	Scale       []float64
+}
+
+type gltfMesh struct {
+	Name       string
+	Primitives []gltfPrimitive
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int
+	Indices    *int
+	Material   *int
+	Mode       *int
+}
+
+type gltfCamera struct {
+	Name        string
+	Type        string
+	Perspective *struct {
+		AspectRatio float64
+		Yfov        float64
+	}
+}
+
+type gltfTextureInfo struct {
+	Index    int
+	TexCoord int
+}
+
+type gltfMaterial struct {
+	Name                 string
+	PbrMetallicRoughness *struct {
+		BaseColorFactor  []float64
+		BaseColorTexture *gltfTextureInfo
+		MetallicFactor   *float64
+		RoughnessFactor  *float64
+	}
+	EmissiveFactor  []float64
+	EmissiveTexture *gltfTextureInfo
+	Extensions      struct {
+		EmissiveStrength *struct {
+			EmissiveStrength *float64
+		} `json:"KHR_materials_emissive_strength"`
+		Transmission *struct {
+			TransmissionFactor float64
+		} `json:"KHR_materials_transmission"`
+		IOR *struct {
+			IOR *float64
+		} `json:"KHR_materials_ior"`
+	}
+}
+
+type gltfImage struct {
+	URI        string
+	MimeType   string
+	BufferView *int
+}
+
+type gltfAccessor struct {
+	BufferView    *int
+	ByteOffset    int
+	ComponentType int
+	Normalized    bool
+	Count         int
+	Type          string
+	Sparse        json.RawMessage
+}
+
+type gltfBufferView struct {
+	Buffer     int
+	ByteOffset int
+	ByteLength int
+	ByteStride int
+}
+
+type gltfBuffer struct {
+	URI        string
+	ByteLength int
+}
+
+// the primitives of triangles, the other ones being points and lines
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+// the magic number and the chunks of the binary files
+const (
+	glbMagic = 0x46546C67
+	glbJSON  = 0x4E4F534A
+	glbBIN   = 0x004E4942
+)
+
+// gltfComponentSizes are the sizes of the component types of the accessors
+var gltfComponentSizes = map[int]int{5120: 1, 5121: 1, 5122: 2, 5123: 2, 5125: 4, 5126: 4}
+
+// gltfTypeSizes are the numbers of components of the types of the accessors
+var gltfTypeSizes = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16}
+
+// gltfExtensions are the extensions which can be required by a file
+var gltfExtensions = map[string]bool{
+	"KHR_materials_emissive_strength": true,
+	"KHR_materials_transmission":      true,
+	"KHR_materials_ior":               true,
+	"KHR_mesh_quantization":           true,
+}
+
+// gltfReader holds the state of the reading of a glTF file
+type gltfReader struct {
+	path  string
+	doc   gltfDocument
+	bin   []byte
+	model *Model
+	// buffers and images are the ones read, by index
+	buffers map[int][]byte
+	images  map[int]*geom.ImageTexture
+	// materials are the indices in the model of the materials of the file,
+	// noMaterial the one of the primitives without material, -1 until used
+	materials  map[int]int
+	noMaterial int
+	cameras    []*Camera
+	visited    map[int]bool
+}
+
+func (p *gltfReader) errorf(format string, args ...interface{}) error {
+	return &Error{Path: p.path, Msg: fmt.Sprintf(format, args...)}
+}
+
+// read read the JSON, or the chunks of a binary file, then the nodes of the
+// scene
+func (p *gltfReader) read(data []byte) error {
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if data, err = p.chunks(data); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, &p.doc); err != nil {
+		return p.errorf("%v", err)
+	}
+	for i := 0; i < len(p.doc.ExtensionsRequired); i++ {
+		if !gltfExtensions[p.doc.ExtensionsRequired[i]] {
+			return p.errorf("the extension %v is not supported", p.doc.ExtensionsRequired[i])
+		}
+	}
+	roots, err := p.roots()
+	if err != nil {
+		return err
+	}
+	// glTF is right-handed, the cameras of view are left-handed : reversing
+	// the Z axis keeps the images from being mirrored
+	root := geom.IdentityMatrix()
+	root[2][2] = -1
+	for i := 0; i < len(roots); i++ {
+		if err := p.node(roots[i], root); err != nil {
+			return err
+		}
+	}
+	// the nodes without triangles have no group
+	groups := p.model.Groups[:0]
+	for i := 0; i < len(p.model.Groups); i++ {
+		if len(p.model.Groups[i].Indices) > 0 {
+			groups = append(groups, p.model.Groups[i])
+		}
+	}
+	p.model.Groups = groups
+	return nil
+}
+
+// chunks return the JSON chunk of a binary file, keeping its binary chunk
+func (p *gltfReader) chunks(data []byte) ([]byte, error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, p.errorf("glTF version %v is not supported", version)
+	}
+	if length := binary.LittleEndian.Uint32(data[8:]); int(length) < len(data) {
+		data = data[:length]
+	}
+	var js []byte
+	for offset := 12; offset+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		typ := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if length > len(data)-offset {
+			return nil, p.errorf("truncated chunk")
+		}
+		switch {
+		case typ == glbJSON && js == nil:
+			js = data[offset : offset+length]
+		case typ == glbBIN && p.bin == nil:
+			p.bin = data[offset : offset+length]
+		}
+		offset += length
+	}
+	if js == nil {
+		return nil, p.errorf("no JSON chunk")
+	}
+	return js, nil
+}
+
+// roots return the root nodes of the scene of the file, the nodes which are
+// no children when it has no scene
+func (p *gltfReader) roots() ([]int, error) {
+	if len(p.doc.Scenes) == 0 {
+		child := map[int]bool{}
+		for i := 0; i < len(p.doc.Nodes); i++ {
+			for j := 0; j < len(p.doc.Nodes[i].Children); j++ {
+				child[p.doc.Nodes[i].Children[j]] = true
+			}
+		}
+		var roots []int
+		for i := 0; i < len(p.doc.Nodes); i++ {
+			if !child[i] {
+				roots = append(roots, i)
+			}
+		}
+		return roots, nil
+	}
+	scene := 0
+	if p.doc.Scene != nil {
+		scene = *p.doc.Scene
+	}
+	if scene < 0 || scene >= len(p.doc.Scenes) {
+		return nil, p.errorf("scene %v out of range", scene)
+	}
+	return p.doc.Scenes[scene].Nodes, nil
+}
+
+// node read a node and its children, parent being the transform of its
+// parent
+func (p *gltfReader) node(index int, parent geom.Matrix) error {
+	if index < 0 || index >= len(p.doc.Nodes) {
+		return p.errorf("node %v out of range", index)
+	}
+	if p.visited[index] {
+		return p.errorf("node %v is twice in the hierarchy", index)
+	}
+	p.visited[index] = true
+	n := p.doc.Nodes[index]
+	local, err := n.transform()
+	if err != nil {
+		return p.errorf("node %v : %v", index, err)
+	}
+	world := parent.Times(local)
+	if n.Mesh != nil {
+		if *n.Mesh < 0 || *n.Mesh >= len(p.doc.Meshes) {
+			return p.errorf("node %v : mesh %v out of range", index, *n.Mesh)
+		}
+		mesh := p.doc.Meshes[*n.Mesh]
+		name := n.Name
+		if name == "" {
+			name = mesh.Name
+		}
+		if name == "" {
+			name = fmt.Sprintf("node%v", index)
+		}
+		group := p.model.Group(name)
+		if group == nil {
+			group = &Group{Name: name}
+			p.model.Groups = append(p.model.Groups, group)
+		}
+		for i := 0; i < len(mesh.Primitives); i++ {
+			if err := p.primitive(mesh.Primitives[i], world, group); err != nil {
+				return p.errorf("mesh %v, primitive %v : %v", *n.Mesh, i, err)
+			}
+		}
+	}
+	if n.Camera != nil {
+		if err := p.camera(*n.Camera, world); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(n.Children); i++ {
+		if err := p.node(n.Children[i], world); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transform return the matrix of a node, or of its translation, rotation
+// and scale
+func (n *gltfNode) transform() (geom.Matrix, error) {
+	if n.Matrix != nil {
+		if len(n.Matrix) != 16 {
+			return geom.Matrix{}, fmt.Errorf("the matrix needs 16 numbers")
+		}
+		// the matrix is stored by column
+		var m geom.Matrix
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				m[i][j] = n.Matrix[4*j+i]
+			}
+		}
+		return m, nil
+	}
+	t, r, s := geom.IdentityMatrix(), geom.IdentityMatrix(), geom.IdentityMatrix()
+	if n.Translation != nil {
+		if len(n.Translation) != 3 {
+			return geom.Matrix{}, fmt.Errorf("the translation needs 3 numbers")
+		}
+		t = geom.Translation(geom.NewVec3(n.Translation[0], n.Translation[1], n.Translation[2]))
+	}
+	if n.Rotation != nil {
+		if len(n.Rotation) != 4 {
+			return geom.Matrix{}, fmt.Errorf("the rotation needs 4 numbers")
+		}
+		r = gltfRotation(n.Rotation[0], n.Rotation[1], n.Rotation[2], n.Rotation[3])
+	}
+	if n.Scale != nil {
+		if len(n.Scale) != 3 {
+			return geom.Matrix{}, fmt.Errorf("the scale needs 3 numbers")
+		}
+		s = geom.Scaling(geom.NewVec3(n.Scale[0], n.Scale[1], n.Scale[2]))
+	}
+	return geom.Compose(s, r, t), nil
+}
+
+// camera add a camera placed by the transform of its node, the cameras
+// looking toward -Z with +Y up. The orthographic cameras are ignored.
+func (p *gltfReader) camera(index int, world geom.Matrix) error {
+	if index < 0 || index >= len(p.doc.Cameras) {
+		return p.errorf("camera %v out of range", index)
+	}
+	c := p.doc.Cameras[index]
+	if c.Type != "perspective" || c.Perspective == nil {
+		return nil
+	}
+	if c.Perspective.Yfov <= 0 || c.Perspective.AspectRatio < 0 {
+		return p.errorf("camera %v : invalid field of view or aspect ratio", index)
+	}
+	p.cameras = append(p.cameras, &Camera{
+		Name:     c.Name,
+		LookFrom: world.Point(geom.NewVec3(0, 0, 0)),
+		LookAt:   world.Point(geom.NewVec3(0, 0, -1)),
+		Vup:      world.Vector(geom.NewVec3(0, 1, 0)),
+		Vfov:     c.Perspective.Yfov * 180 / math.Pi,
+		Aspect:   c.Perspective.AspectRatio,
+	})
+	return nil
+}
+
+// primitive add the triangles of a primitive to a group, its vertices being
+// placed by world. The points and lines are ignored.
+func (p *gltfReader) primitive(prim gltfPrimitive, world geom.Matrix, group *Group) error {
+	mode := gltfTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+	if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+		return nil
+	}
+	position, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return nil
+	}
+	positions, err := p.accessor(position, 3)
+	if err != nil {
+		return err
+	}
+	count := len(positions) / 3
+	var normals, uvs []float64
+	if i, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, err = p.accessor(i, 3); err != nil {
+			return err
+		}
+	}
+	mat, texCoord, err := p.material(prim.Material)
+	if err != nil {
+		return err
+	}
+	if i, ok := prim.Attributes[fmt.Sprintf("TEXCOORD_%v", texCoord)]; ok {
+		if uvs, err = p.accessor(i, 2); err != nil {
+			return err
+		}
+	}
+	if len(normals) != 0 && len(normals) != len(positions) || len(uvs) != 0 && len(uvs)/2 != count {
+		return fmt.Errorf("the attributes have different counts")
+	}
+	var indices []int
+	if prim.Indices != nil {
+		values, err := p.accessor(*prim.Indices, 1)
+		if err != nil {
+			return err
+		}
+		indices = make([]int, len(values))
+		for i := 0; i < len(values); i++ {
+			if values[i] < 0 || int(values[i]) >= count {
+				return fmt.Errorf("vertex index %v out of range, %v vertices", values[i], count)
+			}
+			indices[i] = int(values[i])
+		}
+	} else {
+		indices = make([]int, count)
+		for i := 0; i < count; i++ {
+			indices[i] = i
+		}
+	}
+
+	m := p.model
+	base := len(m.Positions)
+	for i := 0; i < count; i++ {
+		m.Positions = append(m.Positions, world.Point(geom.NewVec3(positions[3*i], positions[3*i+1], positions[3*i+2])))
+	}
+	// the vertices of the primitives without normals or uvs have null ones
+	if normals != nil && m.Normals == nil {
+		m.Normals = make([]geom.Vec3, base)
+	}
+	if m.Normals != nil {
+		// the normals are transformed by the inverse transpose, the ones of
+		// the flattened primitives being left null
+		inverse, _ := world.Inverse()
+		normalMatrix := inverse.Transpose()
+		for i := 0; i < count; i++ {
+			var n geom.Vec3
+			if normals != nil {
+				n = normalMatrix.Vector(geom.NewVec3(normals[3*i], normals[3*i+1], normals[3*i+2]))
+			}
+			if n.SquaredLength() > 0 {
+				n = n.UnitVector()
+			}
+			m.Normals = append(m.Normals, n)
+		}
+	}
+	if uvs != nil && m.UVs == nil {
+		m.UVs = make([]geom.Vec3, base)
+	}
+	if m.UVs != nil {
+		for i := 0; i < count; i++ {
+			var uv geom.Vec3
+			if uvs != nil {
+				// the images of glTF start at the top
+				uv = geom.NewVec3(uvs[2*i], 1-uvs[2*i+1], 0)
+			}
+			m.UVs = append(m.UVs, uv)
+		}
+	}
+
+	// a transform mirroring the primitive reverses the order of its vertices
+	mirrored := world.Determinant() < 0
+	for i := 0; i+2 < len(indices); {
+		a, b, c := indices[i], indices[i+1], indices[i+2]
+		switch mode {
+		case gltfTriangles:
+			i += 3
+		case gltfTriangleStrip:
+			if i%2 == 1 {
+				b, c = c, b
+			}
+			i++
+		case gltfTriangleFan:
+			a = indices[0]
+			b, c = indices[i+1], indices[i+2]
+			i++
+		}
+		if mirrored {
+			b, c = c, b
+		}
+		a, b, c = base+a, base+b, base+c
+		// the degenerate triangles can not be hit
+		if geom.Cross(m.Positions[b].Minus(m.Positions[a]), m.Positions[c].Minus(m.Positions[a])).SquaredLength() == 0 {
+			continue
+		}
+		group.Indices = append(group.Indices, a, b, c)
+		group.MaterialIndices = append(group.MaterialIndices, mat)
+	}
+	return nil
+}
+
+// accessor return the values of an accessor of n components, the ones of
+// each element following each other
+func (p *gltfReader) accessor(index int, n int) ([]float64, error) {
+	if index < 0 || index >= len(p.doc.Accessors) {
+		return nil, fmt.Errorf("accessor %v out of range", index)
+	}
+	a := p.doc.Accessors[index]
+	size := gltfComponentSizes[a.ComponentType]
+	switch {
+	case size == 0:
+		return nil, fmt.Errorf("accessor %v : unknown component type %v", index, a.ComponentType)
+	case gltfTypeSizes[a.Type] != n:
+		return nil, fmt.Errorf("accessor %v : %v components expected instead of %v", index, n, a.Type)
+	case a.Sparse != nil:
+		return nil, fmt.Errorf("accessor %v : the sparse accessors are not supported", index)
+	case a.Count < 0 || a.ByteOffset < 0:
+		return nil, fmt.Errorf("accessor %v : invalid count or offset", index)
+	}
+	values := make([]float64, a.Count*n)
+	// the accessors without buffer view are zeros
+	if a.BufferView == nil {
+		return values, nil
+	}
+	data, stride, err := p.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, fmt.Errorf("accessor %v : %v", index, err)
+	}
+	if stride == 0 {
+		stride = size * n
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+size*n > len(data) {
+		return nil, fmt.Errorf("accessor %v : out of its buffer view", index)
+	}
+	for i := 0; i < a.Count; i++ {
+		for j := 0; j < n; j++ {
+			values[n*i+j] = gltfComponent(data[a.ByteOffset+i*stride+j*size:], a.ComponentType, a.Normalized)
+		}
+	}
+	return values, nil
+}
+
+// gltfComponent decode a component, the normalized integers being mapped
+// between -1 or 0 and 1
+func gltfComponent(b []byte, typ int, normalized bool) float64 {
+	var v, max float64
+	switch typ {
+	case 5120:
+		v, max = float64(int8(b[0])), 127
+	case 5121:
+		v, max = float64(b[0]), 255
+	case 5122:
+		v, max = float64(int16(binary.LittleEndian.Uint16(b))), 32767
+	case 5123:
+		v, max = float64(binary.LittleEndian.Uint16(b)), 65535
+	case 5125:
+		return float64(binary.LittleEndian.Uint32(b))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	if normalized {
+		return math.Max(v/max, -1)
+	}
+	return v
+}
+
+// bufferView return the bytes of a buffer view and its stride
+func (p *gltfReader) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(p.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %v out of range", index)
+	}
+	v := p.doc.BufferViews[index]
+	data, err := p.buffer(v.Buffer)
+	if err != nil {
+		return nil, 0, err
+	}
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset+v.ByteLength > len(data) {
+		return nil, 0, fmt.Errorf("buffer view %v out of its buffer", index)
+	}
+	return data[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// buffer return the bytes of a buffer : the binary chunk, a data URI or an
+// external file
+func (p *gltfReader) buffer(index int) ([]byte, error) {
+	if data, ok := p.buffers[index]; ok {
+		return data, nil
+	}
+	if index < 0 || index >= len(p.doc.Buffers) {
+		return nil, fmt.Errorf("buffer %v out of range", index)
+	}
+	b := p.doc.Buffers[index]
+	var data []byte
+	var err error
+	if b.URI == "" && index == 0 && p.bin != nil {
+		data = p.bin
+	} else if data, err = p.uri(b.URI); err != nil {
+		return nil, fmt.Errorf("buffer %v : %v", index, err)
+	}
+	if len(data) < b.ByteLength {
+		return nil, fmt.Errorf("buffer %v : %v bytes instead of %v", index, len(data), b.ByteLength)
+	}
+	p.buffers[index] = data
+	return data, nil
+}
+
+// uri return the content of a data URI, or of the file named by the URI
+// relatively to the glTF file
+func (p *gltfReader) uri(uri string) ([]byte, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("no uri")
+	}
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 {
+			return nil, fmt.Errorf("invalid data uri")
+		}
+		if strings.HasSuffix(uri[:comma], ";base64") {
+			return base64.StdEncoding.DecodeString(uri[comma+1:])
+		}
+		s, err := url.PathUnescape(uri[comma+1:])
+		return []byte(s), err
+	}
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(resolve(p.path, name))
+}
+
+// material return the index in the model of a material of the file, adding
+// it the first time, and the texture coordinates its textures use
+func (p *gltfReader) material(index *int) (int, int, error) {
+	if index == nil {
+		if p.noMaterial < 0 {
+			p.model.Materials = append(p.model.Materials, defaultMaterial())
+			p.noMaterial = len(p.model.Materials) - 1
+		}
+		return p.noMaterial, 0, nil
+	}
+	if *index < 0 || *index >= len(p.doc.Materials) {
+		return 0, 0, fmt.Errorf("material %v out of range", *index)
+	}
+	m := p.doc.Materials[*index]
+	texCoord := 0
+	if m.PbrMetallicRoughness != nil && m.PbrMetallicRoughness.BaseColorTexture != nil {
+		texCoord = m.PbrMetallicRoughness.BaseColorTexture.TexCoord
+	} else if m.EmissiveTexture != nil {
+		texCoord = m.EmissiveTexture.TexCoord
+	}
+	if i, ok := p.materials[*index]; ok {
+		return i, texCoord, nil
+	}
+	mat, err := p.newMaterial(&m)
+	if err != nil {
+		return 0, 0, fmt.Errorf("material %v : %v", *index, err)
+	}
+	p.model.Materials = append(p.model.Materials, mat)
+	p.materials[*index] = len(p.model.Materials) - 1
+	return p.materials[*index], texCoord, nil
+}
+
+// newMaterial approximate a metallic-roughness material
+func (p *gltfReader) newMaterial(m *gltfMaterial) (geom.Material, error) {
+	baseColor := geom.NewVec3(1, 1, 1)
+	metallic, roughness := 1.0, 1.0
+	var baseTexture *gltfTextureInfo
+	if pbr := m.PbrMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) >= 3 {
+			baseColor = geom.NewVec3(pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2])
+		}
+		if pbr.MetallicFactor != nil {
+			metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			roughness = *pbr.RoughnessFactor
+		}
+		baseTexture = pbr.BaseColorTexture
+	}
+	var emissive geom.Vec3
+	if len(m.EmissiveFactor) >= 3 {
+		emissive = geom.NewVec3(m.EmissiveFactor[0], m.EmissiveFactor[1], m.EmissiveFactor[2])
+	}
+	if s := m.Extensions.EmissiveStrength; s != nil && s.EmissiveStrength != nil {
+		emissive = emissive.TimesScalar(*s.EmissiveStrength)
+	}
+	switch {
+	case maxComponent(emissive) > 0:
+		if m.EmissiveTexture != nil {
+			tex, err := p.texture(m.EmissiveTexture, emissive)
+			if err != nil {
+				return nil, err
+			}
+			return geom.DiffuseLight{Emit: tex}, nil
+		}
+		return geom.DiffuseLight{Emit: geom.NewConstantTexture(emissive)}, nil
+	case m.Extensions.Transmission != nil && m.Extensions.Transmission.TransmissionFactor > 0:
+		refIdx := DefaultRefIdx
+		if ior := m.Extensions.IOR; ior != nil && ior.IOR != nil && *ior.IOR >= 1 {
+			refIdx = *ior.IOR
+		}
+		return geom.Dielectric{RefIdx: refIdx}, nil
+	case metallic >= 0.5:
+		return geom.Metal{Albedo: baseColor, Fuzz: math.Min(1, math.Max(0, roughness))}, nil
+	case baseTexture != nil:
+		tex, err := p.texture(baseTexture, baseColor)
+		if err != nil {
+			return nil, err
+		}
+		return geom.Lambertian{Albedo: tex}, nil
+	}
+	return geom.Lambertian{Albedo: geom.NewConstantTexture(baseColor)}, nil
+}
+
+// texture return the image of a texture multiplied by factor
+func (p *gltfReader) texture(info *gltfTextureInfo, factor geom.Vec3) (geom.Texture, error) {
+	if info.Index < 0 || info.Index >= len(p.doc.Textures) || p.doc.Textures[info.Index].Source == nil {
+		return nil, fmt.Errorf("texture %v out of range or without image", info.Index)
+	}
+	img, err := p.image(*p.doc.Textures[info.Index].Source)
+	if err != nil {
+		return nil, err
+	}
+	if factor == geom.NewVec3(1, 1, 1) {
+		return img, nil
+	}
+	pixels := make([]geom.Vec3, len(img.Pixels))
+	for i := 0; i < len(pixels); i++ {
+		pixels[i] = img.Pixels[i].Times(factor)
+	}
+	return geom.NewImageTexture(img.Width, img.Height, pixels), nil
+}
+
+// image return a PNG image of the file, in a buffer view, a data URI or an
+// external file
+func (p *gltfReader) image(index int) (*geom.ImageTexture, error) {
+	if img, ok := p.images[index]; ok {
+		return img, nil
+	}
+	if index < 0 || index >= len(p.doc.Images) {
+		return nil, fmt.Errorf("image %v out of range", index)
+	}
+	im := p.doc.Images[index]
+	if im.MimeType != "" && im.MimeType != "image/png" || strings.HasPrefix(im.URI, "data:") && !strings.HasPrefix(im.URI, "data:image/png") {
+		return nil, fmt.Errorf("image %v : only the PNG images are supported", index)
+	}
+	var data []byte
+	var err error
+	if im.BufferView != nil {
+		data, _, err = p.bufferView(*im.BufferView)
+	} else {
+		data, err = p.uri(im.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %v : %v", index, err)
+	}
+	fb, err := output.ReadPNG(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %v : %v", index, err)
+	}
+	img := geom.NewImageTexture(fb.Width, fb.Height, fb.Pix)
+	p.images[index] = img
+	return img, nil
+}
+
+// gltfRotation return the rotation of a unit quaternion
+func gltfRotation(x, y, z, w float64) geom.Matrix {
+	return geom.Matrix{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	}
+}
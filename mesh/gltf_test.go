@@ -0,0 +1,157 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// gltfJSON is the document of the test files, whose buffer is BUFFER : a
+// red triangle moved along Z, a mirrored emissive one and a camera
+const gltfJSON = `{
+  "asset": {"version": "2.0"},
+  "scene": 0,
+  "scenes": [{"nodes": [0, 1, 2]}],
+  "nodes": [
+    {"name": "red", "mesh": 0, "translation": [0, 0, 1]},
+    {"name": "lamp", "mesh": 1, "scale": [-1, 1, 1]},
+    {"name": "view", "camera": 0, "translation": [0, 0, 5]}
+  ],
+  "meshes": [
+    {"primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}]},
+    {"primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 1}]}
+  ],
+  "cameras": [{"type": "perspective", "perspective": {"yfov": 0.7853981633974483, "aspectRatio": 1.5}}],
+  "materials": [
+    {"pbrMetallicRoughness": {"baseColorFactor": [0.8, 0.1, 0.1, 1], "metallicFactor": 0}},
+    {"emissiveFactor": [1, 1, 1], "extensions": {"KHR_materials_emissive_strength": {"emissiveStrength": 4}}}
+  ],
+  "accessors": [
+    {"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+    {"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+  ],
+  "bufferViews": [
+    {"buffer": 0, "byteOffset": 0, "byteLength": 36},
+    {"buffer": 0, "byteOffset": 36, "byteLength": 6}
+  ],
+  "buffers": [BUFFER]
+}`
+
+// gltfTestBuffer return the buffer of the test files, the positions of a
+// triangle then its indices
+func gltfTestBuffer() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&buf, binary.LittleEndian, []uint16{0, 1, 2})
+	return buf.Bytes()
+}
+
+// glbChunk write a chunk of a binary file, padded to 4 bytes by pad
+func glbChunk(buf *bytes.Buffer, typ uint32, data []byte, pad byte) {
+	padded := append([]byte{}, data...)
+	for len(padded)%4 != 0 {
+		padded = append(padded, pad)
+	}
+	binary.Write(buf, binary.LittleEndian, []uint32{uint32(len(padded)), typ})
+	buf.Write(padded)
+}
+
+// checkGLTF check the content of the test files
+func checkGLTF(t *testing.T, g *GLTF) {
+	t.Helper()
+	m := g.Model
+	if len(m.Groups) != 2 || m.Groups[0].Name != "red" || m.Groups[1].Name != "lamp" {
+		t.Fatalf("groups %v", m.Groups)
+	}
+	// the Z axis is reversed, which mirrors the triangles and reverses the
+	// order of their vertices, unless their node mirrors them too
+	want := map[string][]geom.Vec3{
+		"red":  {geom.NewVec3(0, 0, -1), geom.NewVec3(0, 1, -1), geom.NewVec3(1, 0, -1)},
+		"lamp": {geom.NewVec3(0, 0, 0), geom.NewVec3(-1, 0, 0), geom.NewVec3(0, 1, 0)},
+	}
+	for i := 0; i < len(m.Groups); i++ {
+		positions := trianglePositions(m, m.Groups[i])
+		w := want[m.Groups[i].Name]
+		if len(positions) != len(w) {
+			t.Fatalf("%v : %v vertices instead of %v", m.Groups[i].Name, len(positions), len(w))
+		}
+		for j := 0; j < len(w); j++ {
+			if positions[j] != w[j] {
+				t.Errorf("%v : vertices %v instead of %v", m.Groups[i].Name, positions, w)
+				break
+			}
+		}
+	}
+	if len(m.Materials) != 2 {
+		t.Fatalf("%v materials instead of 2", len(m.Materials))
+	}
+	if albedo(m.Materials[m.Groups[0].MaterialIndices[0]]) != geom.NewVec3(0.8, 0.1, 0.1) {
+		t.Errorf("red is %#v", m.Materials[0])
+	}
+	if l, ok := m.Materials[m.Groups[1].MaterialIndices[0]].(geom.DiffuseLight); !ok || l.Emit.Value(0, 0, geom.Vec3{}) != geom.NewVec3(4, 4, 4) {
+		t.Errorf("lamp is %#v", m.Materials[1])
+	}
+	if len(g.Cameras) != 1 {
+		t.Fatalf("%v cameras instead of 1", len(g.Cameras))
+	}
+	c := g.Cameras[0]
+	if c.LookFrom != geom.NewVec3(0, 0, -5) || c.LookAt != geom.NewVec3(0, 0, -4) || c.Vup != geom.NewVec3(0, 1, 0) {
+		t.Errorf("camera from %v to %v, up %v", c.LookFrom, c.LookAt, c.Vup)
+	}
+	if math.Abs(c.Vfov-45) > 1e-9 || c.Aspect != 1.5 {
+		t.Errorf("camera of %v degrees and aspect %v", c.Vfov, c.Aspect)
+	}
+}
+
+func TestReadGLTFEmbedded(t *testing.T) {
+	data := gltfTestBuffer()
+	buffer := `{"byteLength": 42, "uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(data) + `"}`
+	src := strings.Replace(gltfJSON, "BUFFER", buffer, 1)
+	g, err := ReadGLTF(strings.NewReader(src), "test.gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGLTF(t, g)
+}
+
+func TestReadGLTFBinary(t *testing.T) {
+	var chunks bytes.Buffer
+	glbChunk(&chunks, glbJSON, []byte(strings.Replace(gltfJSON, "BUFFER", `{"byteLength": 42}`, 1)), ' ')
+	glbChunk(&chunks, glbBIN, gltfTestBuffer(), 0)
+	var src bytes.Buffer
+	binary.Write(&src, binary.LittleEndian, []uint32{glbMagic, 2, uint32(12 + chunks.Len())})
+	src.Write(chunks.Bytes())
+	g, err := ReadGLTF(&src, "test.glb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGLTF(t, g)
+}
+
+func TestReadGLTFErrors(t *testing.T) {
+	buffer := `{"byteLength": 42, "uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(gltfTestBuffer()) + `"}`
+	valid := strings.Replace(gltfJSON, "BUFFER", buffer, 1)
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"extension", strings.Replace(valid, `"scene": 0,`, `"scene": 0, "extensionsRequired": ["KHR_draco_mesh_compression"],`, 1), "KHR_draco_mesh_compression is not supported"},
+		{"scene", strings.Replace(valid, `"scene": 0,`, `"scene": 1,`, 1), "scene 1 out of range"},
+		{"short buffer", strings.Replace(valid, `"byteLength": 42`, `"byteLength": 48`, 1), "42 bytes instead of 48"},
+		{"index", strings.Replace(valid, `"count": 3, "type": "VEC3"`, `"count": 2, "type": "VEC3"`, 1), "vertex index 2 out of range"},
+		{"hierarchy", strings.Replace(valid, `"name": "red", "mesh": 0,`, `"name": "red", "mesh": 0, "children": [0],`, 1), "node 0 is twice"},
+		{"version", "glTF\x01\x00\x00\x00\x0c\x00\x00\x00", "version 1"},
+	}
+	for _, test := range tests {
+		_, err := ReadGLTF(strings.NewReader(test.src), "test.gltf")
+		if err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%v : %v instead of %q", test.name, err, test.msg)
+		}
+	}
+}
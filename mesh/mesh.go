@@ -0,0 +1,230 @@
+// Package mesh reads the triangle meshes of the Wavefront OBJ files with
+// their MTL materials, of the PLY and STL files, and the scenes of the glTF
+// files.
+package mesh
+
+import (
+	"fmt"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// DefaultAlbedo is the albedo of the faces without material
+var DefaultAlbedo = geom.NewVec3(0.8, 0.8, 0.8)
+
+// Error is an error of a mesh file, located by its line
+type Error struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%v: %v", e.Path, e.Msg)
+	}
+	return fmt.Sprintf("%v:%v: %v", e.Path, e.Line, e.Msg)
+}
+
+// Model is the content of a mesh file : its vertices and materials, and its
+// triangles by group
+type Model struct {
+	// Positions, Normals and UVs are the vertices of the triangles. Normals
+	// and UVs are nil when the file has none, the vertices without them
+	// having null ones.
+	Positions []geom.Vec3
+	Normals   []geom.Vec3
+	UVs       []geom.Vec3
+	// Colors are the linear colors of the vertices, nil when the file has
+	// none. They replace the materials, each triangle being lambertian of
+	// the average color of its vertices.
+	Colors    []geom.Vec3
+	Materials []geom.Material
+	Groups    []*Group
+}
+
+// Group is a named group of triangles of a model
+type Group struct {
+	Name string
+	// Indices are the indices of the three vertices of each triangle
+	Indices []int
+	// MaterialIndices are the indices in the materials of the model of the
+	// material of each triangle
+	MaterialIndices []int
+}
+
+// Len return the number of triangles of the group
+func (g *Group) Len() int {
+	return len(g.Indices) / 3
+}
+
+// Group return the group of the given name, nil if there is none
+func (m *Model) Group(name string) *Group {
+	for i := 0; i < len(m.Groups); i++ {
+		if m.Groups[i].Name == name {
+			return m.Groups[i]
+		}
+	}
+	return nil
+}
+
+// SetMaterial replace the materials and the colors of all the triangles
+func (m *Model) SetMaterial(mat geom.Material) {
+	m.Materials = []geom.Material{mat}
+	m.Colors = nil
+	for i := 0; i < len(m.Groups); i++ {
+		for j := 0; j < len(m.Groups[i].MaterialIndices); j++ {
+			m.Groups[i].MaterialIndices[j] = 0
+		}
+	}
+}
+
+// Scale multiply the positions of the vertices by factor
+func (m *Model) Scale(factor float64) {
+	for i := 0; i < len(m.Positions); i++ {
+		m.Positions[i] = m.Positions[i].TimesScalar(factor)
+	}
+}
+
+// Translate move the positions of the vertices by offset
+func (m *Model) Translate(offset geom.Vec3) {
+	for i := 0; i < len(m.Positions); i++ {
+		m.Positions[i] = m.Positions[i].Plus(offset)
+	}
+}
+
+// Emissive return the model of the emissive triangles, the diffuse lights,
+// to sample the lights of a mesh. It shares the vertices and has the groups
+// of the model, which may be left empty.
+func (m *Model) Emissive() *Model {
+	e := *m
+	e.Groups = make([]*Group, len(m.Groups))
+	for i := 0; i < len(m.Groups); i++ {
+		g := m.Groups[i]
+		e.Groups[i] = &Group{Name: g.Name}
+		// the colors of the vertices replace the materials by lambertian ones
+		if m.Colors != nil {
+			continue
+		}
+		for j := 0; j < len(g.MaterialIndices); j++ {
+			switch m.Materials[g.MaterialIndices[j]].(type) {
+			case geom.DiffuseLight, *geom.DiffuseLight:
+				e.Groups[i].Indices = append(e.Groups[i].Indices, g.Indices[3*j:3*j+3]...)
+				e.Groups[i].MaterialIndices = append(e.Groups[i].MaterialIndices, g.MaterialIndices[j])
+			}
+		}
+	}
+	return &e
+}
+
+// Mesh return the triangle mesh of the groups of the given names, of all the
+// groups when there is no name. It shares the vertices of the model.
+func (m *Model) Mesh(names ...string) (*geom.TriangleMesh, error) {
+	groups := m.Groups
+	if len(names) > 0 {
+		groups = make([]*Group, len(names))
+		for i := 0; i < len(names); i++ {
+			groups[i] = m.Group(names[i])
+			if groups[i] == nil {
+				return nil, fmt.Errorf("no group %q in the mesh", names[i])
+			}
+		}
+	}
+	var indices, materialIndices []int
+	for i := 0; i < len(groups); i++ {
+		indices = append(indices, groups[i].Indices...)
+		materialIndices = append(materialIndices, groups[i].MaterialIndices...)
+	}
+	if !m.hasArea(indices) {
+		return nil, fmt.Errorf("the mesh has no triangle")
+	}
+	materials := m.Materials
+	if m.Colors != nil {
+		materials, materialIndices = m.colorMaterials(indices)
+	}
+	mesh := geom.NewTriangleMesh(m.Positions, m.Normals, m.UVs, indices, materials[0])
+	for i := 0; i < len(materialIndices); i++ {
+		if materialIndices[i] != 0 {
+			mesh.SetMaterials(materials, materialIndices)
+			break
+		}
+	}
+	return mesh, nil
+}
+
+// Hitable return the mesh of the groups of the given names as a Hitable, see
+// Mesh
+func (m *Model) Hitable(names ...string) (geom.Hitable, error) {
+	mesh, err := m.Mesh(names...)
+	if err != nil {
+		return nil, err
+	}
+	return mesh, nil
+}
+
+// colorMaterials return the lambertian materials of the average colors of
+// the vertices of the triangles, and the index of the material of each
+// triangle
+func (m *Model) colorMaterials(indices []int) ([]geom.Material, []int) {
+	var materials []geom.Material
+	materialIndices := make([]int, len(indices)/3)
+	byColor := map[geom.Vec3]int{}
+	for i := 0; i < len(materialIndices); i++ {
+		c := m.Colors[indices[3*i]].Plus(m.Colors[indices[3*i+1]]).Plus(m.Colors[indices[3*i+2]]).ByScalar(3)
+		j, ok := byColor[c]
+		if !ok {
+			materials = append(materials, geom.Lambertian{Albedo: geom.NewConstantTexture(c)})
+			j = len(materials) - 1
+			byColor[c] = j
+		}
+		materialIndices[i] = j
+	}
+	return materials, materialIndices
+}
+
+// Points return spheres of the given radius at the vertices of the model, to
+// render the point clouds. They are lambertian of the colors of the vertices,
+// or have the first material of the model.
+func (m *Model) Points(radius float64) (geom.Hitable, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("the radius of the points must be positive")
+	}
+	if len(m.Positions) == 0 {
+		return nil, fmt.Errorf("the mesh has no vertex")
+	}
+	mat := defaultMaterial()
+	if len(m.Materials) > 0 {
+		mat = m.Materials[0]
+	}
+	byColor := map[geom.Vec3]geom.Material{}
+	list := make([]geom.Hitable, len(m.Positions))
+	for i := 0; i < len(m.Positions); i++ {
+		if m.Colors != nil {
+			c := m.Colors[i]
+			if _, ok := byColor[c]; !ok {
+				byColor[c] = geom.Lambertian{Albedo: geom.NewConstantTexture(c)}
+			}
+			mat = byColor[c]
+		}
+		list[i] = geom.NewSphere(m.Positions[i], radius, mat)
+	}
+	return geom.NewLinearBVH(geom.NewHitableList(&list, len(list)), 0, 1, geom.BVHOptions{Strategy: geom.BVHSAH}), nil
+}
+
+// hasArea return true if one of the triangles is not degenerate
+func (m *Model) hasArea(indices []int) bool {
+	for i := 0; i+2 < len(indices); i += 3 {
+		p0 := m.Positions[indices[i]]
+		e1 := m.Positions[indices[i+1]].Minus(p0)
+		e2 := m.Positions[indices[i+2]].Minus(p0)
+		if geom.Cross(e1, e2).SquaredLength() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMaterial return the material of the faces without material
+func defaultMaterial() geom.Material {
+	return geom.Lambertian{Albedo: geom.NewConstantTexture(DefaultAlbedo)}
+}
@@ -0,0 +1,224 @@
+package mesh
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/output"
+)
+
+// DefaultRefIdx is the index of refraction of the transparent materials
+// without Ni
+const DefaultRefIdx = 1.5
+
+// LoadMTL read the materials of the MTL file at path, see ReadMTL
+func LoadMTL(path string) (map[string]geom.Material, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMTL(f, path)
+}
+
+// ReadMTL read the materials of a MTL file by name, approximated with the
+// materials of the geometry package :
+//
+//   - the materials emitting light (Ke) are diffuse lights
+//   - the transparent ones (d or Tr) are dielectrics of index of refraction Ni
+//   - the ones more specular (Ks) than diffuse (Kd) are metals of albedo Ks,
+//     whose fuzz decreases with the specular exponent Ns
+//   - the other ones are lambertian of albedo the image of map_Kd, or Kd
+//
+// The images of map_Kd are PNG, OpenEXR or PFM files named relatively to
+// path, which also names the file in the errors. The options of the maps and
+// the other statements are ignored.
+func ReadMTL(r io.Reader, path string) (map[string]geom.Material, error) {
+	p := &mtlReader{
+		path:     path,
+		textures: map[string]geom.Texture{},
+	}
+	lines := newLineReader(r)
+	for {
+		fields, err := lines.next()
+		p.line = lines.line
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if err := p.statement(fields[0], fields[1:]); err != nil {
+			return nil, err
+		}
+	}
+	materials := map[string]geom.Material{}
+	for i := 0; i < len(p.materials); i++ {
+		m := p.materials[i]
+		mat, err := p.material(m)
+		if err != nil {
+			return nil, err
+		}
+		materials[m.name] = mat
+	}
+	return materials, nil
+}
+
+// mtlMaterial are the parameters of a material of a MTL file
+type mtlMaterial struct {
+	name  string
+	line  int
+	kd    geom.Vec3
+	ks    geom.Vec3
+	ke    geom.Vec3
+	ns    float64
+	ni    float64
+	d     float64
+	mapKd string
+}
+
+// mtlReader holds the state of the reading of a MTL file
+type mtlReader struct {
+	path      string
+	line      int
+	materials []*mtlMaterial
+	// textures are the images of map_Kd by path, shared by the materials
+	textures map[string]geom.Texture
+}
+
+func (p *mtlReader) errorf(format string, args ...interface{}) error {
+	return &Error{Path: p.path, Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// statement read a line of the file
+func (p *mtlReader) statement(keyword string, args []string) error {
+	if keyword == "newmtl" {
+		if len(args) == 0 {
+			return p.errorf("missing the name of the material")
+		}
+		p.materials = append(p.materials, &mtlMaterial{
+			name: strings.Join(args, " "),
+			line: p.line,
+			kd:   DefaultAlbedo,
+			d:    1,
+		})
+		return nil
+	}
+	if len(p.materials) == 0 {
+		// nothing can be defined before the first material
+		return nil
+	}
+	m := p.materials[len(p.materials)-1]
+	var err error
+	switch keyword {
+	case "Kd":
+		m.kd, err = p.color(args)
+	case "Ks":
+		m.ks, err = p.color(args)
+	case "Ke":
+		m.ke, err = p.color(args)
+	case "Ns":
+		m.ns, err = p.number(args)
+	case "Ni":
+		m.ni, err = p.number(args)
+	case "d":
+		m.d, err = p.number(args)
+	case "Tr":
+		var tr float64
+		tr, err = p.number(args)
+		m.d = 1 - tr
+	case "map_Kd":
+		if len(args) == 0 {
+			return p.errorf("missing the file of map_Kd")
+		}
+		// the file is after the options
+		m.mapKd = args[len(args)-1]
+	}
+	return err
+}
+
+// number read the number of a statement
+func (p *mtlReader) number(args []string) (float64, error) {
+	if len(args) == 0 {
+		return 0, p.errorf("expected a number")
+	}
+	v, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", args[0])
+	}
+	return v, nil
+}
+
+// color read a color r g b, or a single value for the three components
+func (p *mtlReader) color(args []string) (geom.Vec3, error) {
+	if len(args) > 0 && (args[0] == "spectral" || args[0] == "xyz") {
+		return geom.Vec3{}, p.errorf("%v colors are not supported", args[0])
+	}
+	if len(args) == 1 {
+		v, err := p.number(args)
+		return geom.NewVec3(v, v, v), err
+	}
+	var c geom.Vec3
+	if len(args) < 3 {
+		return c, p.errorf("expected three numbers")
+	}
+	for i := 0; i < 3; i++ {
+		v, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return c, p.errorf("invalid number %q", args[i])
+		}
+		c.SetAt(i, v)
+	}
+	return c, nil
+}
+
+// material approximate a material of the file
+func (p *mtlReader) material(m *mtlMaterial) (geom.Material, error) {
+	switch {
+	case maxComponent(m.ke) > 0:
+		return geom.DiffuseLight{Emit: geom.NewConstantTexture(m.ke)}, nil
+	case m.d < 1:
+		refIdx := m.ni
+		if refIdx <= 0 {
+			refIdx = DefaultRefIdx
+		}
+		return geom.Dielectric{RefIdx: refIdx}, nil
+	case m.mapKd == "" && maxComponent(m.ks) > maxComponent(m.kd):
+		// the fuzz is the roughness of the Blinn-Phong exponent
+		return geom.Metal{Albedo: m.ks, Fuzz: math.Min(1, math.Sqrt(2/(m.ns+2)))}, nil
+	case m.mapKd != "":
+		tex, err := p.texture(m)
+		if err != nil {
+			return nil, err
+		}
+		return geom.Lambertian{Albedo: tex}, nil
+	}
+	return geom.Lambertian{Albedo: geom.NewConstantTexture(m.kd)}, nil
+}
+
+// texture return the texture of the map_Kd image of a material
+func (p *mtlReader) texture(m *mtlMaterial) (geom.Texture, error) {
+	path := resolve(p.path, m.mapKd)
+	if tex, ok := p.textures[path]; ok {
+		return tex, nil
+	}
+	img, err := output.ReadFile(path)
+	if err != nil {
+		return nil, &Error{Path: p.path, Line: m.line, Msg: fmt.Sprintf("material %q : %v", m.name, err)}
+	}
+	tex := geom.NewImageTexture(img.Width, img.Height, img.Pix)
+	p.textures[path] = tex
+	return tex, nil
+}
+
+func maxComponent(v geom.Vec3) float64 {
+	return math.Max(v.X(), math.Max(v.Y(), v.Z()))
+}
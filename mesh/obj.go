@@ -0,0 +1,356 @@
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// defaultGroup is the group of the faces before any g or o statement
+const defaultGroup = "default"
+
+// LoadOBJ read the OBJ file at path, see ReadOBJ
+func LoadOBJ(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadOBJ(f, path)
+}
+
+// ReadOBJ read an OBJ file : its vertices, texture coordinates and normals,
+// its polygons split in triangles around their first vertex, the groups of
+// the g and o statements and the materials of the MTL files of mtllib, found
+// next to path. path also names the file in the errors.
+// The faces without material are lambertian of DefaultAlbedo, the lines,
+// points, smoothing groups and curves are ignored.
+func ReadOBJ(r io.Reader, path string) (*Model, error) {
+	p := &objReader{
+		path:          path,
+		model:         &Model{},
+		materials:     map[string]geom.Material{},
+		materialIndex: map[string]int{},
+		vertexIndex:   map[objVertex]int{},
+		mat:           -1,
+	}
+	if err := p.read(r); err != nil {
+		return nil, err
+	}
+	if !p.hasNormals {
+		p.model.Normals = nil
+	}
+	if !p.hasUVs {
+		p.model.UVs = nil
+	}
+	// the groups without faces, as the default one of most files, are dropped
+	groups := p.model.Groups[:0]
+	for i := 0; i < len(p.model.Groups); i++ {
+		if len(p.model.Groups[i].Indices) > 0 {
+			groups = append(groups, p.model.Groups[i])
+		}
+	}
+	p.model.Groups = groups
+	return p.model, nil
+}
+
+// objReader holds the state of the reading of an OBJ file
+type objReader struct {
+	path      string
+	line      int
+	positions []geom.Vec3
+	uvs       []geom.Vec3
+	normals   []geom.Vec3
+	// materials are the materials of the MTL files by name, materialIndex
+	// the index in the model of the ones used
+	materials     map[string]geom.Material
+	materialIndex map[string]int
+	// vertexIndex is the index in the model of the vertices of the faces
+	vertexIndex map[objVertex]int
+	hasNormals  bool
+	hasUVs      bool
+	// mat and group are the index of the material and the group of the next
+	// faces, mat being -1 for the default material
+	mat   int
+	group *Group
+	model *Model
+}
+
+func (p *objReader) errorf(format string, args ...interface{}) error {
+	return &Error{Path: p.path, Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *objReader) read(r io.Reader) error {
+	p.setGroup(defaultGroup)
+	lines := newLineReader(r)
+	for {
+		fields, err := lines.next()
+		p.line = lines.line
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		args := fields[1:]
+		switch fields[0] {
+		case "v":
+			v, err := p.vector(args, 3, 3)
+			if err != nil {
+				return err
+			}
+			p.positions = append(p.positions, v)
+		case "vt":
+			v, err := p.vector(args, 1, 2)
+			if err != nil {
+				return err
+			}
+			p.uvs = append(p.uvs, v)
+		case "vn":
+			v, err := p.vector(args, 3, 3)
+			if err != nil {
+				return err
+			}
+			p.normals = append(p.normals, v)
+		case "f":
+			if err := p.face(args); err != nil {
+				return err
+			}
+		case "g", "o":
+			name := strings.Join(args, " ")
+			if name == "" {
+				name = defaultGroup
+			}
+			p.setGroup(name)
+		case "usemtl":
+			if err := p.useMaterial(strings.Join(args, " ")); err != nil {
+				return err
+			}
+		case "mtllib":
+			for i := 0; i < len(args); i++ {
+				if err := p.mtllib(args[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// vector read at least min numbers of args, the ones after the first max
+// being ignored
+func (p *objReader) vector(args []string, min, max int) (geom.Vec3, error) {
+	var v geom.Vec3
+	if len(args) < min {
+		return v, p.errorf("expected %v numbers", min)
+	}
+	for i := 0; i < len(args) && i < max; i++ {
+		f, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return v, p.errorf("invalid number %q", args[i])
+		}
+		v.SetAt(i, f)
+	}
+	return v, nil
+}
+
+// setGroup make the group of the given name the one of the next faces
+func (p *objReader) setGroup(name string) {
+	p.group = p.model.Group(name)
+	if p.group == nil {
+		p.group = &Group{Name: name}
+		p.model.Groups = append(p.model.Groups, p.group)
+	}
+}
+
+// mtllib read the materials of a MTL file, whose name is relative to the
+// directory of the OBJ file
+func (p *objReader) mtllib(name string) error {
+	materials, err := LoadMTL(resolve(p.path, name))
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	for name, mat := range materials {
+		p.materials[name] = mat
+	}
+	return nil
+}
+
+// objVertex are the indices of the position, texture coordinates and normal
+// of a vertex of a face, -1 when they are missing
+type objVertex struct {
+	position int
+	uv       int
+	normal   int
+}
+
+// useMaterial make the material of the given name the one of the next faces
+func (p *objReader) useMaterial(name string) error {
+	if i, ok := p.materialIndex[name]; ok {
+		p.mat = i
+		return nil
+	}
+	mat, ok := p.materials[name]
+	if !ok {
+		return p.errorf("unknown material %q", name)
+	}
+	p.model.Materials = append(p.model.Materials, mat)
+	p.mat = len(p.model.Materials) - 1
+	p.materialIndex[name] = p.mat
+	return nil
+}
+
+// face add the triangles of a polygon
+func (p *objReader) face(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("a face needs at least three vertices")
+	}
+	vertices := make([]objVertex, len(args))
+	for i := 0; i < len(args); i++ {
+		v, err := p.vertex(args[i])
+		if err != nil {
+			return err
+		}
+		vertices[i] = v
+	}
+	if p.mat < 0 {
+		p.model.Materials = append(p.model.Materials, defaultMaterial())
+		p.mat = len(p.model.Materials) - 1
+	}
+	for i := 1; i+1 < len(vertices); i++ {
+		a := p.positions[vertices[0].position]
+		b := p.positions[vertices[i].position]
+		c := p.positions[vertices[i+1].position]
+		// the degenerate triangles can not be hit
+		if geom.Cross(b.Minus(a), c.Minus(a)).SquaredLength() == 0 {
+			continue
+		}
+		p.group.Indices = append(p.group.Indices, p.modelVertex(vertices[0]), p.modelVertex(vertices[i]), p.modelVertex(vertices[i+1]))
+		p.group.MaterialIndices = append(p.group.MaterialIndices, p.mat)
+	}
+	return nil
+}
+
+// modelVertex return the index in the model of a vertex of a face, adding it
+// the first time
+func (p *objReader) modelVertex(v objVertex) int {
+	if i, ok := p.vertexIndex[v]; ok {
+		return i
+	}
+	m := p.model
+	m.Positions = append(m.Positions, p.positions[v.position])
+	var normal, uv geom.Vec3
+	if v.normal >= 0 {
+		normal = p.normals[v.normal]
+		p.hasNormals = true
+	}
+	if v.uv >= 0 {
+		uv = p.uvs[v.uv]
+		p.hasUVs = true
+	}
+	m.Normals = append(m.Normals, normal)
+	m.UVs = append(m.UVs, uv)
+	i := len(m.Positions) - 1
+	p.vertexIndex[v] = i
+	return i
+}
+
+// vertex read a vertex of a face : v, v/vt, v//vn or v/vt/vn
+func (p *objReader) vertex(arg string) (objVertex, error) {
+	v := objVertex{position: -1, uv: -1, normal: -1}
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return v, p.errorf("invalid vertex %q", arg)
+	}
+	var err error
+	if v.position, err = p.index(parts[0], len(p.positions), "vertex"); err != nil {
+		return v, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if v.uv, err = p.index(parts[1], len(p.uvs), "texture coordinates"); err != nil {
+			return v, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if v.normal, err = p.index(parts[2], len(p.normals), "normal"); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// index convert an index of the file, starting at 1 or counted from the end
+// when it is negative, to an index of the n elements read so far
+func (p *objReader) index(s string, n int, what string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return -1, p.errorf("invalid %v index %q", what, s)
+	}
+	if i < 0 {
+		i += n
+	} else {
+		i--
+	}
+	if i < 0 || i >= n {
+		return -1, p.errorf("%v index %v out of range, %v defined", what, s, n)
+	}
+	return i, nil
+}
+
+// lineReader split a text file in lines of fields, skipping the comments and
+// joining the lines ended by a backslash
+type lineReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r)}
+}
+
+// next return the fields of the next line, io.EOF at the end of the file
+func (lr *lineReader) next() ([]string, error) {
+	var text string
+	for {
+		s, err := lr.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF && s == "" && text == "" {
+			return nil, io.EOF
+		}
+		lr.line++
+		s = strings.TrimRight(s, "\r\n")
+		if i := strings.IndexByte(s, '#'); i >= 0 {
+			s = s[:i]
+		}
+		if strings.HasSuffix(s, "\\") && err == nil {
+			text += s[:len(s)-1] + " "
+			continue
+		}
+		text += s
+		return strings.Fields(text), nil
+	}
+}
+
+// resolve return the path of a file named in the file at path, relative to
+// its directory. The backslashes of the files written on Windows are
+// separators.
+func resolve(path, name string) string {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(filepath.Dir(path), name)
+}
@@ -0,0 +1,172 @@
+package mesh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// albedo return the albedo of a lambertian material of constant color, null
+// for the other materials
+func albedo(mat geom.Material) geom.Vec3 {
+	if l, ok := mat.(geom.Lambertian); ok {
+		return l.Albedo.Value(0, 0, geom.Vec3{})
+	}
+	return geom.Vec3{}
+}
+
+// trianglePositions return the positions of the vertices of the triangles of
+// a group
+func trianglePositions(m *Model, g *Group) []geom.Vec3 {
+	positions := make([]geom.Vec3, len(g.Indices))
+	for i := 0; i < len(g.Indices); i++ {
+		positions[i] = m.Positions[g.Indices[i]]
+	}
+	return positions
+}
+
+func TestReadOBJIndices(t *testing.T) {
+	src := `# a square and a triangle
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 1
+vn 0 0 -1
+g square
+f 1/1/1 2/1/1 3/2/1 4/2/1
+o triangle
+v 0 0 1
+v 1 0 1
+v 0 1 1
+f -3//1 -2//1 -1//1
+`
+	m, err := ReadOBJ(strings.NewReader(src), "test.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 2 || m.Groups[0].Name != "square" || m.Groups[1].Name != "triangle" {
+		t.Fatalf("groups %v", m.Groups)
+	}
+	// the quad is split around its first vertex
+	square := trianglePositions(m, m.Groups[0])
+	want := []geom.Vec3{
+		geom.NewVec3(0, 0, 0), geom.NewVec3(1, 0, 0), geom.NewVec3(1, 1, 0),
+		geom.NewVec3(0, 0, 0), geom.NewVec3(1, 1, 0), geom.NewVec3(0, 1, 0),
+	}
+	for i := 0; i < len(want); i++ {
+		if square[i] != want[i] {
+			t.Fatalf("square vertex %v at %v instead of %v", i, square[i], want[i])
+		}
+	}
+	// the negative indices count from the last vertex
+	triangle := trianglePositions(m, m.Groups[1])
+	if triangle[0] != geom.NewVec3(0, 0, 1) || triangle[2] != geom.NewVec3(0, 1, 1) {
+		t.Errorf("triangle %v", triangle)
+	}
+	if m.Normals == nil || m.Normals[m.Groups[1].Indices[0]] != geom.NewVec3(0, 0, -1) {
+		t.Errorf("the normals are not read")
+	}
+	if m.UVs == nil || m.UVs[m.Groups[0].Indices[2]] != geom.NewVec3(1, 1, 0) {
+		t.Errorf("the texture coordinates are not read")
+	}
+	if len(m.Materials) != 1 || albedo(m.Materials[0]) != DefaultAlbedo {
+		t.Errorf("materials %v instead of the default one", m.Materials)
+	}
+}
+
+func TestReadOBJMaterials(t *testing.T) {
+	dir := t.TempDir()
+	mtl := `newmtl red
+Kd 0.8 0.1 0.1
+newmtl lamp
+Ke 4 4 4
+newmtl glass
+d 0.5
+Ni 1.33
+newmtl steel
+Kd 0.1 0.1 0.1
+Ks 0.9 0.9 0.9
+Ns 200
+`
+	if err := os.WriteFile(filepath.Join(dir, "test.mtl"), []byte(mtl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := `mtllib test.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+usemtl red
+f 1 2 3
+usemtl lamp
+f 1 2 3
+usemtl glass
+f 1 2 3
+usemtl steel
+f 1 2 3
+usemtl red
+f 1 2 3
+`
+	m, err := ReadOBJ(strings.NewReader(src), filepath.Join(dir, "test.obj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Materials) != 4 {
+		t.Fatalf("%v materials instead of 4", len(m.Materials))
+	}
+	// the materials used twice are shared
+	want := []int{0, 1, 2, 3, 0}
+	for i := 0; i < len(want); i++ {
+		if m.Groups[0].MaterialIndices[i] != want[i] {
+			t.Fatalf("material indices %v instead of %v", m.Groups[0].MaterialIndices, want)
+		}
+	}
+	if albedo(m.Materials[0]) != geom.NewVec3(0.8, 0.1, 0.1) {
+		t.Errorf("red is %#v", m.Materials[0])
+	}
+	if _, ok := m.Materials[1].(geom.DiffuseLight); !ok {
+		t.Errorf("lamp is %#v", m.Materials[1])
+	}
+	if d, ok := m.Materials[2].(geom.Dielectric); !ok || d.RefIdx != 1.33 {
+		t.Errorf("glass is %#v", m.Materials[2])
+	}
+	if _, ok := m.Materials[3].(geom.Metal); !ok {
+		t.Errorf("steel is %#v", m.Materials[3])
+	}
+}
+
+func TestReadOBJErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		msg  string
+	}{
+		{"out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", 4, "vertex index 4 out of range"},
+		{"negative out of range", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -1 -2 -4\n", 4, "vertex index -4 out of range"},
+		{"zero index", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 0 1 2\n", 4, "vertex index 0 out of range"},
+		{"invalid number", "v 0 0 0\nv 1 x 0\n", 2, `invalid number "x"`},
+		{"short vertex", "v 0 0\n", 1, "expected 3 numbers"},
+		{"two vertices", "v 0 0 0\nv 1 0 0\nf 1 2\n", 3, "at least three vertices"},
+		{"invalid vertex", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", 4, "invalid vertex"},
+		{"normal", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2//1 3//1\n", 4, "normal index 1 out of range"},
+		{"unknown material", "v 0 0 0\nusemtl gold\n", 2, `unknown material "gold"`},
+		{"missing mtllib", "\nmtllib missing.mtl\n", 2, "missing.mtl"},
+	}
+	for _, test := range tests {
+		_, err := ReadOBJ(strings.NewReader(test.src), filepath.Join(t.TempDir(), "test.obj"))
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v : %v is not a located error", test.name, err)
+			continue
+		}
+		if e.Line != test.line || !strings.Contains(e.Msg, test.msg) {
+			t.Errorf("%v : %q instead of line %v %q", test.name, err, test.line, test.msg)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package mesh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// LoadPLY read the PLY file at path, see ReadPLY
+func LoadPLY(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPLY(f, path)
+}
+
+// ReadPLY read a PLY file, ASCII or binary little or big endian : the
+// positions, normals (nx, ny, nz), texture coordinates (u, v or s, t) and
+// colors (red, green, blue) of its vertices, and its faces split in
+// triangles around their first vertex, in a single group. The colors are
+// sRGB, from 0 to 255 for the integer types and from 0 to 1 for the other
+// ones. The other elements and properties are ignored, and a file without
+// faces is a point cloud, see Model.Points. path names the file in the
+// errors.
+func ReadPLY(r io.Reader, path string) (*Model, error) {
+	p := &plyReader{
+		path: path,
+		r:    bufio.NewReader(r),
+	}
+	if err := p.header(); err != nil {
+		return nil, err
+	}
+	m := &Model{Materials: []geom.Material{defaultMaterial()}}
+	group := &Group{Name: defaultGroup}
+	for i := 0; i < len(p.elements); i++ {
+		e := p.elements[i]
+		var err error
+		switch e.name {
+		case "vertex":
+			err = p.vertices(e, m)
+		case "face":
+			err = p.faces(e, m, group)
+		default:
+			err = p.skip(e)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(group.Indices) > 0 {
+		m.Groups = []*Group{group}
+	}
+	return m, nil
+}
+
+// plyProperty is a property of an element, countType being the type of the
+// number of items of the lists
+type plyProperty struct {
+	name      string
+	typ       string
+	list      bool
+	countType string
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyReader holds the state of the reading of a PLY file
+type plyReader struct {
+	path string
+	r    *bufio.Reader
+	// line is the line read in the header and the ASCII data
+	line     int
+	format   string
+	order    binary.ByteOrder
+	elements []*plyElement
+	buf      [8]byte
+}
+
+func (p *plyReader) errorf(format string, args ...interface{}) error {
+	line := p.line
+	if p.order != nil {
+		// the binary data have no lines
+		line = 0
+	}
+	return &Error{Path: p.path, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// plySizes are the sizes of the types of the properties
+var plySizes = map[string]int{
+	"char": 1, "uchar": 1, "short": 2, "ushort": 2, "int": 4, "uint": 4, "float": 4, "double": 8,
+	"int8": 1, "uint8": 1, "int16": 2, "uint16": 2, "int32": 4, "uint32": 4, "float32": 4, "float64": 8,
+}
+
+// header read the header up to end_header
+func (p *plyReader) header() error {
+	for {
+		s, err := p.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return p.errorf("the header is not ended by end_header")
+			}
+			return p.errorf("%v", err)
+		}
+		p.line++
+		fields := strings.Fields(s)
+		if p.line == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return p.errorf("not a PLY file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return p.errorf("missing the format")
+			}
+			switch fields[1] {
+			case "ascii":
+			case "binary_little_endian":
+				p.order = binary.LittleEndian
+			case "binary_big_endian":
+				p.order = binary.BigEndian
+			default:
+				return p.errorf("unknown format %q", fields[1])
+			}
+			p.format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return p.errorf("expected element <name> <count>")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return p.errorf("invalid number of %v %q", fields[1], fields[2])
+			}
+			p.elements = append(p.elements, &plyElement{name: fields[1], count: count})
+		case "property":
+			if len(p.elements) == 0 {
+				return p.errorf("property before any element")
+			}
+			var prop plyProperty
+			if len(fields) == 5 && fields[1] == "list" {
+				prop = plyProperty{name: fields[4], typ: fields[3], list: true, countType: fields[2]}
+			} else if len(fields) == 3 {
+				prop = plyProperty{name: fields[2], typ: fields[1]}
+			} else {
+				return p.errorf("invalid property")
+			}
+			if plySizes[prop.typ] == 0 || (prop.list && plySizes[prop.countType] == 0) {
+				return p.errorf("unknown type in the property %q", prop.name)
+			}
+			e := p.elements[len(p.elements)-1]
+			e.properties = append(e.properties, prop)
+		case "end_header":
+			if p.format == "" {
+				return p.errorf("missing the format")
+			}
+			if p.order == nil {
+				// the data start on the next line
+				p.line++
+			}
+			return nil
+		}
+	}
+}
+
+// value read a value of the given type
+func (p *plyReader) value(typ string) (float64, error) {
+	if p.order == nil {
+		return p.ascii()
+	}
+	b := p.buf[:plySizes[typ]]
+	if _, err := io.ReadFull(p.r, b); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, p.errorf("unexpected end of file")
+		}
+		return 0, p.errorf("%v", err)
+	}
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(p.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(p.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(p.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(p.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(p.order.Uint32(b))), nil
+	}
+	return math.Float64frombits(p.order.Uint64(b)), nil
+}
+
+// ascii read the next number of the ASCII data
+func (p *plyReader) ascii() (float64, error) {
+	var word []byte
+	for {
+		c, err := p.r.ReadByte()
+		if err == io.EOF && len(word) > 0 {
+			break
+		}
+		if err == io.EOF {
+			return 0, p.errorf("unexpected end of file")
+		}
+		if err != nil {
+			return 0, p.errorf("%v", err)
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			if len(word) > 0 {
+				p.r.UnreadByte()
+				break
+			}
+			if c == '\n' {
+				p.line++
+			}
+			continue
+		}
+		word = append(word, c)
+	}
+	v, err := strconv.ParseFloat(string(word), 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", word)
+	}
+	return v, nil
+}
+
+// element read the values of the properties of an instance of an element,
+// the items of the lists being appended to lists
+func (p *plyReader) element(e *plyElement, values []float64, lists [][]float64) error {
+	for i := 0; i < len(e.properties); i++ {
+		prop := e.properties[i]
+		if !prop.list {
+			v, err := p.value(prop.typ)
+			if err != nil {
+				return err
+			}
+			values[i] = v
+			continue
+		}
+		n, err := p.value(prop.countType)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n != math.Trunc(n) {
+			return p.errorf("invalid length of list %v", n)
+		}
+		lists[i] = lists[i][:0]
+		for j := 0; j < int(n); j++ {
+			v, err := p.value(prop.typ)
+			if err != nil {
+				return err
+			}
+			lists[i] = append(lists[i], v)
+		}
+	}
+	return nil
+}
+
+// skip read the instances of an element that is not used
+func (p *plyReader) skip(e *plyElement) error {
+	values := make([]float64, len(e.properties))
+	lists := make([][]float64, len(e.properties))
+	for i := 0; i < e.count; i++ {
+		if err := p.element(e, values, lists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find return the index of the first property of the names, -1 if there is
+// none
+func (e *plyElement) find(names ...string) int {
+	for i := 0; i < len(names); i++ {
+		for j := 0; j < len(e.properties); j++ {
+			if e.properties[j].name == names[i] && !e.properties[j].list {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// vertices read the vertices of the model
+func (p *plyReader) vertices(e *plyElement, m *Model) error {
+	x, y, z := e.find("x"), e.find("y"), e.find("z")
+	if x < 0 || y < 0 || z < 0 {
+		return p.errorf("the vertices have no x, y, z")
+	}
+	nx, ny, nz := e.find("nx"), e.find("ny"), e.find("nz")
+	hasNormals := nx >= 0 && ny >= 0 && nz >= 0
+	u := e.find("u", "s", "texture_u", "texture_s")
+	v := e.find("v", "t", "texture_v", "texture_t")
+	hasUVs := u >= 0 && v >= 0
+	red := e.find("red", "diffuse_red", "r")
+	green := e.find("green", "diffuse_green", "g")
+	blue := e.find("blue", "diffuse_blue", "b")
+	hasColors := red >= 0 && green >= 0 && blue >= 0
+	// the integer colors are from 0 to 255
+	scale := 1.0
+	if hasColors && e.properties[red].typ != "float" && e.properties[red].typ != "float32" && e.properties[red].typ != "double" && e.properties[red].typ != "float64" {
+		scale = 255
+	}
+	values := make([]float64, len(e.properties))
+	lists := make([][]float64, len(e.properties))
+	for i := 0; i < e.count; i++ {
+		if err := p.element(e, values, lists); err != nil {
+			return err
+		}
+		m.Positions = append(m.Positions, geom.NewVec3(values[x], values[y], values[z]))
+		if hasNormals {
+			m.Normals = append(m.Normals, geom.NewVec3(values[nx], values[ny], values[nz]))
+		}
+		if hasUVs {
+			m.UVs = append(m.UVs, geom.NewVec3(values[u], values[v], 0))
+		}
+		if hasColors {
+			m.Colors = append(m.Colors, geom.NewVec3(
+				geom.LinearSRGB(values[red]/scale),
+				geom.LinearSRGB(values[green]/scale),
+				geom.LinearSRGB(values[blue]/scale)))
+		}
+	}
+	return nil
+}
+
+// faces read the faces of the model, after its vertices
+func (p *plyReader) faces(e *plyElement, m *Model, group *Group) error {
+	list := -1
+	for i := 0; i < len(e.properties); i++ {
+		if e.properties[i].list && (e.properties[i].name == "vertex_indices" || e.properties[i].name == "vertex_index") {
+			list = i
+		}
+	}
+	if list < 0 {
+		return p.errorf("the faces have no vertex_indices")
+	}
+	values := make([]float64, len(e.properties))
+	lists := make([][]float64, len(e.properties))
+	for i := 0; i < e.count; i++ {
+		if err := p.element(e, values, lists); err != nil {
+			return err
+		}
+		face := lists[list]
+		if len(face) < 3 {
+			return p.errorf("face %v : a face needs at least three vertices", i)
+		}
+		for j := 0; j < len(face); j++ {
+			if face[j] < 0 || int(face[j]) >= len(m.Positions) {
+				return p.errorf("face %v : vertex index %v out of range, %v vertices", i, face[j], len(m.Positions))
+			}
+		}
+		for j := 1; j+1 < len(face); j++ {
+			a, b, c := int(face[0]), int(face[j]), int(face[j+1])
+			// the degenerate triangles can not be hit
+			if geom.Cross(m.Positions[b].Minus(m.Positions[a]), m.Positions[c].Minus(m.Positions[a])).SquaredLength() == 0 {
+				continue
+			}
+			group.Indices = append(group.Indices, a, b, c)
+			group.MaterialIndices = append(group.MaterialIndices, 0)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// plyHeader is the header of the test files, a quad and a triangle sharing
+// two of their vertices
+const plyHeader = `ply
+format FORMAT 1.0
+comment test
+element vertex 5
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 2
+property list uchar int vertex_indices
+end_header
+`
+
+// plyVertices are the positions and colors of the vertices of the test files
+var plyVertices = [][6]float64{
+	{0, 0, 0, 255, 0, 0},
+	{1, 0, 0, 0, 255, 0},
+	{1, 1, 0, 0, 0, 255},
+	{0, 1, 0, 255, 255, 255},
+	{2, 0, 0, 0, 0, 0},
+}
+
+// plyFaces are the faces of the test files
+var plyFaces = [][]int{{0, 1, 2, 3}, {1, 4, 2}}
+
+// checkPLYModel check the model of the test files
+func checkPLYModel(t *testing.T, m *Model) {
+	t.Helper()
+	if len(m.Positions) != len(plyVertices) || len(m.Colors) != len(plyVertices) {
+		t.Fatalf("%v positions and %v colors instead of %v", len(m.Positions), len(m.Colors), len(plyVertices))
+	}
+	for i := 0; i < len(plyVertices); i++ {
+		v := plyVertices[i]
+		if m.Positions[i] != geom.NewVec3(v[0], v[1], v[2]) {
+			t.Errorf("vertex %v at %v", i, m.Positions[i])
+		}
+		// 0 and 255 are the same in sRGB and linear
+		if m.Colors[i] != geom.NewVec3(v[3]/255, v[4]/255, v[5]/255) {
+			t.Errorf("vertex %v of color %v", i, m.Colors[i])
+		}
+	}
+	if len(m.Groups) != 1 {
+		t.Fatalf("%v groups instead of 1", len(m.Groups))
+	}
+	want := []int{0, 1, 2, 0, 2, 3, 1, 4, 2}
+	for i := 0; i < len(want); i++ {
+		if m.Groups[0].Indices[i] != want[i] {
+			t.Fatalf("indices %v instead of %v", m.Groups[0].Indices, want)
+		}
+	}
+}
+
+func TestReadPLYASCII(t *testing.T) {
+	var src strings.Builder
+	src.WriteString(strings.Replace(plyHeader, "FORMAT", "ascii", 1))
+	src.WriteString("0 0 0 255 0 0\n1 0 0 0 255 0\n1 1 0 0 0 255\n0 1 0 255 255 255\n2 0 0 0 0 0\n")
+	src.WriteString("4 0 1 2 3\n3 1 4 2\n")
+	m, err := ReadPLY(strings.NewReader(src.String()), "test.ply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPLYModel(t, m)
+}
+
+func TestReadPLYBinary(t *testing.T) {
+	var src bytes.Buffer
+	src.WriteString(strings.Replace(plyHeader, "FORMAT", "binary_little_endian", 1))
+	for i := 0; i < len(plyVertices); i++ {
+		v := plyVertices[i]
+		binary.Write(&src, binary.LittleEndian, []float32{float32(v[0]), float32(v[1]), float32(v[2])})
+		src.Write([]byte{byte(v[3]), byte(v[4]), byte(v[5])})
+	}
+	for i := 0; i < len(plyFaces); i++ {
+		src.WriteByte(byte(len(plyFaces[i])))
+		for j := 0; j < len(plyFaces[i]); j++ {
+			binary.Write(&src, binary.LittleEndian, int32(plyFaces[i][j]))
+		}
+	}
+	m, err := ReadPLY(&src, "test.ply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPLYModel(t, m)
+}
+
+func TestReadPLYPointCloud(t *testing.T) {
+	src := `ply
+format ascii 1.0
+element vertex 3
+property double x
+property double y
+property double z
+property float red
+property float green
+property float blue
+end_header
+0 0 0 0.5 0.5 0.5
+1 0 0 1 1 1
+0 1 0 1 1 1
+`
+	m, err := ReadPLY(strings.NewReader(src), "cloud.ply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 0 || len(m.Positions) != 3 {
+		t.Fatalf("%v groups and %v vertices instead of 0 and 3", len(m.Groups), len(m.Positions))
+	}
+	// the float colors are from 0 to 1, sRGB
+	if c := m.Colors[0].X(); math.Abs(c-0.214) > 0.001 {
+		t.Errorf("sRGB 0.5 read as %v instead of 0.214", c)
+	}
+	if _, err := m.Hitable(); err == nil {
+		t.Error("a point cloud has triangles")
+	}
+	points, err := m.Points(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var box geom.Aabb
+	points.BoundingBox(0, 1, &box)
+	if box.Min() != geom.NewVec3(-0.1, -0.1, -0.1) || box.Max() != geom.NewVec3(1.1, 1.1, 0.1) {
+		t.Errorf("points in %v %v", box.Min(), box.Max())
+	}
+}
+
+func TestReadPLYErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		msg  string
+	}{
+		{"not ply", "obj\n", 1, "not a PLY file"},
+		{"format", "ply\nformat binary_middle_endian 1.0\n", 2, "unknown format"},
+		{"no end", "ply\nformat ascii 1.0\nelement vertex 1\n", 3, "end_header"},
+		{"property type", "ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\n", 4, "unknown type"},
+		{"index", "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+			"element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n1 0 0\n0 1 0\n3 0 1 3\n", 13, "vertex index 3 out of range"},
+		{"number", "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 x 0\n", 8, `invalid number "x"`},
+	}
+	for _, test := range tests {
+		_, err := ReadPLY(strings.NewReader(test.src), "test.ply")
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v : %v is not a located error", test.name, err)
+			continue
+		}
+		if e.Line != test.line || !strings.Contains(e.Msg, test.msg) {
+			t.Errorf("%v : %q instead of line %v %q", test.name, err, test.line, test.msg)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// sizes of the binary STL files
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50
+)
+
+// LoadSTL read the STL file at path, see ReadSTL
+func LoadSTL(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSTL(f, path)
+}
+
+// ReadSTL read an ASCII or binary STL file. The triangles of each solid of
+// an ASCII file form a group of the name of the solid, the ones of a binary
+// file a single group, and the vertices at the same position are shared. The
+// normals of the facets are ignored : as STL requires, the front side of a
+// triangle is the one seeing its vertices counterclockwise. path names the
+// file in the errors.
+func ReadSTL(r io.Reader, path string) (*Model, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &stlReader{
+		path:        path,
+		model:       &Model{Materials: []geom.Material{defaultMaterial()}},
+		vertexIndex: map[geom.Vec3]int{},
+	}
+	// binary files can also start with "solid", their size and their null
+	// bytes tell them apart
+	binarySize := -1
+	if len(data) >= stlHeaderSize+4 {
+		binarySize = stlHeaderSize + 4 + stlTriangleSize*int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	}
+	text := bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid")) && bytes.IndexByte(data, 0) < 0
+	if binarySize != len(data) && text {
+		err = p.ascii(data)
+	} else {
+		err = p.binary(data, binarySize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.model, nil
+}
+
+// stlReader holds the state of the reading of a STL file
+type stlReader struct {
+	path        string
+	line        int
+	model       *Model
+	vertexIndex map[geom.Vec3]int
+}
+
+func (p *stlReader) errorf(format string, args ...interface{}) error {
+	return &Error{Path: p.path, Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// add add a polygon to a group, its vertices being shared with the ones at
+// the same positions
+func (p *stlReader) add(group *Group, vertices []geom.Vec3) {
+	for i := 1; i+1 < len(vertices); i++ {
+		a, b, c := vertices[0], vertices[i], vertices[i+1]
+		// the degenerate triangles can not be hit
+		if geom.Cross(b.Minus(a), c.Minus(a)).SquaredLength() == 0 {
+			continue
+		}
+		group.Indices = append(group.Indices, p.vertex(a), p.vertex(b), p.vertex(c))
+		group.MaterialIndices = append(group.MaterialIndices, 0)
+	}
+}
+
+// vertex return the index of the vertex at a position, adding it the first
+// time
+func (p *stlReader) vertex(v geom.Vec3) int {
+	if i, ok := p.vertexIndex[v]; ok {
+		return i
+	}
+	p.model.Positions = append(p.model.Positions, v)
+	p.vertexIndex[v] = len(p.model.Positions) - 1
+	return len(p.model.Positions) - 1
+}
+
+// binary read a binary file : a header of 80 bytes, the number of triangles
+// and for each triangle its normal, its vertices and 2 bytes of attributes
+func (p *stlReader) binary(data []byte, size int) error {
+	if size < 0 {
+		return p.errorf("not a STL file")
+	}
+	if size != len(data) {
+		return p.errorf("%v bytes instead of %v for %v triangles", len(data), size, (size-stlHeaderSize-4)/stlTriangleSize)
+	}
+	group := &Group{Name: defaultGroup}
+	n := (size - stlHeaderSize - 4) / stlTriangleSize
+	vertices := make([]geom.Vec3, 3)
+	for i := 0; i < n; i++ {
+		// the vertices are after the normal
+		b := data[stlHeaderSize+4+i*stlTriangleSize+12:]
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				v := math.Float32frombits(binary.LittleEndian.Uint32(b[12*j+4*k:]))
+				vertices[j].SetAt(k, float64(v))
+			}
+		}
+		p.add(group, vertices)
+	}
+	if len(group.Indices) > 0 {
+		p.model.Groups = append(p.model.Groups, group)
+	}
+	return nil
+}
+
+// ascii read an ASCII file : solids of facets, whose outer loop is a list of
+// vertices
+func (p *stlReader) ascii(data []byte) error {
+	var group *Group
+	var vertices []geom.Vec3
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		p.line = i + 1
+		fields := strings.Fields(lines[i])
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "solid":
+			name := strings.Join(fields[1:], " ")
+			if name == "" {
+				name = defaultGroup
+			}
+			group = p.model.Group(name)
+			if group == nil {
+				group = &Group{Name: name}
+				p.model.Groups = append(p.model.Groups, group)
+			}
+		case "outer":
+			vertices = vertices[:0]
+		case "vertex":
+			if len(fields) != 4 {
+				return p.errorf("expected vertex x y z")
+			}
+			var v geom.Vec3
+			for k := 0; k < 3; k++ {
+				f, err := strconv.ParseFloat(fields[k+1], 64)
+				if err != nil {
+					return p.errorf("invalid number %q", fields[k+1])
+				}
+				v.SetAt(k, f)
+			}
+			vertices = append(vertices, v)
+		case "endloop":
+			if group == nil {
+				return p.errorf("facet outside of a solid")
+			}
+			if len(vertices) < 3 {
+				return p.errorf("a facet needs at least three vertices")
+			}
+			p.add(group, vertices)
+		case "facet", "endfacet", "endsolid":
+		default:
+			return p.errorf("unexpected %q", fields[0])
+		}
+	}
+	// the solids without facets are dropped
+	groups := p.model.Groups[:0]
+	for i := 0; i < len(p.model.Groups); i++ {
+		if len(p.model.Groups[i].Indices) > 0 {
+			groups = append(groups, p.model.Groups[i])
+		}
+	}
+	p.model.Groups = groups
+	return nil
+}
@@ -0,0 +1,158 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// stlTriangles are the triangles of the test files, a square of two
+// triangles and a degenerate triangle
+var stlTriangles = [][3]geom.Vec3{
+	{geom.NewVec3(0, 0, 0), geom.NewVec3(1, 0, 0), geom.NewVec3(1, 1, 0)},
+	{geom.NewVec3(0, 0, 0), geom.NewVec3(1, 1, 0), geom.NewVec3(0, 1, 0)},
+	{geom.NewVec3(0, 0, 0), geom.NewVec3(1, 0, 0), geom.NewVec3(2, 0, 0)},
+}
+
+// binarySTL return a binary file of triangles, whose header starts with
+// "solid" as some exporters write it
+func binarySTL(triangles [][3]geom.Vec3) []byte {
+	var buf bytes.Buffer
+	header := make([]byte, stlHeaderSize)
+	copy(header, "solid binary")
+	buf.Write(header)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(triangles)))
+	for i := 0; i < len(triangles); i++ {
+		// the normal is ignored
+		binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 1})
+		for j := 0; j < 3; j++ {
+			v := triangles[i][j]
+			binary.Write(&buf, binary.LittleEndian, []float32{float32(v.X()), float32(v.Y()), float32(v.Z())})
+		}
+		buf.Write([]byte{0, 0})
+	}
+	return buf.Bytes()
+}
+
+// checkSTLSquare check that a group is the square of the test files, on
+// shared vertices
+func checkSTLSquare(t *testing.T, m *Model, g *Group) {
+	t.Helper()
+	if len(g.Indices) != 6 || len(g.MaterialIndices) != 2 {
+		t.Fatalf("%v indices and %v triangles instead of 6 and 2", len(g.Indices), len(g.MaterialIndices))
+	}
+	positions := trianglePositions(m, g)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			if positions[3*i+j] != stlTriangles[i][j] {
+				t.Fatalf("triangle %v at %v", i, positions[3*i:3*i+3])
+			}
+		}
+	}
+}
+
+func TestReadSTLASCII(t *testing.T) {
+	src := `solid square
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 2 0 0
+    endloop
+  endfacet
+endsolid square
+solid empty
+endsolid empty
+solid roof
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 1
+      vertex 1 0 1
+      vertex 0 1 1
+    endloop
+  endfacet
+endsolid roof
+`
+	m, err := ReadSTL(strings.NewReader(src), "test.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the solids are groups, the empty one is dropped
+	if len(m.Groups) != 2 || m.Groups[0].Name != "square" || m.Groups[1].Name != "roof" {
+		t.Fatalf("groups %v", m.Groups)
+	}
+	// the degenerate triangle is skipped, the square shares its vertices
+	checkSTLSquare(t, m, m.Groups[0])
+	if len(m.Positions) != 7 {
+		t.Errorf("%v vertices instead of 7", len(m.Positions))
+	}
+}
+
+func TestReadSTLBinary(t *testing.T) {
+	data := binarySTL(stlTriangles)
+	if len(data) != stlHeaderSize+4+stlTriangleSize*len(stlTriangles) {
+		t.Fatalf("binary file of %v bytes", len(data))
+	}
+	m, err := ReadSTL(bytes.NewReader(data), "test.stl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 1 {
+		t.Fatalf("%v groups instead of 1", len(m.Groups))
+	}
+	checkSTLSquare(t, m, m.Groups[0])
+	// the vertex (2, 0, 0) is only used by the degenerate triangle
+	if len(m.Positions) != 4 {
+		t.Errorf("%v vertices instead of 4", len(m.Positions))
+	}
+	m.Scale(2)
+	if m.Positions[2] != geom.NewVec3(2, 2, 0) {
+		t.Errorf("vertex scaled at %v instead of [2 2 0]", m.Positions[2])
+	}
+}
+
+func TestReadSTLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		msg  string
+	}{
+		{"not stl", "ply\n", 0, "not a STL file"},
+		{"truncated", string(binarySTL(stlTriangles)[:stlHeaderSize+4+stlTriangleSize]), 0, "bytes instead of"},
+		{"no solid", "facet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\n", 0, "not a STL file"},
+		{"short vertex", "solid a\nfacet normal 0 0 1\nouter loop\nvertex 0 0\n", 4, "expected vertex x y z"},
+		{"number", "solid a\nfacet normal 0 0 1\nouter loop\nvertex 0 x 0\n", 4, `invalid number "x"`},
+		{"two vertices", "solid a\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\n", 6, "at least three vertices"},
+		{"keyword", "solid a\nface normal 0 0 1\n", 2, `unexpected "face"`},
+	}
+	for _, test := range tests {
+		_, err := ReadSTL(strings.NewReader(test.src), "test.stl")
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v : %v is not a located error", test.name, err)
+			continue
+		}
+		if e.Line != test.line || !strings.Contains(e.Msg, test.msg) {
+			t.Errorf("%v : %q instead of line %v %q", test.name, err, test.line, test.msg)
+		}
+	}
+}
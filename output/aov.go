@@ -0,0 +1,185 @@
+package output
+
+import (
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// aovChannels are the names of the OpenEXR channels of each AOV
+var aovChannels = map[string][]string{
+	render.AOVNormal:     {"normal.X", "normal.Y", "normal.Z"},
+	render.AOVPosition:   {"position.X", "position.Y", "position.Z"},
+	render.AOVDepth:      {"Z"},
+	render.AOVAlbedo:     {"albedo.R", "albedo.G", "albedo.B"},
+	render.AOVUV:         {"uv.U", "uv.V"},
+	render.AOVObjectID:   {"objectID"},
+	render.AOVMaterialID: {"materialID"},
+}
+
+// AddAOVs add the AOVs as channels of the image : normal.X, position.X,
+// albedo.R, uv.U..., the depth as the Z channel and the IDs as the objectID
+// and materialID channels
+func (img *EXRImage) AddAOVs(aovs *render.AOVs) {
+	layers := aovs.Layers()
+	for i := 0; i < len(layers); i++ {
+		names := aovChannels[layers[i].Name]
+		fb := layers[i].Buffer
+		for c := 0; c < len(names); c++ {
+			pix := make([]float32, len(fb.Pix))
+			for j := 0; j < len(fb.Pix); j++ {
+				pix[j] = float32(fb.Pix[j].At(c))
+			}
+			img.AddChannel(names[c], pix)
+		}
+	}
+}
+
+// AOVs return the AOVs stored in the channels of the image by AddAOVs, the
+// missing ones being nil
+func (img *EXRImage) AOVs() *render.AOVs {
+	aovs := &render.AOVs{}
+	for _, layer := range aovs.Layers() {
+		names := aovChannels[layer.Name]
+		planes := make([][]float32, len(names))
+		complete := true
+		for c := 0; c < len(names); c++ {
+			planes[c] = img.Channel(names[c])
+			complete = complete && planes[c] != nil
+		}
+		if !complete {
+			continue
+		}
+		fb := render.NewFramebuffer(img.Width, img.Height)
+		for i := 0; i < len(fb.Pix); i++ {
+			for c := 0; c < 3; c++ {
+				// the scalar AOVs are in the three components
+				fb.Pix[i].SetAt(c, float64(planes[min(c, len(planes)-1)][i]))
+			}
+			if layer.Name == render.AOVUV {
+				fb.Pix[i].SetAt(2, 0)
+			}
+		}
+		aovs.SetLayer(layer.Name, fb)
+	}
+	return aovs
+}
+
+// AOVPath return the path of the file of an AOV next to the image at path,
+// image_normal.png for the normal of image.png
+func AOVPath(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + name + ext
+}
+
+// WriteAOVs write each AOV in its own file next to the image at path, named
+// by AOVPath. The high dynamic range formats store the values of the AOVs,
+// the 8 bits ones a visualization (see VisualizeAOV).
+func WriteAOVs(path string, aovs *render.AOVs, format Format) error {
+	if format == FormatAuto {
+		var err error
+		if format, err = FormatFromPath(path); err != nil {
+			return err
+		}
+	}
+	hdr := format == FormatHDR || format == FormatPFM || format == FormatEXR
+	layers := aovs.Layers()
+	for i := 0; i < len(layers); i++ {
+		fb := layers[i].Buffer
+		if !hdr {
+			fb = VisualizeAOV(layers[i].Name, fb)
+		}
+		if err := WriteFile(AOVPath(path, layers[i].Name), fb, format, ToneMapping{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFileWithAOVs write the image and its AOVs : an OpenEXR file holds them
+// all in its channels, the other formats are written with WriteFile and
+// WriteAOVs
+func WriteFileWithAOVs(path string, fb render.Image, aovs *render.AOVs, format Format, tm ToneMapping) error {
+	if format == FormatAuto {
+		var err error
+		if format, err = FormatFromPath(path); err != nil {
+			return err
+		}
+	}
+	if format != FormatEXR {
+		if err := WriteFile(path, fb, format, tm); err != nil {
+			return err
+		}
+		return WriteAOVs(path, aovs, format)
+	}
+	img := NewEXRImage(fb.Size())
+	img.AddLayer("", fb)
+	img.AddAlpha("", 1)
+	img.AddAOVs(aovs)
+	return writeFile(path, func(w io.Writer) error {
+		return WriteEXR(w, img, EXRZIPCompression)
+	})
+}
+
+// VisualizeAOV return a displayable image of an AOV : the normals are mapped
+// from [-1, 1] to [0, 1], the positions and the depth are scaled to the range
+// of the image and every ID gets its own color
+func VisualizeAOV(name string, fb *render.Framebuffer) *render.Framebuffer {
+	vis := render.NewFramebuffer(fb.Width, fb.Height)
+	switch name {
+	case render.AOVNormal:
+		for i := 0; i < len(fb.Pix); i++ {
+			if fb.Pix[i].SquaredLength() > 0 {
+				vis.Pix[i] = fb.Pix[i].PlusScalar(1).TimesScalar(0.5)
+			}
+		}
+	case render.AOVPosition, render.AOVDepth:
+		lo := geom.NewVec3(math.Inf(1), math.Inf(1), math.Inf(1))
+		hi := geom.NewVec3(math.Inf(-1), math.Inf(-1), math.Inf(-1))
+		for i := 0; i < len(fb.Pix); i++ {
+			lo = geom.NewVec3(math.Min(lo.X(), fb.Pix[i].X()), math.Min(lo.Y(), fb.Pix[i].Y()), math.Min(lo.Z(), fb.Pix[i].Z()))
+			hi = geom.NewVec3(math.Max(hi.X(), fb.Pix[i].X()), math.Max(hi.Y(), fb.Pix[i].Y()), math.Max(hi.Z(), fb.Pix[i].Z()))
+		}
+		for i := 0; i < len(fb.Pix); i++ {
+			for c := 0; c < 3; c++ {
+				if hi.At(c) > lo.At(c) {
+					vis.Pix[i].SetAt(c, (fb.Pix[i].At(c)-lo.At(c))/(hi.At(c)-lo.At(c)))
+				}
+			}
+		}
+	case render.AOVObjectID, render.AOVMaterialID:
+		for i := 0; i < len(fb.Pix); i++ {
+			vis.Pix[i] = idColor(int(fb.Pix[i].X()))
+		}
+	default:
+		copy(vis.Pix, fb.Pix)
+	}
+	return vis
+}
+
+// idColor return a color for an ID, black for 0, by hashing it into a hue
+func idColor(id int) geom.Vec3 {
+	if id == 0 {
+		return geom.NewVec3(0, 0, 0)
+	}
+	// golden ratio steps spread the consecutive IDs around the hue circle
+	h := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	switch int(h) {
+	case 0:
+		return geom.NewVec3(1, x, 0)
+	case 1:
+		return geom.NewVec3(x, 1, 0)
+	case 2:
+		return geom.NewVec3(0, 1, x)
+	case 3:
+		return geom.NewVec3(0, x, 1)
+	case 4:
+		return geom.NewVec3(x, 0, 1)
+	}
+	return geom.NewVec3(1, 0, x)
+}
@@ -0,0 +1,277 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// EXRCompression is the compression of the pixels of an OpenEXR file, its
+// value is the code of the format
+type EXRCompression int
+
+const (
+	// EXRNoCompression stores the pixels as they are
+	EXRNoCompression EXRCompression = 0
+	// EXRZIPSCompression compresses each scanline with zlib
+	EXRZIPSCompression EXRCompression = 2
+	// EXRZIPCompression compresses blocks of 16 scanlines with zlib
+	EXRZIPCompression EXRCompression = 3
+)
+
+// linesPerBlock return the number of scanlines compressed together
+func (c EXRCompression) linesPerBlock() int {
+	if c == EXRZIPCompression {
+		return 16
+	}
+	return 1
+}
+
+// pixel types of the OpenEXR channels
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+// exrMagic is the number starting every OpenEXR file
+const exrMagic = 20000630
+
+// EXRChannel is a named plane of the image, the first value of Pix being the
+// top left pixel. In an OpenEXR file, the channels of a layer are named
+// layer.channel, as normal.X or albedo.R.
+type EXRChannel struct {
+	Name string
+	Pix  []float32
+}
+
+// EXRImage is a set of channels of the same size, which are stored as 32
+// bits floats by WriteEXR
+type EXRImage struct {
+	Width    int
+	Height   int
+	Channels []EXRChannel
+}
+
+// NewEXRImage instantiate an image without channels
+func NewEXRImage(width, height int) *EXRImage {
+	return &EXRImage{
+		Width:  width,
+		Height: height,
+	}
+}
+
+// AddChannel add a channel, replacing the one with the same name
+func (img *EXRImage) AddChannel(name string, pix []float32) {
+	for i := 0; i < len(img.Channels); i++ {
+		if img.Channels[i].Name == name {
+			img.Channels[i].Pix = pix
+			return
+		}
+	}
+	img.Channels = append(img.Channels, EXRChannel{Name: name, Pix: pix})
+}
+
+// Channel return the values of the channel with the given name, nil if there
+// is none
+func (img *EXRImage) Channel(name string) []float32 {
+	for i := 0; i < len(img.Channels); i++ {
+		if img.Channels[i].Name == name {
+			return img.Channels[i].Pix
+		}
+	}
+	return nil
+}
+
+// layerChannels return the names of the channels of a layer, the empty name
+// being the main R, G, B layer
+func layerChannels(layer string, names ...string) []string {
+	if layer == "" {
+		return names
+	}
+	full := make([]string, len(names))
+	for i := 0; i < len(names); i++ {
+		full[i] = layer + "." + names[i]
+	}
+	return full
+}
+
+// AddLayer add the R, G and B channels of a layer from a film or framebuffer
+// of the size of the image, an empty layer name being the main color of the
+// image
+func (img *EXRImage) AddLayer(layer string, fb render.Image) {
+	names := layerChannels(layer, "R", "G", "B")
+	var planes [3][]float32
+	for c := 0; c < 3; c++ {
+		planes[c] = make([]float32, img.Width*img.Height)
+	}
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			col := fb.At(x, y)
+			for c := 0; c < 3; c++ {
+				planes[c][y*img.Width+x] = float32(col.At(c))
+			}
+		}
+	}
+	for c := 0; c < 3; c++ {
+		img.AddChannel(names[c], planes[c])
+	}
+}
+
+// AddAlpha add a constant alpha channel to a layer
+func (img *EXRImage) AddAlpha(layer string, alpha float32) {
+	pix := make([]float32, img.Width*img.Height)
+	for i := 0; i < len(pix); i++ {
+		pix[i] = alpha
+	}
+	img.AddChannel(layerChannels(layer, "A")[0], pix)
+}
+
+// Layer return the R, G and B channels of a layer as a framebuffer
+func (img *EXRImage) Layer(layer string) (*render.Framebuffer, error) {
+	names := layerChannels(layer, "R", "G", "B")
+	var planes [3][]float32
+	for c := 0; c < 3; c++ {
+		if planes[c] = img.Channel(names[c]); planes[c] == nil {
+			return nil, fmt.Errorf("no channel %v in the EXR image", names[c])
+		}
+	}
+	fb := render.NewFramebuffer(img.Width, img.Height)
+	for i := 0; i < len(fb.Pix); i++ {
+		fb.Pix[i] = geom.NewVec3(float64(planes[0][i]), float64(planes[1][i]), float64(planes[2][i]))
+	}
+	return fb, nil
+}
+
+// WriteEXR write the image as a single part scanline OpenEXR file of 32 bits
+// float channels
+func WriteEXR(w io.Writer, img *EXRImage, compression EXRCompression) error {
+	if compression != EXRNoCompression && compression != EXRZIPSCompression && compression != EXRZIPCompression {
+		return fmt.Errorf("unsupported EXR compression %v", int(compression))
+	}
+	if img.Width <= 0 || img.Height <= 0 || len(img.Channels) == 0 {
+		return fmt.Errorf("can not write an empty EXR image")
+	}
+	// the channels are stored in alphabetical order
+	channels := append([]EXRChannel(nil), img.Channels...)
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+	for i := 0; i < len(channels); i++ {
+		if len(channels[i].Pix) != img.Width*img.Height {
+			return fmt.Errorf("the EXR channel %v has %v values instead of %v", channels[i].Name, len(channels[i].Pix), img.Width*img.Height)
+		}
+	}
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, uint32(exrMagic))
+	// version 2, single part scanline file
+	binary.Write(&header, binary.LittleEndian, uint32(2))
+	var chlist bytes.Buffer
+	for i := 0; i < len(channels); i++ {
+		chlist.WriteString(channels[i].Name)
+		chlist.WriteByte(0)
+		// pixel type, pLinear and reserved bytes, x and y sampling
+		binary.Write(&chlist, binary.LittleEndian, []int32{exrFloat, 0, 1, 1})
+	}
+	chlist.WriteByte(0)
+	writeEXRAttribute(&header, "channels", "chlist", chlist.Bytes())
+	writeEXRAttribute(&header, "compression", "compression", []byte{byte(compression)})
+	window := exrBytes([]int32{0, 0, int32(img.Width - 1), int32(img.Height - 1)})
+	writeEXRAttribute(&header, "dataWindow", "box2i", window)
+	writeEXRAttribute(&header, "displayWindow", "box2i", window)
+	// increasing y
+	writeEXRAttribute(&header, "lineOrder", "lineOrder", []byte{0})
+	writeEXRAttribute(&header, "pixelAspectRatio", "float", exrBytes(float32(1)))
+	writeEXRAttribute(&header, "screenWindowCenter", "v2f", exrBytes([]float32{0, 0}))
+	writeEXRAttribute(&header, "screenWindowWidth", "float", exrBytes(float32(1)))
+	header.WriteByte(0)
+
+	// the chunks follow the table of their offsets in the file
+	lines := compression.linesPerBlock()
+	nChunks := (img.Height + lines - 1) / lines
+	offsets := make([]uint64, nChunks)
+	offset := uint64(header.Len() + 8*nChunks)
+	var chunks bytes.Buffer
+	raw := make([]byte, 0, 4*img.Width*len(channels)*lines)
+	for c := 0; c < nChunks; c++ {
+		y0 := c * lines
+		y1 := min(y0+lines, img.Height)
+		raw = raw[:0]
+		for y := y0; y < y1; y++ {
+			for i := 0; i < len(channels); i++ {
+				row := channels[i].Pix[y*img.Width : (y+1)*img.Width]
+				for x := 0; x < img.Width; x++ {
+					raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(row[x]))
+				}
+			}
+		}
+		data := raw
+		if compression != EXRNoCompression {
+			data = zipEXR(raw)
+		}
+		offsets[c] = offset + uint64(chunks.Len())
+		binary.Write(&chunks, binary.LittleEndian, []int32{int32(y0), int32(len(data))})
+		chunks.Write(data)
+	}
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, offsets); err != nil {
+		return err
+	}
+	_, err := w.Write(chunks.Bytes())
+	return err
+}
+
+// writeEXRAttribute write an attribute of the header
+func writeEXRAttribute(buf *bytes.Buffer, name, kind string, value []byte) {
+	buf.WriteString(name)
+	buf.WriteByte(0)
+	buf.WriteString(kind)
+	buf.WriteByte(0)
+	binary.Write(buf, binary.LittleEndian, int32(len(value)))
+	buf.Write(value)
+}
+
+// exrBytes return the little-endian encoding of a fixed size value
+func exrBytes(v interface{}) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, v)
+	return buf.Bytes()
+}
+
+// zipEXR compress the pixels of a chunk as OpenEXR does : the even and the
+// odd bytes are separated, each byte is replaced by its difference with the
+// previous one and the result is compressed with zlib. The data is kept
+// uncompressed if this does not make it smaller.
+func zipEXR(raw []byte) []byte {
+	tmp := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i := 0; i < len(raw); i++ {
+		if i%2 == 0 {
+			tmp[i/2] = raw[i]
+		} else {
+			tmp[half+i/2] = raw[i]
+		}
+	}
+	for i := len(tmp) - 1; i > 0; i-- {
+		tmp[i] = byte(int(tmp[i]) - int(tmp[i-1]) + 128)
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(tmp)
+	zw.Close()
+	if buf.Len() >= len(raw) {
+		return raw
+	}
+	return buf.Bytes()
+}
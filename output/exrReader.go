@@ -0,0 +1,278 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// exrChannelInfo is a channel described by the header of an OpenEXR file
+type exrChannelInfo struct {
+	name      string
+	pixelType int32
+}
+
+// ReadEXR read a single part scanline OpenEXR file, uncompressed or
+// compressed with ZIP or ZIPS, whose channels are not subsampled. The values
+// of every pixel type are converted to 32 bits floats.
+func ReadEXR(r io.Reader) (*EXRImage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rd := &exrReader{data: data}
+	if rd.uint32() != exrMagic {
+		return nil, fmt.Errorf("not an OpenEXR file")
+	}
+	// the low byte is the version, the others are flags for tiled, long
+	// names, deep and multipart files
+	version := rd.uint32()
+	if version&0xff != 2 || version&^(0xff|0x400) != 0 {
+		return nil, fmt.Errorf("unsupported OpenEXR file version %#x", version)
+	}
+
+	var channels []exrChannelInfo
+	compression := EXRCompression(-1)
+	var window []int32
+	for {
+		name := rd.string()
+		if name == "" {
+			break
+		}
+		kind := rd.string()
+		size := int(rd.int32())
+		value := rd.bytes(size)
+		if rd.err != nil {
+			break
+		}
+		switch name {
+		case "channels":
+			if kind != "chlist" {
+				return nil, fmt.Errorf("EXR channels of type %v", kind)
+			}
+			if channels, err = parseEXRChannels(value); err != nil {
+				return nil, err
+			}
+		case "compression":
+			if size != 1 {
+				return nil, fmt.Errorf("EXR compression of size %v", size)
+			}
+			compression = EXRCompression(value[0])
+		case "dataWindow":
+			if size != 16 {
+				return nil, fmt.Errorf("EXR data window of size %v", size)
+			}
+			window = make([]int32, 4)
+			binary.Read(bytes.NewReader(value), binary.LittleEndian, window)
+		}
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+	if channels == nil || window == nil {
+		return nil, fmt.Errorf("EXR header without channels or data window")
+	}
+	if compression != EXRNoCompression && compression != EXRZIPSCompression && compression != EXRZIPCompression {
+		return nil, fmt.Errorf("unsupported EXR compression %v", int(compression))
+	}
+	img := NewEXRImage(int(window[2]-window[0]+1), int(window[3]-window[1]+1))
+	if img.Width <= 0 || img.Height <= 0 {
+		return nil, fmt.Errorf("empty EXR data window")
+	}
+	lineSize := 0
+	for i := 0; i < len(channels); i++ {
+		img.Channels = append(img.Channels, EXRChannel{Name: channels[i].name, Pix: make([]float32, img.Width*img.Height)})
+		lineSize += exrPixelSize(channels[i].pixelType) * img.Width
+	}
+
+	lines := compression.linesPerBlock()
+	nChunks := (img.Height + lines - 1) / lines
+	offsets := make([]uint64, nChunks)
+	for c := 0; c < nChunks; c++ {
+		offsets[c] = rd.uint64()
+	}
+	for c := 0; c < nChunks; c++ {
+		if offsets[c] >= uint64(len(data)) {
+			return nil, fmt.Errorf("EXR chunk %v out of the file", c)
+		}
+		rd.pos = int(offsets[c])
+		y0 := int(rd.int32() - window[1])
+		size := int(rd.int32())
+		chunk := rd.bytes(size)
+		if rd.err != nil {
+			return nil, rd.err
+		}
+		if y0 < 0 || y0 >= img.Height {
+			return nil, fmt.Errorf("EXR chunk %v at the line %v out of the image", c, y0)
+		}
+		y1 := min(y0+lines, img.Height)
+		expected := lineSize * (y1 - y0)
+		if size < expected {
+			if chunk, err = unzipEXR(chunk, expected); err != nil {
+				return nil, err
+			}
+		}
+		if len(chunk) != expected {
+			return nil, fmt.Errorf("EXR chunk %v has %v bytes instead of %v", c, len(chunk), expected)
+		}
+		pos := 0
+		for y := y0; y < y1; y++ {
+			for i := 0; i < len(channels); i++ {
+				row := img.Channels[i].Pix[y*img.Width : (y+1)*img.Width]
+				for x := 0; x < img.Width; x++ {
+					switch channels[i].pixelType {
+					case exrUint:
+						row[x] = float32(binary.LittleEndian.Uint32(chunk[pos:]))
+					case exrHalf:
+						row[x] = halfToFloat(binary.LittleEndian.Uint16(chunk[pos:]))
+					case exrFloat:
+						row[x] = math.Float32frombits(binary.LittleEndian.Uint32(chunk[pos:]))
+					}
+					pos += exrPixelSize(channels[i].pixelType)
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// exrReader decodes the little-endian values of an OpenEXR file, the first
+// error making the following reads return zeros
+type exrReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (rd *exrReader) bytes(n int) []byte {
+	if rd.err != nil {
+		return nil
+	}
+	if n < 0 || rd.pos+n > len(rd.data) {
+		rd.err = fmt.Errorf("unexpected end of the EXR file")
+		return nil
+	}
+	b := rd.data[rd.pos : rd.pos+n]
+	rd.pos += n
+	return b
+}
+
+func (rd *exrReader) uint32() uint32 {
+	b := rd.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (rd *exrReader) int32() int32 {
+	return int32(rd.uint32())
+}
+
+func (rd *exrReader) uint64() uint64 {
+	b := rd.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// string read a null terminated string
+func (rd *exrReader) string() string {
+	if rd.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(rd.data[rd.pos:], 0)
+	if end < 0 {
+		rd.err = fmt.Errorf("unexpected end of the EXR file")
+		return ""
+	}
+	s := string(rd.data[rd.pos : rd.pos+end])
+	rd.pos += end + 1
+	return s
+}
+
+// parseEXRChannels decode a chlist attribute
+func parseEXRChannels(value []byte) ([]exrChannelInfo, error) {
+	rd := &exrReader{data: value}
+	var channels []exrChannelInfo
+	for {
+		name := rd.string()
+		if name == "" {
+			break
+		}
+		pixelType := rd.int32()
+		// pLinear and reserved bytes
+		rd.bytes(4)
+		xSampling := rd.int32()
+		ySampling := rd.int32()
+		if rd.err != nil {
+			break
+		}
+		if pixelType < exrUint || pixelType > exrFloat {
+			return nil, fmt.Errorf("EXR channel %v of unknown pixel type %v", name, pixelType)
+		}
+		if xSampling != 1 || ySampling != 1 {
+			return nil, fmt.Errorf("EXR channel %v is subsampled", name)
+		}
+		channels = append(channels, exrChannelInfo{name: name, pixelType: pixelType})
+	}
+	return channels, rd.err
+}
+
+// exrPixelSize return the size in bytes of a value of the pixel type
+func exrPixelSize(pixelType int32) int {
+	if pixelType == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+// unzipEXR reverse zipEXR
+func unzipEXR(data []byte, size int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tmp := make([]byte, size)
+	if _, err := io.ReadFull(zr, tmp); err != nil {
+		return nil, fmt.Errorf("EXR chunk : %v", err)
+	}
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = byte(int(tmp[i-1]) + int(tmp[i]) - 128)
+	}
+	raw := make([]byte, size)
+	half := (size + 1) / 2
+	for i := 0; i < size; i++ {
+		if i%2 == 0 {
+			raw[i] = tmp[i/2]
+		} else {
+			raw[i] = tmp[half+i/2]
+		}
+	}
+	return raw, nil
+}
+
+// halfToFloat convert a 16 bits float to a 32 bits one
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+	switch {
+	case exponent == 0 && mantissa == 0:
+		return math.Float32frombits(sign)
+	case exponent == 0:
+		// subnormal
+		v := float32(mantissa) / (1 << 24)
+		if sign != 0 {
+			v = -v
+		}
+		return v
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	}
+	return math.Float32frombits(sign | (exponent+127-15)<<23 | mantissa<<13)
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// testEXRImage return an image of the main R, G, B and A channels and of
+// extra layers. The noisy channels do not compress, the smooth ones do.
+func testEXRImage(width, height int, noisy bool) *EXRImage {
+	rnd := rand.New(rand.NewSource(int64(width*1000 + height)))
+	img := NewEXRImage(width, height)
+	names := []string{"R", "G", "B", "A", "normal.X", "normal.Y", "normal.Z", "albedo.R"}
+	for c := 0; c < len(names); c++ {
+		pix := make([]float32, width*height)
+		for i := 0; i < len(pix); i++ {
+			if noisy {
+				pix[i] = math.Float32frombits(rnd.Uint32())
+			} else {
+				pix[i] = float32(c) + float32(i/width)/8
+			}
+		}
+		img.AddChannel(names[c], pix)
+	}
+	return img
+}
+
+// channelNames return the sorted names of the channels of an image
+func channelNames(img *EXRImage) []string {
+	names := make([]string, len(img.Channels))
+	for i := 0; i < len(names); i++ {
+		names[i] = img.Channels[i].Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestEXRRoundTrip(t *testing.T) {
+	compressions := map[string]EXRCompression{
+		"none": EXRNoCompression,
+		"zips": EXRZIPSCompression,
+		"zip":  EXRZIPCompression,
+	}
+	for name, compression := range compressions {
+		// the heights are not multiples of the 16 lines of the ZIP blocks
+		for _, height := range []int{1, 7, 16, 37} {
+			for _, noisy := range []bool{false, true} {
+				img := testEXRImage(5, height, noisy)
+				var buf bytes.Buffer
+				if err := WriteEXR(&buf, img, compression); err != nil {
+					t.Fatal(err)
+				}
+				got, err := ReadEXR(&buf)
+				if err != nil {
+					t.Fatalf("%v, height %v, noisy %v : %v", name, height, noisy, err)
+				}
+				if got.Width != img.Width || got.Height != img.Height {
+					t.Fatalf("%v : size %vx%v instead of %vx%v", name, got.Width, got.Height, img.Width, img.Height)
+				}
+				want := channelNames(img)
+				if names := channelNames(got); len(names) != len(want) {
+					t.Fatalf("%v : channels %v instead of %v", name, names, want)
+				}
+				for c := 0; c < len(want); c++ {
+					wantPix := img.Channel(want[c])
+					gotPix := got.Channel(want[c])
+					if gotPix == nil {
+						t.Fatalf("%v : no channel %v", name, want[c])
+					}
+					for i := 0; i < len(wantPix); i++ {
+						if math.Float32bits(gotPix[i]) != math.Float32bits(wantPix[i]) {
+							t.Fatalf("%v, height %v, noisy %v : %v[%v] is %v instead of %v", name, height, noisy, want[c], i, gotPix[i], wantPix[i])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestZipEXRIncompressible(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	raw := make([]byte, 4096)
+	rnd.Read(raw)
+	if data := zipEXR(raw); !bytes.Equal(data, raw) {
+		t.Errorf("random bytes are compressed to %v bytes instead of being kept", len(data))
+	}
+	smooth := make([]byte, 4096)
+	if data := zipEXR(smooth); len(data) >= len(smooth) {
+		t.Errorf("null bytes are not compressed, %v bytes", len(data))
+	}
+}
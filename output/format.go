@@ -0,0 +1,144 @@
+package output
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// Format is an image file format
+type Format int
+
+const (
+	// FormatAuto selects the format from the extension of the file
+	FormatAuto Format = iota
+	// FormatPNG is the Portable Network Graphics format
+	FormatPNG
+	// FormatPPM is the binary (P6) Portable PixMap format
+	FormatPPM
+	// FormatPPMASCII is the ASCII (P3) Portable PixMap format
+	FormatPPMASCII
+	// FormatHDR is the Radiance RGBE format, storing the linear radiance
+	FormatHDR
+	// FormatPFM is the Portable FloatMap format, storing the linear radiance
+	FormatPFM
+	// FormatEXR is the OpenEXR format, storing the linear radiance in the
+	// R, G, B channels with an opaque alpha, compressed with ZIP
+	FormatEXR
+)
+
+// formatNames are the names accepted by ParseFormat
+var formatNames = map[string]Format{
+	"auto": FormatAuto,
+	"png":  FormatPNG,
+	"ppm":  FormatPPM,
+	"p6":   FormatPPM,
+	"p3":   FormatPPMASCII,
+	"hdr":  FormatHDR,
+	"pfm":  FormatPFM,
+	"exr":  FormatEXR,
+}
+
+// formatExtensions are the extensions recognized by FormatFromPath
+var formatExtensions = map[string]Format{
+	".png": FormatPNG,
+	".ppm": FormatPPM,
+	".hdr": FormatHDR,
+	".pfm": FormatPFM,
+	".exr": FormatEXR,
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+	case FormatPNG:
+		return "png"
+	case FormatPPM:
+		return "ppm"
+	case FormatPPMASCII:
+		return "p3"
+	case FormatHDR:
+		return "hdr"
+	case FormatPFM:
+		return "pfm"
+	case FormatEXR:
+		return "exr"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat return the format of the given name (png, ppm or p6, p3, hdr,
+// pfm, exr, auto)
+func ParseFormat(name string) (Format, error) {
+	if f, ok := formatNames[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return FormatAuto, fmt.Errorf("unknown image format %q", name)
+}
+
+// FormatFromPath return the format matching the extension of path
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if f, ok := formatExtensions[ext]; ok {
+		return f, nil
+	}
+	return FormatAuto, fmt.Errorf("no image format for the extension %q of %s", ext, path)
+}
+
+// Write encode a film or framebuffer in the given format, which can not be
+// FormatAuto. The high dynamic range formats (HDR, PFM, EXR) store the linear
+// radiance, the others the 8 bits values given by the tone mapping.
+func Write(w io.Writer, fb render.Image, format Format, tm ToneMapping) error {
+	switch format {
+	case FormatPNG:
+		return png.Encode(w, ToImage(fb, tm))
+	case FormatPPM:
+		return WritePPM(w, ToImage(fb, tm), false)
+	case FormatPPMASCII:
+		return WritePPM(w, ToImage(fb, tm), true)
+	case FormatHDR:
+		return WriteHDR(w, fb)
+	case FormatPFM:
+		return WritePFM(w, fb)
+	case FormatEXR:
+		img := NewEXRImage(fb.Size())
+		img.AddLayer("", fb)
+		img.AddAlpha("", 1)
+		return WriteEXR(w, img, EXRZIPCompression)
+	}
+	return fmt.Errorf("can not write the image format %v", format)
+}
+
+// WriteFile write a film or framebuffer in the file at path, with the format of its
+// extension if format is FormatAuto
+func WriteFile(path string, fb render.Image, format Format, tm ToneMapping) error {
+	if format == FormatAuto {
+		var err error
+		if format, err = FormatFromPath(path); err != nil {
+			return err
+		}
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return Write(w, fb, format, tm)
+	})
+}
+
+// writeFile create the file at path and fill it with write
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// WriteHDR write the linear radiance of a film or framebuffer as a Radiance
+// RGBE (.hdr) image with run-length encoded scanlines
+func WriteHDR(w io.Writer, fb render.Image) error {
+	width, height := fb.Size()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %v +X %v\n", height, width)
+	// the run-length encoding is only defined for these widths
+	rle := width >= 8 && width <= 0x7fff
+	scanline := make([]byte, 4*width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			rgbe := toRGBE(fb.At(x, y))
+			if rle {
+				// components are stored one after the other
+				for c := 0; c < 4; c++ {
+					scanline[c*width+x] = rgbe[c]
+				}
+			} else {
+				copy(scanline[4*x:], rgbe[:])
+			}
+		}
+		if !rle {
+			bw.Write(scanline)
+			continue
+		}
+		bw.Write([]byte{2, 2, byte(width >> 8), byte(width & 0xff)})
+		for c := 0; c < 4; c++ {
+			writeRLE(bw, scanline[c*width:(c+1)*width])
+		}
+	}
+	// the errors of bufio.Writer are sticky and reported by Flush
+	return bw.Flush()
+}
+
+// toRGBE encode a color with a shared exponent
+func toRGBE(col geom.Vec3) [4]byte {
+	r := math.Max(col.R(), 0)
+	g := math.Max(col.G(), 0)
+	b := math.Max(col.B(), 0)
+	v := math.Max(r, math.Max(g, b))
+	if v < 1e-32 || math.IsNaN(v) {
+		return [4]byte{0, 0, 0, 0}
+	}
+	mantissa, exponent := math.Frexp(v)
+	if exponent > 127 {
+		return [4]byte{255, 255, 255, 255}
+	}
+	scale := mantissa * 256 / v
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exponent + 128)}
+}
+
+// minRun is the shortest run worth encoding
+const minRun = 4
+
+// writeRLE write one component of a scanline with the Radiance run-length
+// encoding : a count above 128 is followed by a byte repeated count-128
+// times, otherwise by count literal bytes
+func writeRLE(w *bufio.Writer, data []byte) {
+	cur := 0
+	for cur < len(data) {
+		// look for the next run long enough
+		begRun := cur
+		runCount := 0
+		for runCount < minRun && begRun < len(data) {
+			begRun += runCount
+			runCount = 1
+			for begRun+runCount < len(data) && runCount < 127 && data[begRun] == data[begRun+runCount] {
+				runCount++
+			}
+		}
+		// a short run just before the long one
+		if begRun-cur > 1 && begRun-cur < minRun {
+			nextByte := cur + 1
+			for nextByte < begRun && data[nextByte] == data[cur] {
+				nextByte++
+			}
+			if nextByte == begRun {
+				w.Write([]byte{byte(128 + begRun - cur), data[cur]})
+				cur = begRun
+			}
+		}
+		// literal bytes before the run
+		for cur < begRun {
+			count := min(begRun-cur, 128)
+			w.WriteByte(byte(count))
+			w.Write(data[cur : cur+count])
+			cur += count
+		}
+		// the run
+		if runCount >= minRun {
+			w.Write([]byte{byte(128 + runCount), data[begRun]})
+			cur += runCount
+		}
+	}
+}
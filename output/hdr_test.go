@@ -0,0 +1,154 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+func TestWriteRLE(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want []byte
+	}{
+		// a single run
+		{[]byte{5, 5, 5, 5, 5, 5, 5, 5}, []byte{128 + 8, 5}},
+		// literals, a run, a literal
+		{[]byte{1, 2, 3, 3, 3, 3, 3, 4}, []byte{2, 1, 2, 128 + 5, 3, 1, 4}},
+		// a short run before a long one
+		{[]byte{7, 7, 9, 9, 9, 9}, []byte{128 + 2, 7, 128 + 4, 9}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		bw := bufio.NewWriter(&buf)
+		writeRLE(bw, test.data)
+		bw.Flush()
+		if !bytes.Equal(buf.Bytes(), test.want) {
+			t.Errorf("%v is encoded as %v instead of %v", test.data, buf.Bytes(), test.want)
+		}
+	}
+}
+
+// readRLE decode n bytes of a run-length encoded component
+func readRLE(r *bufio.Reader, n int) ([]byte, error) {
+	var data []byte
+	for len(data) < n {
+		count, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if count > 128 {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, bytes.Repeat([]byte{b}, int(count)-128)...)
+			continue
+		}
+		literal := make([]byte, count)
+		if _, err := io.ReadFull(r, literal); err != nil {
+			return nil, err
+		}
+		data = append(data, literal...)
+	}
+	return data, nil
+}
+
+func TestWriteHDR(t *testing.T) {
+	// a run of the same color then a ramp on each row
+	fb := render.NewFramebuffer(12, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 12; x++ {
+			col := geom.NewVec3(0.25, 1, 3)
+			if x >= 6 {
+				col = geom.NewVec3(float64(x), 0.01*float64(y+1), 1000)
+			}
+			fb.Set(x, y, col)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteHDR(&buf, fb); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(&buf)
+	header := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 2 +X 12\n"
+	got := make([]byte, len(header))
+	if _, err := io.ReadFull(r, got); err != nil || string(got) != header {
+		t.Fatalf("header %q instead of %q", got, header)
+	}
+	for y := 0; y < 2; y++ {
+		start := make([]byte, 4)
+		if _, err := io.ReadFull(r, start); err != nil || !bytes.Equal(start, []byte{2, 2, 0, 12}) {
+			t.Fatalf("scanline %v starts with %v", y, start)
+		}
+		var components [4][]byte
+		for c := 0; c < 4; c++ {
+			var err error
+			if components[c], err = readRLE(r, 12); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for x := 0; x < 12; x++ {
+			// the mantissas keep 8 bits of the largest component
+			e := math.Ldexp(1, int(components[3][x])-136)
+			col := fb.At(x, y)
+			for c := 0; c < 3; c++ {
+				v := (float64(components[c][x]) + 0.5) * e
+				if math.Abs(v-col.At(c)) > math.Max(col.R(), math.Max(col.G(), col.B()))/256 {
+					t.Errorf("pixel %v %v component %v is %v instead of %v", x, y, c, v, col.At(c))
+				}
+			}
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Error("bytes after the last scanline")
+	}
+}
+
+func TestPFMRoundTrip(t *testing.T) {
+	fb := render.NewFramebuffer(3, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			fb.Set(x, y, geom.NewVec3(float64(x)+0.5, -float64(y), 1e4*float64(x+y)+0.125))
+		}
+	}
+	var buf bytes.Buffer
+	if err := WritePFM(&buf, fb); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PF\n3 2\n-1.0\n")) || buf.Len() != len("PF\n3 2\n-1.0\n")+3*2*12 {
+		t.Fatalf("PFM file of %v bytes starting with %q", buf.Len(), buf.Bytes()[:12])
+	}
+	got, err := ReadPFM(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(fb.Pix); i++ {
+		if got.Pix[i] != fb.Pix[i] {
+			t.Errorf("pixel %v is %v instead of %v", i, got.Pix[i], fb.Pix[i])
+		}
+	}
+}
+
+func TestReadPFMGrayscale(t *testing.T) {
+	// a big endian grayscale file, whose first row is the bottom
+	var buf bytes.Buffer
+	buf.WriteString("Pf\n2 2\n1.0\n")
+	values := []float32{1, 2, 3, 4}
+	for i := 0; i < len(values); i++ {
+		binary.Write(&buf, binary.BigEndian, values[i])
+	}
+	fb, err := ReadPFM(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fb.At(0, 1) != geom.NewVec3(1, 1, 1) || fb.At(1, 0) != geom.NewVec3(4, 4, 4) {
+		t.Errorf("pixels %v", fb.Pix)
+	}
+}
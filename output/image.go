@@ -0,0 +1,39 @@
+package output
+
+import (
+	"image"
+	"image/color"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// ToImage convert the linear radiance of a film or framebuffer to an 8 bits
+// sRGB image through the tone mapping
+func ToImage(fb render.Image, tm ToneMapping) *image.RGBA {
+	width, height := fb.Size()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := tm.Apply(fb.At(x, y))
+			img.SetRGBA(x, y, color.RGBA{
+				R: quantize(col.R()),
+				G: quantize(col.G()),
+				B: quantize(col.B()),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// quantize clamp a display value, encode it in sRGB and round it to 8 bits
+func quantize(c float64) uint8 {
+	if !(c > 0) {
+		return 0
+	}
+	if c > 1 {
+		return 255
+	}
+	return uint8(255*geom.SRGB(c) + 0.5)
+}
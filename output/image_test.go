@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// testLDR return a 3x2 framebuffer and its 8 bits sRGB values with the Clamp
+// operator, row by row
+func testLDR() (*render.Framebuffer, [][3]uint8) {
+	fb := render.NewFramebuffer(3, 2)
+	fb.Set(0, 0, geom.NewVec3(0, 0.5, 1))
+	fb.Set(1, 0, geom.NewVec3(2, -1, 0.001))
+	fb.Set(2, 0, geom.NewVec3(0.2, 0.2, 0.2))
+	fb.Set(0, 1, geom.NewVec3(1, 1, 1))
+	fb.Set(1, 1, geom.NewVec3(0, 0, 0))
+	fb.Set(2, 1, geom.NewVec3(0.0031308, 0.04, 0.75))
+	want := [][3]uint8{
+		{0, 188, 255}, {255, 0, 3}, {124, 124, 124},
+		{255, 255, 255}, {0, 0, 0}, {10, 56, 225},
+	}
+	return fb, want
+}
+
+func TestWritePNG(t *testing.T) {
+	fb, want := testLDR()
+	var buf bytes.Buffer
+	if err := Write(&buf, fb, FormatPNG, ToneMapping{}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 3 || b.Dy() != 2 {
+		t.Fatalf("image of %vx%v", b.Dx(), b.Dy())
+	}
+	for i := 0; i < len(want); i++ {
+		r, g, b, a := img.At(i%3, i/3).RGBA()
+		got := [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+		if got != want[i] || a != 0xffff {
+			t.Errorf("pixel %v is %v, alpha %v, instead of %v", i, got, a, want[i])
+		}
+	}
+}
+
+func TestWritePPM(t *testing.T) {
+	fb, want := testLDR()
+	var binary, ascii bytes.Buffer
+	if err := Write(&binary, fb, FormatPPM, ToneMapping{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&ascii, fb, FormatPPMASCII, ToneMapping{}); err != nil {
+		t.Fatal(err)
+	}
+
+	header := "P6\n3 2\n255\n"
+	var values []byte
+	for i := 0; i < len(want); i++ {
+		values = append(values, want[i][:]...)
+	}
+	if binary.String() != header+string(values) {
+		t.Errorf("P6 file %q instead of %q", binary.Bytes(), header+string(values))
+	}
+
+	// the ASCII values are one pixel per line after the header
+	sc := bufio.NewScanner(&ascii)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 3+len(want) || lines[0] != "P3" || lines[1] != "3 2" || lines[2] != "255" {
+		t.Fatalf("P3 file %q", lines)
+	}
+	for i := 0; i < len(want); i++ {
+		if w := fmt.Sprintf("%v %v %v", want[i][0], want[i][1], want[i][2]); lines[3+i] != w {
+			t.Errorf("P3 pixel %v is %q instead of %q", i, lines[3+i], w)
+		}
+	}
+}
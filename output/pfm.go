@@ -0,0 +1,82 @@
+package output
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// WritePFM write the linear radiance of a film or framebuffer as a
+// little-endian color Portable FloatMap (.pfm), whose rows go from the bottom
+// to the top
+func WritePFM(w io.Writer, fb render.Image) error {
+	width, height := fb.Size()
+	bw := bufio.NewWriter(w)
+	// a negative scale means little-endian
+	fmt.Fprintf(bw, "PF\n%v %v\n-1.0\n", width, height)
+	row := make([]byte, 12*width)
+	for y := height - 1; y >= 0; y-- {
+		for x := 0; x < width; x++ {
+			col := fb.At(x, y)
+			binary.LittleEndian.PutUint32(row[12*x:], math.Float32bits(float32(col.R())))
+			binary.LittleEndian.PutUint32(row[12*x+4:], math.Float32bits(float32(col.G())))
+			binary.LittleEndian.PutUint32(row[12*x+8:], math.Float32bits(float32(col.B())))
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadPFM read a color (PF) or grayscale (Pf) Portable FloatMap, the
+// grayscale values being copied to the three components
+func ReadPFM(r io.Reader) (*render.Framebuffer, error) {
+	br := bufio.NewReader(r)
+	var magic string
+	var width, height int
+	var scale float64
+	if _, err := fmt.Fscan(br, &magic, &width, &height, &scale); err != nil {
+		return nil, fmt.Errorf("PFM header : %v", err)
+	}
+	components := 3
+	switch magic {
+	case "PF":
+	case "Pf":
+		components = 1
+	default:
+		return nil, fmt.Errorf("not a PFM file")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("PFM of size %vx%v", width, height)
+	}
+	// a single whitespace separates the header from the values
+	if _, err := br.ReadByte(); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		order = binary.LittleEndian
+	}
+	fb := render.NewFramebuffer(width, height)
+	row := make([]byte, 4*components*width)
+	var col [3]float64
+	for y := height - 1; y >= 0; y-- {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return nil, fmt.Errorf("PFM values : %v", err)
+		}
+		for x := 0; x < width; x++ {
+			for c := 0; c < 3; c++ {
+				k := x*components + min(c, components-1)
+				col[c] = float64(math.Float32frombits(order.Uint32(row[4*k:])))
+			}
+			fb.Set(x, y, geom.NewVec3(col[0], col[1], col[2]))
+		}
+	}
+	return fb, nil
+}
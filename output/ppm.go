@@ -0,0 +1,36 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+)
+
+// WritePPM write the image as a PPM, binary (P6) or ASCII (P3)
+func WritePPM(w io.Writer, img image.Image, ascii bool) error {
+	bounds := img.Bounds()
+	bw := bufio.NewWriter(w)
+	// Header of Picture
+	magic := "P6"
+	if ascii {
+		magic = "P3"
+	}
+	fmt.Fprintf(bw, "%v\n%v %v\n%v\n", magic, bounds.Dx(), bounds.Dy(), 255)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			ir, ig, ib := uint8(r>>8), uint8(g>>8), uint8(b>>8)
+			var err error
+			if ascii {
+				_, err = fmt.Fprintf(bw, "%v %v %v\n", ir, ig, ib)
+			} else {
+				_, err = bw.Write([]byte{ir, ig, ib})
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// ReadFile read the linear radiance of an image with the format of the
+// extension of path : the R, G, B channels of an OpenEXR file, a PFM, or a
+// PNG whose sRGB values are converted back to linear
+func ReadFile(path string) (*render.Framebuffer, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch format {
+	case FormatEXR:
+		img, err := ReadEXR(f)
+		if err != nil {
+			return nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return img.Layer("")
+	case FormatPFM:
+		fb, err := ReadPFM(f)
+		if err != nil {
+			return nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return fb, nil
+	case FormatPNG:
+		fb, err := ReadPNG(f)
+		if err != nil {
+			return nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return fb, nil
+	}
+	return nil, fmt.Errorf("can not read the image format %v", format)
+}
+
+// ReadFileWithAOVs read an image and the AOVs written with it by
+// WriteFileWithAOVs : the channels of an OpenEXR file, or the files named by
+// AOVPath next to a PFM. The missing AOVs are nil.
+func ReadFileWithAOVs(path string) (*render.Framebuffer, *render.AOVs, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if format == FormatEXR {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		img, err := ReadEXR(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v : %v", path, err)
+		}
+		fb, err := img.Layer("")
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return fb, img.AOVs(), nil
+	}
+	fb, err := ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	aovs := &render.AOVs{}
+	// the AOVs of the 8 bits formats are visualizations
+	if format != FormatPFM {
+		return fb, aovs, nil
+	}
+	for _, layer := range aovs.Layers() {
+		aovPath := AOVPath(path, layer.Name)
+		if _, err := os.Stat(aovPath); err != nil {
+			continue
+		}
+		aov, err := ReadFile(aovPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if aov.Width != fb.Width || aov.Height != fb.Height {
+			return nil, nil, fmt.Errorf("%v is %vx%v instead of %vx%v", aovPath, aov.Width, aov.Height, fb.Width, fb.Height)
+		}
+		aovs.SetLayer(layer.Name, aov)
+	}
+	return fb, aovs, nil
+}
+
+// ReadPNG read a PNG image, its sRGB values being converted back to linear
+func ReadPNG(r io.Reader) (*render.Framebuffer, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return fromImage(img), nil
+}
+
+// fromImage convert an 8 bits sRGB image to linear values
+func fromImage(img image.Image) *render.Framebuffer {
+	bounds := img.Bounds()
+	fb := render.NewFramebuffer(bounds.Dx(), bounds.Dy())
+	for y := 0; y < fb.Height; y++ {
+		for x := 0; x < fb.Width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			fb.Set(x, y, geom.NewVec3(geom.LinearSRGB(float64(r)/0xffff), geom.LinearSRGB(float64(g)/0xffff), geom.LinearSRGB(float64(b)/0xffff)))
+		}
+	}
+	return fb
+}
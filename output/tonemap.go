@@ -0,0 +1,162 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// ToneMapper compresses a linear radiance into the displayable range, the
+// components of the result being clamped between 0 and 1 afterwards
+type ToneMapper interface {
+	Map(c geom.Vec3) geom.Vec3
+}
+
+// ToneMapping is the transform from the linear radiance of a framebuffer to
+// the 8 bits values of the low dynamic range formats : the radiance is scaled
+// by the exposure, compressed by the operator and encoded with the sRGB
+// transfer function
+type ToneMapping struct {
+	// Operator is the tone mapping operator, nil means Clamp
+	Operator ToneMapper
+	// Exposure is the number of stops the radiance is scaled by before the
+	// operator, 0 keeping it as it is
+	Exposure float64
+}
+
+// Apply return the display color of a linear radiance, before the sRGB
+// encoding
+func (tm ToneMapping) Apply(c geom.Vec3) geom.Vec3 {
+	c = c.TimesScalar(math.Exp2(tm.Exposure))
+	if tm.Operator != nil {
+		c = tm.Operator.Map(c)
+	}
+	return c
+}
+
+// Clamp keeps the radiance as it is, the values above 1 are clipped
+type Clamp struct{}
+
+// Map implements ToneMapper
+func (Clamp) Map(c geom.Vec3) geom.Vec3 {
+	return c
+}
+
+// Reinhard compresses the luminance L to L / (1 + L), keeping the hue
+type Reinhard struct{}
+
+// Map implements ToneMapper
+func (Reinhard) Map(c geom.Vec3) geom.Vec3 {
+	return scaleLuminance(c, func(l float64) float64 {
+		return l / (1 + l)
+	})
+}
+
+// DefaultReinhardWhite is the white point of ReinhardExtended when it is not
+// set
+const DefaultReinhardWhite = 4.0
+
+// ReinhardExtended compresses the luminance like Reinhard but maps White to
+// 1, so that the highlights are burnt only above it
+type ReinhardExtended struct {
+	// White is the smallest luminance displayed as white, 0 means
+	// DefaultReinhardWhite
+	White float64
+}
+
+// Map implements ToneMapper
+func (tm ReinhardExtended) Map(c geom.Vec3) geom.Vec3 {
+	white := tm.White
+	if white <= 0 {
+		white = DefaultReinhardWhite
+	}
+	return scaleLuminance(c, func(l float64) float64 {
+		return l * (1 + l/(white*white)) / (1 + l)
+	})
+}
+
+// scaleLuminance scale the color so that its luminance becomes f(luminance)
+func scaleLuminance(c geom.Vec3, f func(l float64) float64) geom.Vec3 {
+	l := render.Luminance(c)
+	if !(l > 0) {
+		return geom.NewVec3(0, 0, 0)
+	}
+	return c.TimesScalar(f(l) / l)
+}
+
+// ACES is Krzysztof Narkowicz's fit of the ACES filmic curve, applied to
+// each component
+type ACES struct{}
+
+// Map implements ToneMapper
+func (ACES) Map(c geom.Vec3) geom.Vec3 {
+	return mapComponents(c, func(x float64) float64 {
+		// the fit expects a radiance already exposed by 0.6
+		x *= 0.6
+		return (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+	})
+}
+
+// DefaultHableWhite is the white point of Hable when it is not set
+const DefaultHableWhite = 11.2
+
+// Hable is John Hable's filmic curve made for Uncharted 2, applied to each
+// component
+type Hable struct {
+	// White is the value displayed as white, compared to the radiance
+	// doubled by the exposure bias of the original curve. 0 means
+	// DefaultHableWhite.
+	White float64
+}
+
+// hableCurve is the filmic curve with the parameters of Uncharted 2
+func hableCurve(x float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return (x*(a*x+c*b)+d*e)/(x*(a*x+b)+d*f) - e/f
+}
+
+// Map implements ToneMapper
+func (tm Hable) Map(c geom.Vec3) geom.Vec3 {
+	white := tm.White
+	if white <= 0 {
+		white = DefaultHableWhite
+	}
+	scale := 1 / hableCurve(white)
+	return mapComponents(c, func(x float64) float64 {
+		// exposure bias of the original curve
+		return hableCurve(2*x) * scale
+	})
+}
+
+// mapComponents apply f to the positive components of the color
+func mapComponents(c geom.Vec3, f func(x float64) float64) geom.Vec3 {
+	var res geom.Vec3
+	for i := 0; i < 3; i++ {
+		if x := c.At(i); x > 0 {
+			res.SetAt(i, f(x))
+		}
+	}
+	return res
+}
+
+// toneMappers are the operators accepted by ParseToneMapper
+var toneMappers = map[string]ToneMapper{
+	"clamp":             Clamp{},
+	"reinhard":          Reinhard{},
+	"reinhard-extended": ReinhardExtended{},
+	"aces":              ACES{},
+	"hable":             Hable{},
+	"uncharted":         Hable{},
+}
+
+// ParseToneMapper return the operator of the given name (clamp, reinhard,
+// reinhard-extended, aces, hable or uncharted) with its default parameters
+func ParseToneMapper(name string) (ToneMapper, error) {
+	if tm, ok := toneMappers[strings.ToLower(name)]; ok {
+		return tm, nil
+	}
+	return nil, fmt.Errorf("unknown tone mapping operator %q", name)
+}
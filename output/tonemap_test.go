@@ -0,0 +1,74 @@
+package output
+
+import (
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+func TestToneMappers(t *testing.T) {
+	grey := func(v float64) geom.Vec3 {
+		return geom.NewVec3(v, v, v)
+	}
+	tests := []struct {
+		name string
+		tm   ToneMapper
+		in   geom.Vec3
+		want geom.Vec3
+	}{
+		{"clamp", Clamp{}, geom.NewVec3(2, 0.5, -1), geom.NewVec3(2, 0.5, -1)},
+		{"reinhard 1", Reinhard{}, grey(1), grey(0.5)},
+		{"reinhard 3", Reinhard{}, grey(3), grey(0.75)},
+		// the hue is kept, only the luminance is compressed
+		{"reinhard hue", Reinhard{}, geom.NewVec3(2, 0, 0), geom.NewVec3(2*(1/(1+0.4252)), 0, 0)},
+		{"reinhard black", Reinhard{}, grey(0), grey(0)},
+		{"reinhard extended white", ReinhardExtended{}, grey(DefaultReinhardWhite), grey(1)},
+		{"reinhard extended 1", ReinhardExtended{White: 4}, grey(1), grey(0.53125)},
+		{"reinhard extended 2", ReinhardExtended{White: 2}, grey(2), grey(1)},
+		{"aces 1", ACES{}, grey(1), grey(0.6732904734073641)},
+		{"aces 10", ACES{}, grey(10), grey(0.9931987713909607)},
+		{"aces negative", ACES{}, geom.NewVec3(-1, 0, 1), geom.NewVec3(0, 0, 0.6732904734073641)},
+		{"hable white", Hable{}, grey(DefaultHableWhite / 2), grey(1)},
+		{"hable 1", Hable{}, grey(1), grey(0.49291854599116447)},
+		{"hable black", Hable{White: 4}, grey(0), grey(0)},
+	}
+	for _, test := range tests {
+		got := test.tm.Map(test.in)
+		if got.Minus(test.want).Length() > 1e-9 {
+			t.Errorf("%v : %v is mapped to %v instead of %v", test.name, test.in, got, test.want)
+		}
+	}
+}
+
+func TestToneMappingExposure(t *testing.T) {
+	tm := ToneMapping{Exposure: -2, Operator: Reinhard{}}
+	if got := tm.Apply(geom.NewVec3(4, 4, 4)); got.Minus(geom.NewVec3(0.5, 0.5, 0.5)).Length() > 1e-9 {
+		t.Errorf("4 exposed by -2 stops is mapped to %v instead of 0.5", got)
+	}
+	// without operator the radiance is only scaled
+	if got := (ToneMapping{Exposure: 1}).Apply(geom.NewVec3(0.25, 1, 3)); got != geom.NewVec3(0.5, 2, 6) {
+		t.Errorf("exposure of 1 stop gives %v", got)
+	}
+}
+
+func TestParseToneMapper(t *testing.T) {
+	names := map[string]ToneMapper{
+		"clamp":             Clamp{},
+		"Reinhard":          Reinhard{},
+		"reinhard-extended": ReinhardExtended{},
+		"ACES":              ACES{},
+		"hable":             Hable{},
+		"uncharted":         Hable{},
+	}
+	for name, want := range names {
+		tm, err := ParseToneMapper(name)
+		if err != nil || tm != want {
+			t.Errorf("%v is parsed as %#v, %v", name, tm, err)
+		}
+	}
+	for _, name := range []string{"", "filmic", "reinhard extended"} {
+		if tm, err := ParseToneMapper(name); err == nil {
+			t.Errorf("%q is parsed as %#v", name, tm)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// Accumulator holds the Film of a render, counts the samples of every pixel
+// and keeps the running mean and variance of their luminance (Welford's
+// algorithm) to estimate the noise left in the image.
+// The first row is the top of the image.
+type Accumulator struct {
+	Width  int
+	Height int
+	Film   *Film
+	Count  []int
+	// running mean and sum of squared differences of the luminance
+	lumMean []float64
+	lumM2   []float64
+	// sums of the AOVs, nil if they are not recorded
+	aov *aovAccumulator
+}
+
+// NewAccumulator instantiate an empty accumulator of the given size, whose
+// film reconstructs the image with filter (nil for a box of radius 0.5)
+func NewAccumulator(width, height int, filter Filter) *Accumulator {
+	n := width * height
+	return &Accumulator{
+		Width:   width,
+		Height:  height,
+		Film:    NewFilm(width, height, filter),
+		Count:   make([]int, n),
+		lumMean: make([]float64, n),
+		lumM2:   make([]float64, n),
+	}
+}
+
+// Luminance return the Rec. 709 luminance of a linear color
+func Luminance(c geom.Vec3) float64 {
+	return 0.2126*c.R() + 0.7152*c.G() + 0.0722*c.B()
+}
+
+// Add count a sample of the pixel (x, y) in its statistics, the sample
+// itself being splatted on the film by a FilmTile
+func (acc *Accumulator) Add(x, y int, col geom.Vec3) {
+	i := y*acc.Width + x
+	acc.Count[i]++
+	lum := Luminance(col)
+	delta := lum - acc.lumMean[i]
+	acc.lumMean[i] += delta / float64(acc.Count[i])
+	acc.lumM2[i] += delta * (lum - acc.lumMean[i])
+}
+
+// Samples return the number of samples of the pixel (x, y)
+func (acc *Accumulator) Samples(x, y int) int {
+	return acc.Count[y*acc.Width+x]
+}
+
+// StdError return the standard error of the mean luminance of the pixel
+// (x, y), +Inf with less than 2 samples
+func (acc *Accumulator) StdError(x, y int) float64 {
+	i := y*acc.Width + x
+	n := float64(acc.Count[i])
+	if n < 2 {
+		return math.Inf(1)
+	}
+	return math.Sqrt(acc.lumM2[i] / (n - 1) / n)
+}
+
+// noiseFloor is the luminance under which pixels are compared to it rather
+// than to their own mean, so that black pixels do not dominate the noise
+const noiseFloor = 0.01
+
+// Noise return an estimation of the noise left in the image : the average
+// over the pixels of the standard error of their mean luminance relative to
+// this mean
+func (acc *Accumulator) Noise() float64 {
+	sum := 0.0
+	for y := 0; y < acc.Height; y++ {
+		for x := 0; x < acc.Width; x++ {
+			i := y*acc.Width + x
+			sum += acc.StdError(x, y) / math.Max(acc.lumMean[i], noiseFloor)
+		}
+	}
+	return sum / float64(acc.Width*acc.Height)
+}
+
+// Heatmap return an image of the number of samples of each pixel, from
+// blue for no sample to red for maxSamples and more
+func (acc *Accumulator) Heatmap(maxSamples int) *Framebuffer {
+	fb := NewFramebuffer(acc.Width, acc.Height)
+	for y := 0; y < acc.Height; y++ {
+		for x := 0; x < acc.Width; x++ {
+			t := float64(acc.Samples(x, y)) / float64(max(maxSamples, 1))
+			fb.Set(x, y, heatColor(t))
+		}
+	}
+	return fb
+}
+
+// heatRamp are the colors of the heatmap, evenly spaced from 0 to 1
+var heatRamp = [...]geom.Vec3{
+	geom.NewVec3(0, 0, 1),
+	geom.NewVec3(0, 1, 1),
+	geom.NewVec3(0, 1, 0),
+	geom.NewVec3(1, 1, 0),
+	geom.NewVec3(1, 0, 0),
+}
+
+// heatColor interpolate the heatmap colors at t between 0 and 1
+func heatColor(t float64) geom.Vec3 {
+	t = math.Min(math.Max(t, 0), 1) * float64(len(heatRamp)-1)
+	i := min(int(t), len(heatRamp)-2)
+	f := t - float64(i)
+	return heatRamp[i].TimesScalar(1 - f).Plus(heatRamp[i+1].TimesScalar(f))
+}
+
+// Framebuffer return the image reconstructed by the film
+func (acc *Accumulator) Framebuffer() *Framebuffer {
+	return acc.Film.Framebuffer()
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"math"
+)
+
+// default values of AdaptiveOptions
+const (
+	DefaultAdaptiveMinSamples  = 16
+	DefaultAdaptivePassSamples = 8
+	DefaultAdaptiveThreshold   = 0.05
+)
+
+// confidenceZ is the z-score of the 95% confidence interval
+const confidenceZ = 1.96
+
+// AdaptiveOptions are the parameters of RenderAdaptive
+type AdaptiveOptions struct {
+	// MinSamples is the number of samples every pixel receives before its
+	// variance is trusted, 0 means DefaultAdaptiveMinSamples
+	MinSamples int
+	// MaxSamples is the maximum number of samples of a pixel, 0 means
+	// Settings.Samples
+	MaxSamples int
+	// PassSamples is the number of samples added to the unconverged pixels
+	// at each pass, 0 means DefaultAdaptivePassSamples
+	PassSamples int
+	// Threshold is the half-width of the 95% confidence interval of the
+	// pixel luminance, relative to its mean, under which a pixel is
+	// converged. 0 means DefaultAdaptiveThreshold.
+	Threshold float64
+}
+
+// RenderAdaptive compute the image of the scene, spending the samples where
+// the image is noisy : after MinSamples samples everywhere, only the pixels
+// whose luminance confidence interval is wider than Threshold keep being
+// sampled, PassSamples at a time, until they converge or reach MaxSamples.
+// It returns the image and the heatmap of the number of samples per pixel.
+func (rd *Renderer) RenderAdaptive(opts AdaptiveOptions) (*Framebuffer, *Framebuffer) {
+	settings := rd.Scene.Settings
+	minSamples := opts.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultAdaptiveMinSamples
+	}
+	maxSamples := opts.MaxSamples
+	if maxSamples <= 0 {
+		maxSamples = settings.Samples
+	}
+	minSamples = min(minSamples, maxSamples)
+	passSamples := opts.PassSamples
+	if passSamples <= 0 {
+		passSamples = DefaultAdaptivePassSamples
+	}
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = DefaultAdaptiveThreshold
+	}
+
+	acc := rd.newAccumulator()
+	rd.renderPass(acc, minSamples, nil, nil)
+	budget := make([]int, settings.Width*settings.Height)
+	for {
+		remaining := false
+		for y := 0; y < acc.Height; y++ {
+			for x := 0; x < acc.Width; x++ {
+				i := y*acc.Width + x
+				budget[i] = 0
+				if acc.Count[i] < maxSamples && !acc.converged(x, y, threshold) {
+					budget[i] = min(passSamples, maxSamples-acc.Count[i])
+					remaining = true
+				}
+			}
+		}
+		if !remaining {
+			break
+		}
+		rd.renderPass(acc, 0, budget, nil)
+	}
+	return acc.Framebuffer(), acc.Heatmap(maxSamples)
+}
+
+// converged tell if the confidence interval of the luminance of the pixel
+// (x, y) is narrower than threshold relative to its mean
+func (acc *Accumulator) converged(x, y int, threshold float64) bool {
+	mean := acc.lumMean[y*acc.Width+x]
+	return confidenceZ*acc.StdError(x, y) <= threshold*math.Max(mean, noiseFloor)
+}
@@ -0,0 +1,95 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// heatSamples return the number of samples of a pixel of a heatmap of
+// maxSamples, reading its color back along the ramp blue, cyan, green,
+// yellow, red
+func heatSamples(c geom.Vec3, maxSamples int) int {
+	var t float64
+	switch {
+	case c.R() == 0 && c.B() == 1:
+		t = c.G()
+	case c.R() == 0:
+		t = 2 - c.B()
+	case c.G() == 1:
+		t = 2 + c.R()
+	default:
+		t = 4 - c.G()
+	}
+	return int(math.Round(t / 4 * float64(maxSamples)))
+}
+
+func TestHeatmap(t *testing.T) {
+	acc := render.NewAccumulator(5, 1, nil)
+	counts := []int{0, 2, 4, 6, 12}
+	for x := 0; x < len(counts); x++ {
+		for i := 0; i < counts[x]; i++ {
+			acc.Add(x, 0, geom.NewVec3(1, 1, 1))
+		}
+	}
+	heatmap := acc.Heatmap(8)
+	// the pixels over the maximum are red too
+	want := []geom.Vec3{
+		geom.NewVec3(0, 0, 1),
+		geom.NewVec3(0, 1, 1),
+		geom.NewVec3(0, 1, 0),
+		geom.NewVec3(1, 1, 0),
+		geom.NewVec3(1, 0, 0),
+	}
+	for x := 0; x < len(want); x++ {
+		if c := heatmap.At(x, 0); c != want[x] {
+			t.Errorf("pixel of %v samples is %v instead of %v", counts[x], c, want[x])
+		}
+	}
+}
+
+func TestAdaptiveConstant(t *testing.T) {
+	settings := &render.Settings{Width: 8, Height: 6, Samples: 64}
+	renderer := render.NewRenderer(constantScene(settings))
+	fb, heatmap := renderer.RenderAdaptive(render.AdaptiveOptions{MinSamples: 4})
+	// no pixel has variance, they all stop after the minimum samples
+	film := renderer.Film()
+	for y := 0; y < settings.Height; y++ {
+		for x := 0; x < settings.Width; x++ {
+			if n := heatSamples(heatmap.At(x, y), settings.Samples); n != 4 || film.Weight[y*settings.Width+x] != 4 {
+				t.Fatalf("pixel %v %v has %v samples and a weight of %v instead of 4", x, y, n, film.Weight[y*settings.Width+x])
+			}
+			if fb.At(x, y) != geom.NewVec3(0.5, 0.5, 0.5) {
+				t.Fatalf("pixel %v %v is %v", x, y, fb.At(x, y))
+			}
+		}
+	}
+}
+
+func TestAdaptiveCornell(t *testing.T) {
+	const minSamples, maxSamples = 4, 32
+	renderer := cornellRenderer(maxSamples)
+	_, heatmap := renderer.RenderAdaptive(render.AdaptiveOptions{MinSamples: minSamples, Threshold: 0.1})
+	// the box filter weights each sample 1, the film holds the samples
+	width, height := heatmap.Size()
+	film := renderer.Film()
+	total, weight := 0, 0.0
+	lowest, highest := maxSamples, 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			n := heatSamples(heatmap.At(x, y), maxSamples)
+			total += n
+			weight += film.Weight[y*width+x]
+			lowest = min(lowest, n)
+			highest = max(highest, n)
+		}
+	}
+	if float64(total) != weight {
+		t.Errorf("the heatmap sums to %v samples, the film to %v", total, weight)
+	}
+	if lowest < minSamples || highest > maxSamples || lowest == highest {
+		t.Errorf("the pixels have from %v to %v samples, instead of varying between %v and %v", lowest, highest, minSamples, maxSamples)
+	}
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// names of the AOVs
+const (
+	AOVNormal     = "normal"
+	AOVPosition   = "position"
+	AOVDepth      = "depth"
+	AOVAlbedo     = "albedo"
+	AOVUV         = "uv"
+	AOVObjectID   = "objectID"
+	AOVMaterialID = "materialID"
+)
+
+// AOVSample is what a camera ray hits first
+type AOVSample struct {
+	Hit bool
+	// Normal is the shading normal
+	Normal   geom.Vec3
+	Position geom.Vec3
+	// Depth is the parameter T of the hit along the camera ray
+	Depth float64
+	// Albedo is the attenuation of the material, or for a light its emitted
+	// color clamped to 1
+	Albedo     geom.Vec3
+	U          float64
+	V          float64
+	ObjectID   int
+	MaterialID int
+}
+
+// record set the sample from the first hit of the camera ray, the albedo
+// being the one of a light until the material scatters
+func (aov *AOVSample) record(hrec *geom.HitRecord, emitted geom.Vec3) {
+	aov.Hit = true
+	aov.Normal = hrec.Normal
+	aov.Position = hrec.P
+	aov.Depth = hrec.T
+	aov.Albedo = geom.NewVec3(math.Min(emitted.R(), 1), math.Min(emitted.G(), 1), math.Min(emitted.B(), 1))
+	aov.U = hrec.U
+	aov.V = hrec.V
+	aov.ObjectID = hrec.ObjectID
+	aov.MaterialID = hrec.MaterialID
+}
+
+// AOVs are the arbitrary output variables of a render : properties of the
+// first surface seen through each pixel, averaged over its samples, the
+// pixels of the background being 0.
+// Depth is in the three components, UV in the first two. The IDs, which can
+// not be averaged, are the ones of the first sample.
+type AOVs struct {
+	Normal     *Framebuffer
+	Position   *Framebuffer
+	Depth      *Framebuffer
+	Albedo     *Framebuffer
+	UV         *Framebuffer
+	ObjectID   *Framebuffer
+	MaterialID *Framebuffer
+}
+
+// AOVLayer is an AOV with its name
+type AOVLayer struct {
+	Name   string
+	Buffer *Framebuffer
+}
+
+// Layers return the AOVs with their names
+func (aovs *AOVs) Layers() []AOVLayer {
+	return []AOVLayer{
+		{AOVNormal, aovs.Normal},
+		{AOVPosition, aovs.Position},
+		{AOVDepth, aovs.Depth},
+		{AOVAlbedo, aovs.Albedo},
+		{AOVUV, aovs.UV},
+		{AOVObjectID, aovs.ObjectID},
+		{AOVMaterialID, aovs.MaterialID},
+	}
+}
+
+// SetLayer set the AOV of the given name, returning false for an unknown name
+func (aovs *AOVs) SetLayer(name string, fb *Framebuffer) bool {
+	switch name {
+	case AOVNormal:
+		aovs.Normal = fb
+	case AOVPosition:
+		aovs.Position = fb
+	case AOVDepth:
+		aovs.Depth = fb
+	case AOVAlbedo:
+		aovs.Albedo = fb
+	case AOVUV:
+		aovs.UV = fb
+	case AOVObjectID:
+		aovs.ObjectID = fb
+	case AOVMaterialID:
+		aovs.MaterialID = fb
+	default:
+		return false
+	}
+	return true
+}
+
+// aovAccumulator sums the AOV samples of every pixel, the number of samples
+// being the one of the Accumulator
+type aovAccumulator struct {
+	normal     []geom.Vec3
+	position   []geom.Vec3
+	depth      []float64
+	albedo     []geom.Vec3
+	uv         []geom.Vec3
+	objectID   []int
+	materialID []int
+}
+
+func newAOVAccumulator(n int) *aovAccumulator {
+	return &aovAccumulator{
+		normal:     make([]geom.Vec3, n),
+		position:   make([]geom.Vec3, n),
+		depth:      make([]float64, n),
+		albedo:     make([]geom.Vec3, n),
+		uv:         make([]geom.Vec3, n),
+		objectID:   make([]int, n),
+		materialID: make([]int, n),
+	}
+}
+
+// EnableAOVs make the accumulator record the AOVs of its samples
+func (acc *Accumulator) EnableAOVs() {
+	if acc.aov == nil {
+		acc.aov = newAOVAccumulator(acc.Width * acc.Height)
+	}
+}
+
+// AddAOV add the AOVs of the sample s to the pixel (x, y)
+func (acc *Accumulator) AddAOV(x, y, s int, sample *AOVSample) {
+	if !sample.Hit {
+		return
+	}
+	i := y*acc.Width + x
+	aov := acc.aov
+	aov.normal[i] = aov.normal[i].Plus(sample.Normal)
+	aov.position[i] = aov.position[i].Plus(sample.Position)
+	aov.depth[i] += sample.Depth
+	aov.albedo[i] = aov.albedo[i].Plus(sample.Albedo)
+	aov.uv[i] = aov.uv[i].Plus(geom.NewVec3(sample.U, sample.V, 0))
+	if s == 0 {
+		aov.objectID[i] = sample.ObjectID
+		aov.materialID[i] = sample.MaterialID
+	}
+}
+
+// AOVs return the average AOVs of the samples, nil if they are not recorded
+func (acc *Accumulator) AOVs() *AOVs {
+	if acc.aov == nil {
+		return nil
+	}
+	aovs := &AOVs{
+		Normal:     NewFramebuffer(acc.Width, acc.Height),
+		Position:   NewFramebuffer(acc.Width, acc.Height),
+		Depth:      NewFramebuffer(acc.Width, acc.Height),
+		Albedo:     NewFramebuffer(acc.Width, acc.Height),
+		UV:         NewFramebuffer(acc.Width, acc.Height),
+		ObjectID:   NewFramebuffer(acc.Width, acc.Height),
+		MaterialID: NewFramebuffer(acc.Width, acc.Height),
+	}
+	aov := acc.aov
+	for i := 0; i < acc.Width*acc.Height; i++ {
+		objectID := float64(aov.objectID[i])
+		materialID := float64(aov.materialID[i])
+		aovs.ObjectID.Pix[i] = geom.NewVec3(objectID, objectID, objectID)
+		aovs.MaterialID.Pix[i] = geom.NewVec3(materialID, materialID, materialID)
+		if acc.Count[i] == 0 {
+			continue
+		}
+		n := float64(acc.Count[i])
+		if aov.normal[i].SquaredLength() > 0 {
+			aovs.Normal.Pix[i] = aov.normal[i].UnitVector()
+		}
+		aovs.Position.Pix[i] = aov.position[i].ByScalar(n)
+		depth := aov.depth[i] / n
+		aovs.Depth.Pix[i] = geom.NewVec3(depth, depth, depth)
+		aovs.Albedo.Pix[i] = aov.albedo[i].ByScalar(n)
+		aovs.UV.Pix[i] = aov.uv[i].ByScalar(n)
+	}
+	return aovs
+}
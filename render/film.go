@@ -0,0 +1,177 @@
+package render
+
+import (
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// Image is a grid of linear colors that can be written by the output
+// package, y = 0 being the top row
+type Image interface {
+	Size() (width, height int)
+	At(x, y int) geom.Vec3
+}
+
+// Film reconstructs the image from the samples : each sample is splatted on
+// the pixels within the radius of the filter, weighted by the filter, and a
+// pixel is the weighted average of its samples.
+// The first row is the top of the image.
+type Film struct {
+	Width  int
+	Height int
+	Filter Filter
+	Sum    []geom.Vec3
+	Weight []float64
+}
+
+// NewFilm instantiate an empty film, a nil filter meaning a box filter of
+// radius 0.5
+func NewFilm(width, height int, filter Filter) *Film {
+	if filter == nil {
+		filter = NewBoxFilter(DefaultBoxRadius)
+	}
+	n := width * height
+	return &Film{
+		Width:  width,
+		Height: height,
+		Filter: filter,
+		Sum:    make([]geom.Vec3, n),
+		Weight: make([]float64, n),
+	}
+}
+
+// Size implements Image
+func (f *Film) Size() (int, int) {
+	return f.Width, f.Height
+}
+
+// At return the weighted average of the samples of the pixel (x, y), black
+// if its weights do not sum to a positive value
+func (f *Film) At(x, y int) geom.Vec3 {
+	i := y*f.Width + x
+	if !(f.Weight[i] > 0) {
+		return geom.NewVec3(0, 0, 0)
+	}
+	return f.Sum[i].ByScalar(f.Weight[i])
+}
+
+// Framebuffer return the pixels of the film
+func (f *Film) Framebuffer() *Framebuffer {
+	fb := NewFramebuffer(f.Width, f.Height)
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			fb.Set(x, y, f.At(x, y))
+		}
+	}
+	return fb
+}
+
+// margin return the number of pixels around a pixel reached by its samples
+func (f *Film) margin() int {
+	return max(int(math.Ceil(f.Filter.Radius()-0.5)), 0)
+}
+
+// FilmTile collects the samples of a Tile and of the pixels around it that
+// they reach, so that the tiles can be rendered concurrently and merged in
+// the film afterwards
+type FilmTile struct {
+	// pixels covered, X0 and Y0 included, X1 and Y1 excluded
+	X0     int
+	Y0     int
+	X1     int
+	Y1     int
+	filter Filter
+	sum    []geom.Vec3
+	weight []float64
+	stride int
+	// direct is true when the samples stay in their pixel, the tile then
+	// writes in the film itself
+	direct bool
+}
+
+// NewTile instantiate the tile of the film collecting the samples of the
+// pixels of t
+func (f *Film) NewTile(t Tile) *FilmTile {
+	m := f.margin()
+	if m == 0 {
+		return &FilmTile{
+			X0:     t.X0,
+			Y0:     t.Y0,
+			X1:     t.X1,
+			Y1:     t.Y1,
+			filter: f.Filter,
+			sum:    f.Sum,
+			weight: f.Weight,
+			stride: f.Width,
+			direct: true,
+		}
+	}
+	ft := &FilmTile{
+		X0:     max(t.X0-m, 0),
+		Y0:     max(t.Y0-m, 0),
+		X1:     min(t.X1+m, f.Width),
+		Y1:     min(t.Y1+m, f.Height),
+		filter: f.Filter,
+	}
+	ft.stride = ft.X1 - ft.X0
+	n := ft.stride * (ft.Y1 - ft.Y0)
+	ft.sum = make([]geom.Vec3, n)
+	ft.weight = make([]float64, n)
+	return ft
+}
+
+// index return the index of the pixel (x, y) of the film in the tile buffers
+func (ft *FilmTile) index(x, y int) int {
+	if ft.direct {
+		return y*ft.stride + x
+	}
+	return (y-ft.Y0)*ft.stride + x - ft.X0
+}
+
+// AddSample splat a sample taken in the pixel (x, y) at the offset (dx, dy)
+// from its top left corner, dx and dy being between 0 and 1
+func (ft *FilmTile) AddSample(x, y int, dx, dy float64, col geom.Vec3) {
+	if ft.direct {
+		// the sample only reaches its own pixel
+		w := ft.filter.Evaluate(dx-0.5, dy-0.5)
+		i := ft.index(x, y)
+		ft.sum[i] = ft.sum[i].Plus(col.TimesScalar(w))
+		ft.weight[i] += w
+		return
+	}
+	radius := ft.filter.Radius()
+	px := float64(x) + dx
+	py := float64(y) + dy
+	x0 := max(int(math.Ceil(px-0.5-radius)), ft.X0)
+	x1 := min(int(math.Floor(px-0.5+radius)), ft.X1-1)
+	y0 := max(int(math.Ceil(py-0.5-radius)), ft.Y0)
+	y1 := min(int(math.Floor(py-0.5+radius)), ft.Y1-1)
+	for j := y0; j <= y1; j++ {
+		for i := x0; i <= x1; i++ {
+			w := ft.filter.Evaluate(float64(i)+0.5-px, float64(j)+0.5-py)
+			if w == 0 {
+				continue
+			}
+			k := ft.index(i, j)
+			ft.sum[k] = ft.sum[k].Plus(col.TimesScalar(w))
+			ft.weight[k] += w
+		}
+	}
+}
+
+// MergeTile add the samples of the tile to the film. The tiles must be merged
+// in the same order for the image to be reproducible.
+func (f *Film) MergeTile(ft *FilmTile) {
+	if ft.direct {
+		return
+	}
+	for y := ft.Y0; y < ft.Y1; y++ {
+		for x := ft.X0; x < ft.X1; x++ {
+			i := y*f.Width + x
+			k := ft.index(x, y)
+			f.Sum[i] = f.Sum[i].Plus(ft.sum[k])
+			f.Weight[i] += ft.weight[k]
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Filter is a pixel reconstruction filter : the weight of a sample in a pixel
+// whose center is at (x, y) from it. The filters are separable and zero
+// beyond Radius in x or y.
+type Filter interface {
+	Radius() float64
+	Evaluate(x, y float64) float64
+}
+
+// BoxFilter gives the same weight to all the samples within its radius, with
+// a radius of 0.5 each pixel is the plain average of its own samples
+type BoxFilter struct {
+	radius float64
+}
+
+// NewBoxFilter instantiate a new BoxFilter
+func NewBoxFilter(radius float64) *BoxFilter {
+	return &BoxFilter{radius: radius}
+}
+
+func (f BoxFilter) Radius() float64 {
+	return f.radius
+}
+
+func (f BoxFilter) Evaluate(x, y float64) float64 {
+	if math.Abs(x) > f.radius || math.Abs(y) > f.radius {
+		return 0
+	}
+	return 1
+}
+
+// TentFilter decreases linearly from the center to its radius
+type TentFilter struct {
+	radius float64
+}
+
+// NewTentFilter instantiate a new TentFilter
+func NewTentFilter(radius float64) *TentFilter {
+	return &TentFilter{radius: radius}
+}
+
+func (f TentFilter) Radius() float64 {
+	return f.radius
+}
+
+func (f TentFilter) Evaluate(x, y float64) float64 {
+	return math.Max(0, f.radius-math.Abs(x)) * math.Max(0, f.radius-math.Abs(y))
+}
+
+// GaussianFilter is a Gaussian exp(-alpha x^2) shifted down to reach 0 at its
+// radius
+type GaussianFilter struct {
+	radius float64
+	alpha  float64
+	// value of the Gaussian at the radius
+	edge float64
+}
+
+// NewGaussianFilter instantiate a new GaussianFilter, a larger alpha making
+// it sharper
+func NewGaussianFilter(radius, alpha float64) *GaussianFilter {
+	return &GaussianFilter{
+		radius: radius,
+		alpha:  alpha,
+		edge:   math.Exp(-alpha * radius * radius),
+	}
+}
+
+func (f GaussianFilter) Radius() float64 {
+	return f.radius
+}
+
+func (f GaussianFilter) gaussian(x float64) float64 {
+	return math.Max(0, math.Exp(-f.alpha*x*x)-f.edge)
+}
+
+func (f GaussianFilter) Evaluate(x, y float64) float64 {
+	return f.gaussian(x) * f.gaussian(y)
+}
+
+// MitchellFilter is the Mitchell-Netravali cubic filter stretched to its
+// radius, b and c being the parameters of the family (1/3, 1/3 is the
+// recommended compromise between blurring and ringing)
+type MitchellFilter struct {
+	radius float64
+	b      float64
+	c      float64
+}
+
+// NewMitchellFilter instantiate a new MitchellFilter
+func NewMitchellFilter(radius, b, c float64) *MitchellFilter {
+	return &MitchellFilter{radius: radius, b: b, c: c}
+}
+
+func (f MitchellFilter) Radius() float64 {
+	return f.radius
+}
+
+// mitchell evaluate the cubic, defined between -2 and 2
+func (f MitchellFilter) mitchell(x float64) float64 {
+	x = math.Abs(2 * x / f.radius)
+	b := f.b
+	c := f.c
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+func (f MitchellFilter) Evaluate(x, y float64) float64 {
+	return f.mitchell(x) * f.mitchell(y)
+}
+
+// LanczosFilter is the sinc function windowed by a sinc stretched to its
+// radius
+type LanczosFilter struct {
+	radius float64
+}
+
+// NewLanczosFilter instantiate a new LanczosFilter
+func NewLanczosFilter(radius float64) *LanczosFilter {
+	return &LanczosFilter{radius: radius}
+}
+
+func (f LanczosFilter) Radius() float64 {
+	return f.radius
+}
+
+func sinc(x float64) float64 {
+	if math.Abs(x) < 1e-5 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func (f LanczosFilter) lanczos(x float64) float64 {
+	if math.Abs(x) > f.radius {
+		return 0
+	}
+	return sinc(x) * sinc(x/f.radius)
+}
+
+func (f LanczosFilter) Evaluate(x, y float64) float64 {
+	return f.lanczos(x) * f.lanczos(y)
+}
+
+// default parameters of the filters
+const (
+	DefaultBoxRadius      = 0.5
+	DefaultTentRadius     = 1.0
+	DefaultGaussianRadius = 1.5
+	DefaultGaussianAlpha  = 2.0
+	DefaultMitchellRadius = 2.0
+	DefaultLanczosRadius  = 3.0
+)
+
+// ParseFilter return the filter of the given name (box, tent, gaussian,
+// mitchell or lanczos) with the given radius, 0 meaning its default radius
+func ParseFilter(name string, radius float64) (Filter, error) {
+	if radius < 0 {
+		return nil, fmt.Errorf("negative filter radius %v", radius)
+	}
+	orDefault := func(def float64) float64 {
+		if radius == 0 {
+			return def
+		}
+		return radius
+	}
+	switch strings.ToLower(name) {
+	case "box":
+		return NewBoxFilter(orDefault(DefaultBoxRadius)), nil
+	case "tent", "triangle":
+		return NewTentFilter(orDefault(DefaultTentRadius)), nil
+	case "gaussian":
+		return NewGaussianFilter(orDefault(DefaultGaussianRadius), DefaultGaussianAlpha), nil
+	case "mitchell":
+		return NewMitchellFilter(orDefault(DefaultMitchellRadius), 1.0/3, 1.0/3), nil
+	case "lanczos":
+		return NewLanczosFilter(orDefault(DefaultLanczosRadius)), nil
+	}
+	return nil, fmt.Errorf("unknown filter %q", name)
+}
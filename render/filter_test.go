@@ -0,0 +1,92 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// testFilters are the filters of the tests with their value at the center
+var testFilters = []struct {
+	name   string
+	filter render.Filter
+	center float64
+}{
+	{"box", render.NewBoxFilter(render.DefaultBoxRadius), 1},
+	{"tent", render.NewTentFilter(render.DefaultTentRadius), 1},
+	{"gaussian", render.NewGaussianFilter(render.DefaultGaussianRadius, render.DefaultGaussianAlpha), (1 - math.Exp(-4.5)) * (1 - math.Exp(-4.5))},
+	{"mitchell", render.NewMitchellFilter(render.DefaultMitchellRadius, 1.0/3, 1.0/3), 64.0 / 81},
+	{"lanczos", render.NewLanczosFilter(render.DefaultLanczosRadius), 1},
+}
+
+func TestFilterValues(t *testing.T) {
+	for _, f := range testFilters {
+		r := f.filter.Radius()
+		if v := f.filter.Evaluate(0, 0); math.Abs(v-f.center) > 1e-12 {
+			t.Errorf("%v is %v at the center instead of %v", f.name, v, f.center)
+		}
+		// the box still counts the samples on its border
+		atRadius := 0.0
+		if f.name == "box" {
+			atRadius = 1
+		}
+		if v := f.filter.Evaluate(r, 0); math.Abs(v-atRadius) > 1e-12 {
+			t.Errorf("%v is %v at its radius %v instead of %v", f.name, v, r, atRadius)
+		}
+		if v := f.filter.Evaluate(0, -r); math.Abs(v-atRadius) > 1e-12 {
+			t.Errorf("%v is %v at its radius -%v instead of %v", f.name, v, r, atRadius)
+		}
+		if v := f.filter.Evaluate(1.01*r, 0); v != 0 {
+			t.Errorf("%v is %v beyond its radius", f.name, v)
+		}
+	}
+}
+
+func TestFilmConstant(t *testing.T) {
+	const width, height, samples = 9, 7, 4
+	col := geom.NewVec3(0.25, 0.5, 2)
+	for _, f := range testFilters {
+		film := render.NewFilm(width, height, f.filter)
+		// stratified samples splatted tile by tile, as the renderer does
+		for _, tile := range render.SplitTiles(width, height, 4) {
+			ft := film.NewTile(tile)
+			for y := tile.Y0; y < tile.Y1; y++ {
+				for x := tile.X0; x < tile.X1; x++ {
+					for j := 0; j < samples; j++ {
+						for i := 0; i < samples; i++ {
+							ft.AddSample(x, y, (float64(i)+0.5)/samples, (float64(j)+0.5)/samples, col)
+						}
+					}
+				}
+			}
+			film.MergeTile(ft)
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if c := film.At(x, y); c.Minus(col).Length() > 1e-9 {
+					t.Fatalf("%v : pixel %v %v is %v instead of %v", f.name, x, y, c, col)
+				}
+			}
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, f := range testFilters {
+		filter, err := render.ParseFilter(f.name, 0)
+		if err != nil || filter.Radius() != f.filter.Radius() {
+			t.Errorf("%v is parsed as %#v, %v", f.name, filter, err)
+		}
+	}
+	if filter, err := render.ParseFilter("Tent", 2.5); err != nil || filter.Radius() != 2.5 {
+		t.Errorf("a tent of radius 2.5 is parsed as %#v, %v", filter, err)
+	}
+	if _, err := render.ParseFilter("box", -1); err == nil {
+		t.Error("a negative radius is accepted")
+	}
+	if _, err := render.ParseFilter("sinc", 0); err == nil {
+		t.Error("an unknown filter is accepted")
+	}
+}
@@ -0,0 +1,37 @@
+package render
+
+import (
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// Framebuffer is the linear radiance estimated for each pixel of the image.
+// The first row of Pix is the top of the image.
+type Framebuffer struct {
+	Width  int
+	Height int
+	Pix    []geom.Vec3
+}
+
+// NewFramebuffer instantiate a black framebuffer of the given size
+func NewFramebuffer(width, height int) *Framebuffer {
+	return &Framebuffer{
+		Width:  width,
+		Height: height,
+		Pix:    make([]geom.Vec3, width*height),
+	}
+}
+
+// Size implements Image
+func (fb *Framebuffer) Size() (int, int) {
+	return fb.Width, fb.Height
+}
+
+// At return the color of the pixel (x, y), y = 0 being the top row
+func (fb *Framebuffer) At(x, y int) geom.Vec3 {
+	return fb.Pix[y*fb.Width+x]
+}
+
+// Set set the color of the pixel (x, y), y = 0 being the top row
+func (fb *Framebuffer) Set(x, y int, col geom.Vec3) {
+	fb.Pix[y*fb.Width+x] = col
+}
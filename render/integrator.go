@@ -0,0 +1,125 @@
+package render
+
+import (
+	"math"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// DefaultMaxDepth is the maximum number of bounces when Settings.MaxDepth is
+// not set
+const DefaultMaxDepth = 50
+
+// DefaultRouletteDepth is the number of bounces after which Russian roulette
+// starts when Settings.RouletteDepth is not set
+const DefaultRouletteDepth = 5
+
+// maxRouletteProbability caps the survival probability so that paths
+// with a high throughput still end
+const maxRouletteProbability = 0.95
+
+// Integrator estimates the radiance coming back along camera rays by
+// following paths iteratively. Each path carries its throughput, the product
+// of the attenuations divided by the pdf of the sampled directions, and after
+// RouletteDepth bounces it survives with a probability equal to its
+// throughput, its contribution being divided by this probability so that the
+// estimate stays unbiased.
+type Integrator struct {
+	World         geom.Hitable
+	Lights        geom.Hitable
+	MaxDepth      int
+	RouletteDepth int
+}
+
+// NewIntegrator instantiate the integrator of a scene
+func NewIntegrator(scene *Scene) *Integrator {
+	maxDepth := scene.Settings.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	rouletteDepth := scene.Settings.RouletteDepth
+	if rouletteDepth <= 0 {
+		rouletteDepth = DefaultRouletteDepth
+	}
+	return &Integrator{
+		World:         scene.Objects,
+		Lights:        scene.Lights,
+		MaxDepth:      maxDepth,
+		RouletteDepth: rouletteDepth,
+	}
+}
+
+// Li estimate the radiance coming back along r
+func (it *Integrator) Li(r geom.Ray, smp *geom.Sampler) geom.Vec3 {
+	return it.LiAOV(r, smp, nil)
+}
+
+// LiAOV estimate the radiance coming back along r like Li and, if aov is not
+// nil, record its first hit in aov. The random numbers drawn are the same as
+// Li.
+func (it *Integrator) LiAOV(r geom.Ray, smp *geom.Sampler, aov *AOVSample) geom.Vec3 {
+	if aov != nil {
+		*aov = AOVSample{}
+	}
+	var hrec geom.HitRecord
+	var srec geom.ScatterRecord
+	radiance := geom.NewVec3(0, 0, 0)
+	throughput := geom.NewVec3(1, 1, 1)
+
+	for depth := 0; ; depth++ {
+		// the IDs are only set by the tagged objects
+		hrec.ObjectID, hrec.MaterialID = 0, 0
+		if !it.World.Hit(r, 0.001, math.MaxFloat64, &hrec) {
+			break
+		}
+		emitted := hrec.MatPtr.Emitted(r, &hrec, hrec.U, hrec.V, hrec.P)
+		radiance = radiance.Plus(throughput.Times(emitted))
+		if depth == 0 && aov != nil {
+			aov.record(&hrec, emitted)
+		}
+		if depth >= it.MaxDepth || !hrec.MatPtr.Scatter(r, &hrec, &srec, smp) {
+			break
+		}
+		if depth == 0 && aov != nil {
+			aov.Albedo = srec.Attenuation
+		}
+		if srec.IsSpecular {
+			throughput = throughput.Times(srec.Attenuation)
+			r = srec.SpecularRay
+		} else {
+			pLight := geom.NewHitablePdf(it.Lights, hrec.P)
+			p := geom.NewMixturePdf(pLight, srec.PdfPtr)
+			scattered := geom.NewRayWithTime(hrec.P, p.Generate(smp), r.Time())
+			pdfVal := p.Value(scattered.Direction())
+			weight := hrec.MatPtr.ScatteringPdf(r, &hrec, scattered) / pdfVal
+			throughput = throughput.Times(srec.Attenuation.TimesScalar(weight))
+			r = scattered
+		}
+		// Russian roulette
+		if depth+1 >= it.RouletteDepth {
+			q := math.Min(math.Max(throughput.X(), math.Max(throughput.Y(), throughput.Z())), maxRouletteProbability)
+			if !(smp.Float64() < q) {
+				break
+			}
+			throughput = throughput.ByScalar(q)
+		}
+	}
+	return radiance
+}
+
+// deNan replace the NaN and negative components of a sample by 0
+func deNan(v geom.Vec3) geom.Vec3 {
+	x := v.X()
+	y := v.Y()
+	z := v.Z()
+	if x < 0 || math.IsNaN(x) {
+		x = 0.0
+	}
+	if y < 0 || math.IsNaN(y) {
+		y = 0.0
+	}
+	if z < 0 || math.IsNaN(z) {
+		z = 0.0
+	}
+	return geom.NewVec3(x, y, z)
+}
@@ -0,0 +1,85 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/scenes"
+)
+
+// meanRadiance return the mean and the standard error of the mean of the
+// luminance of n paths through the center of the Cornell box
+func meanRadiance(it *render.Integrator, scene *render.Scene, n int, seed uint64) (float64, float64) {
+	smp := geom.NewSampler(seed)
+	sum, sumSquares := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		r := scene.Camera.GetRay(0.4+0.2*smp.Float64(), 0.4+0.2*smp.Float64(), smp)
+		c := it.Li(r, smp)
+		y := 0.2126*c.X() + 0.7152*c.Y() + 0.0722*c.Z()
+		if math.IsNaN(y) {
+			y = 0
+		}
+		sum += y
+		sumSquares += y * y
+	}
+	mean := sum / float64(n)
+	variance := (sumSquares/float64(n) - mean*mean) / float64(n-1)
+	return mean, math.Sqrt(variance)
+}
+
+func TestRouletteUnbiased(t *testing.T) {
+	settings := &render.Settings{
+		Width:    40,
+		Height:   40,
+		Samples:  1,
+		MaxDepth: 50,
+	}
+	scene := scenes.CornellBox(settings, scenes.DefaultLeftWall, scenes.DefaultRightWall)
+	it := render.NewIntegrator(scene)
+	// the roulette starts after the first bounce, or never
+	it.RouletteDepth = 1
+	on, onErr := meanRadiance(it, scene, 200000, 1)
+	it.RouletteDepth = settings.MaxDepth + 1
+	off, offErr := meanRadiance(it, scene, 200000, 2)
+	// the difference of the means is within 4 standard errors
+	tolerance := 4 * math.Sqrt(onErr*onErr+offErr*offErr)
+	if math.Abs(on-off) > tolerance {
+		t.Errorf("mean luminance %v with the roulette and %v without, more than %v apart", on, off, tolerance)
+	}
+	if on <= 0 || tolerance > 0.1*off {
+		t.Errorf("mean luminance %v ± %v, the test is not conclusive", off, tolerance)
+	}
+}
+
+func TestMeshLightUnbiased(t *testing.T) {
+	settings := &render.Settings{
+		Width:    40,
+		Height:   40,
+		Samples:  1,
+		MaxDepth: 50,
+	}
+	scene := scenes.CornellBox(settings, scenes.DefaultLeftWall, scenes.DefaultRightWall)
+	it := render.NewIntegrator(scene)
+	// only the ceiling light, the density of the sphere being off
+	it.Lights = geom.NewXZRect(213, 343, 227, 332, 554, geom.NewNoMaterial())
+	rect, rectErr := meanRadiance(it, scene, 200000, 1)
+	// the rays are sampled toward the ceiling light and a copy of it below,
+	// the room seeing the light through the copy
+	positions := []geom.Vec3{
+		geom.NewVec3(213, 554, 227), geom.NewVec3(343, 554, 227), geom.NewVec3(343, 554, 332), geom.NewVec3(213, 554, 332),
+		geom.NewVec3(213, 450, 227), geom.NewVec3(343, 450, 227), geom.NewVec3(343, 450, 332), geom.NewVec3(213, 450, 332),
+	}
+	indices := []int{0, 1, 2, 0, 2, 3, 4, 5, 6, 4, 6, 7}
+	it.Lights = geom.NewTriangleMesh(positions, nil, nil, indices, geom.NewNoMaterial())
+	mesh, meshErr := meanRadiance(it, scene, 200000, 2)
+	// the difference of the means is within 4 standard errors
+	tolerance := 4 * math.Sqrt(rectErr*rectErr+meshErr*meshErr)
+	if math.Abs(rect-mesh) > tolerance {
+		t.Errorf("mean luminance %v sampling the rectangle and %v the mesh, more than %v apart", rect, mesh, tolerance)
+	}
+	if rect <= 0 || tolerance > 0.1*rect {
+		t.Errorf("mean luminance %v ± %v, the test is not conclusive", rect, tolerance)
+	}
+}
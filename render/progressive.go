@@ -0,0 +1,110 @@
+package render
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultPassSamples is the number of samples per pixel of each pass when
+// ProgressiveOptions.PassSamples is not set
+const DefaultPassSamples = 4
+
+// ProgressiveOptions are the parameters of RenderProgressive. The render
+// stops at the first of the three limits reached : MaxSamples, TimeBudget
+// and NoiseTarget.
+type ProgressiveOptions struct {
+	// PassSamples is the number of samples per pixel added by each pass,
+	// 0 means DefaultPassSamples
+	PassSamples int
+	// MaxSamples is the number of samples per pixel after which the render
+	// stops, 0 means Settings.Samples and a negative number no limit, the
+	// render then only stopping on TimeBudget or NoiseTarget
+	MaxSamples int
+	// TimeBudget is the wall-clock time after which the render stops, the
+	// tiles of the pass being computed are then skipped. 0 means no limit.
+	TimeBudget time.Duration
+	// NoiseTarget stops the render when Accumulator.Noise falls below it,
+	// 0 means no target
+	NoiseTarget float64
+	// SnapshotInterval is the minimum time between two calls to Snapshot,
+	// 0 means after every pass
+	SnapshotInterval time.Duration
+	// Snapshot, if not nil, is called with the intermediate image between
+	// passes and with the final image at the end
+	Snapshot func(fb *Framebuffer, status ProgressiveStatus)
+}
+
+// ProgressiveStatus describes the state of a progressive render
+type ProgressiveStatus struct {
+	// Pass is the number of passes done
+	Pass int
+	// Samples is the number of samples per pixel of the complete passes
+	Samples int
+	// Elapsed is the time since the beginning of the render
+	Elapsed time.Duration
+	// Noise is the estimation of the noise left, see Accumulator.Noise
+	Noise float64
+	// Done is true for the final image
+	Done bool
+}
+
+// RenderProgressive compute the image of the scene by passes of
+// PassSamples samples per pixel accumulated until one of the limits of opts
+// is reached. When the limit is MaxSamples, the image is the same as the one
+// returned by Render with as many samples.
+func (rd *Renderer) RenderProgressive(opts ProgressiveOptions) *Framebuffer {
+	settings := rd.Scene.Settings
+	passSamples := opts.PassSamples
+	if passSamples <= 0 {
+		passSamples = DefaultPassSamples
+	}
+	maxSamples := opts.MaxSamples
+	if maxSamples == 0 {
+		maxSamples = settings.Samples
+	} else if maxSamples < 0 {
+		maxSamples = math.MaxInt
+	}
+	start := time.Now()
+	var stop func() bool
+	if opts.TimeBudget > 0 {
+		stop = func() bool {
+			return time.Since(start) >= opts.TimeBudget
+		}
+	}
+
+	acc := rd.newAccumulator()
+	status := ProgressiveStatus{}
+	lastSnapshot := start
+	for status.Samples < maxSamples {
+		samples := min(passSamples, maxSamples-status.Samples)
+		rd.renderPass(acc, samples, nil, stop)
+		if stop != nil && stop() {
+			break
+		}
+		status.Pass++
+		status.Samples += samples
+		status.Elapsed = time.Since(start)
+		if opts.NoiseTarget > 0 {
+			status.Noise = acc.Noise()
+			if status.Noise < opts.NoiseTarget {
+				break
+			}
+		}
+		if opts.Snapshot != nil && status.Samples < maxSamples && time.Since(lastSnapshot) >= opts.SnapshotInterval {
+			if opts.NoiseTarget <= 0 {
+				status.Noise = acc.Noise()
+			}
+			opts.Snapshot(acc.Framebuffer(), status)
+			lastSnapshot = time.Now()
+		}
+	}
+
+	fb := acc.Framebuffer()
+	if opts.Snapshot != nil {
+		status.Elapsed = time.Since(start)
+		status.Noise = acc.Noise()
+		status.Done = true
+		opts.Snapshot(fb, status)
+	}
+	return fb
+}
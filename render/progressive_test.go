@@ -0,0 +1,127 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/scenes"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// constantScene return a scene whose camera is inside a sphere emitting the
+// same radiance everywhere, so that every sample of every pixel is the same
+func constantScene(settings *render.Settings) *render.Scene {
+	light := geom.NewDiffuseLight(geom.NewConstantTexture(geom.NewVec3(0.5, 0.5, 0.5)))
+	sphere := geom.NewFlipNormals(geom.NewSphere(geom.NewVec3(0, 0, 0), 10, light))
+	aspect := float64(settings.Width) / float64(settings.Height)
+	return &render.Scene{
+		Objects:  sphere,
+		Lights:   sphere,
+		Camera:   view.NewCamera(geom.NewVec3(0, 0, 0), geom.NewVec3(0, 0, 1), geom.NewVec3(0, 1, 0), 60, aspect, 0, 1, 0, 1),
+		Settings: settings,
+	}
+}
+
+// cornellRenderer return a renderer of a small Cornell box with samples
+// samples per pixel
+func cornellRenderer(samples int) *render.Renderer {
+	settings := &render.Settings{
+		Width:    20,
+		Height:   15,
+		Samples:  samples,
+		Seed:     3,
+		TileSize: 8,
+	}
+	return render.NewRenderer(scenes.CornellBox(settings, scenes.DefaultLeftWall, scenes.DefaultRightWall))
+}
+
+func TestProgressiveMaxSamples(t *testing.T) {
+	var statuses []render.ProgressiveStatus
+	fb := cornellRenderer(10).RenderProgressive(render.ProgressiveOptions{
+		PassSamples: 4,
+		Snapshot: func(fb *render.Framebuffer, status render.ProgressiveStatus) {
+			statuses = append(statuses, status)
+		},
+	})
+	// passes of 4, 4 and 2 samples, the final image being the last call
+	want := []int{4, 8, 10}
+	if len(statuses) != len(want) {
+		t.Fatalf("%v snapshots instead of %v", len(statuses), len(want))
+	}
+	for i := 0; i < len(want); i++ {
+		if statuses[i].Pass != i+1 || statuses[i].Samples != want[i] || statuses[i].Done != (i == len(want)-1) {
+			t.Errorf("snapshot %v is %+v", i, statuses[i])
+		}
+	}
+	// the passes give the image of a single render with as many samples
+	if i := samePix(fb, cornellRenderer(10).Render()); i >= 0 {
+		t.Errorf("pixel %v differs from the one of Render", i)
+	}
+}
+
+func TestProgressiveNoiseTarget(t *testing.T) {
+	settings := &render.Settings{Width: 8, Height: 6, Samples: 100}
+	var last render.ProgressiveStatus
+	fb := render.NewRenderer(constantScene(settings)).RenderProgressive(render.ProgressiveOptions{
+		PassSamples: 2,
+		MaxSamples:  -1,
+		NoiseTarget: 0.01,
+		Snapshot: func(fb *render.Framebuffer, status render.ProgressiveStatus) {
+			last = status
+		},
+	})
+	// two samples are enough to know that the pixels have no variance
+	if !last.Done || last.Pass != 1 || last.Samples != 2 || last.Noise != 0 {
+		t.Errorf("the render stops at %+v", last)
+	}
+	if c := fb.At(3, 2); c != geom.NewVec3(0.5, 0.5, 0.5) {
+		t.Errorf("pixel %v instead of the radiance of the sphere", c)
+	}
+}
+
+func TestProgressiveTimeBudget(t *testing.T) {
+	const budget = 200 * time.Millisecond
+	var last render.ProgressiveStatus
+	start := time.Now()
+	cornellRenderer(1).RenderProgressive(render.ProgressiveOptions{
+		MaxSamples: -1,
+		TimeBudget: budget,
+		Snapshot: func(fb *render.Framebuffer, status render.ProgressiveStatus) {
+			last = status
+		},
+	})
+	// the pass interrupted by the budget is not counted
+	elapsed := time.Since(start)
+	if elapsed < budget || elapsed > budget+2*time.Second {
+		t.Errorf("the render stops after %v with a budget of %v", elapsed, budget)
+	}
+	if !last.Done || last.Samples != last.Pass*render.DefaultPassSamples || last.Elapsed < budget {
+		t.Errorf("the render stops at %+v", last)
+	}
+}
+
+func TestAccumulatorWelford(t *testing.T) {
+	acc := render.NewAccumulator(2, 1, nil)
+	lums := []float64{0.5, 1.25, 3, 0.75, 2}
+	mean := 0.0
+	for i := 0; i < len(lums); i++ {
+		acc.Add(1, 0, geom.NewVec3(lums[i], lums[i], lums[i]))
+		mean += lums[i]
+	}
+	mean /= float64(len(lums))
+	variance := 0.0
+	for i := 0; i < len(lums); i++ {
+		variance += (lums[i] - mean) * (lums[i] - mean)
+	}
+	variance /= float64(len(lums) - 1)
+	want := math.Sqrt(variance / float64(len(lums)))
+	if got := acc.StdError(1, 0); math.Abs(got-want) > 1e-12 {
+		t.Errorf("standard error %v instead of %v", got, want)
+	}
+	if acc.Samples(1, 0) != len(lums) || acc.Samples(0, 0) != 0 || !math.IsInf(acc.StdError(0, 0), 1) {
+		t.Errorf("%v and %v samples, standard error %v of the empty pixel", acc.Samples(0, 0), acc.Samples(1, 0), acc.StdError(0, 0))
+	}
+}
@@ -0,0 +1,176 @@
+package render
+
+import (
+	"runtime"
+	"sync"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// Renderer turns a Scene into a Framebuffer
+type Renderer struct {
+	Scene *Scene
+	// Progress, if not nil, is called after each rendered tile with the number
+	// of pixels done and the total number of pixels of the current pass.
+	// Calls are serialized.
+	Progress func(done, total int)
+
+	integrator *Integrator
+	// accumulator of the last render
+	acc *Accumulator
+}
+
+// NewRenderer instantiate a new Renderer for the scene
+func NewRenderer(scene *Scene) *Renderer {
+	return &Renderer{
+		Scene: scene,
+	}
+}
+
+// workers return the number of goroutines to use for the render
+func (rd *Renderer) workers() int {
+	if rd.Scene.Settings.Workers > 0 {
+		return rd.Scene.Settings.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Render compute the image of the scene with Settings.Samples samples per
+// pixel.
+// The image is split in tiles that are rendered by a pool of goroutines, each
+// pixel being written by exactly one worker. Every sample draws its random
+// numbers from a Sampler seeded by (Settings.Seed, pixel, sample index) so the
+// image only depends on the seed, not on the number of workers.
+func (rd *Renderer) Render() *Framebuffer {
+	acc := rd.newAccumulator()
+	rd.renderPass(acc, rd.Scene.Settings.Samples, nil, nil)
+	return acc.Framebuffer()
+}
+
+// newAccumulator instantiate the accumulator of a render, recording the AOVs
+// if the settings ask for them
+func (rd *Renderer) newAccumulator() *Accumulator {
+	settings := rd.Scene.Settings
+	rd.acc = NewAccumulator(settings.Width, settings.Height, settings.Filter)
+	if settings.AOVs {
+		rd.acc.EnableAOVs()
+	}
+	return rd.acc
+}
+
+// Film return the film of the last render, nil before the first one
+func (rd *Renderer) Film() *Film {
+	if rd.acc == nil {
+		return nil
+	}
+	return rd.acc.Film
+}
+
+// AOVs return the AOVs of the last render, nil if Settings.AOVs was not set
+func (rd *Renderer) AOVs() *AOVs {
+	if rd.acc == nil {
+		return nil
+	}
+	return rd.acc.AOVs()
+}
+
+// renderPass add samples samples to every pixel of the accumulator, the
+// sample indices following the ones already accumulated in each pixel.
+// If budget is not nil, it replaces samples with a number of samples for each
+// pixel y*width+x.
+// If stop is not nil, it is checked before each tile and the remaining tiles
+// are skipped once it returns true.
+// The samples are splatted on a FilmTile per tile, merged in the film in the
+// order of the tiles at the end of the pass.
+func (rd *Renderer) renderPass(acc *Accumulator, samples int, budget []int, stop func() bool) {
+	settings := rd.Scene.Settings
+	if rd.integrator == nil {
+		rd.integrator = NewIntegrator(rd.Scene)
+	}
+	tiles := SplitTiles(settings.Width, settings.Height, settings.TileSize)
+	filmTiles := make([]*FilmTile, len(tiles))
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	total := settings.Width * settings.Height
+	for w := 0; w < rd.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			smp := geom.NewSampler(0)
+			for t := range queue {
+				if stop != nil && stop() {
+					continue
+				}
+				tile := tiles[t]
+				filmTiles[t] = acc.Film.NewTile(tile)
+				rd.renderTile(acc, filmTiles[t], tile, samples, budget, smp)
+				if rd.Progress != nil {
+					mu.Lock()
+					done += tile.Pixels()
+					rd.Progress(done, total)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for t := 0; t < len(tiles); t++ {
+		queue <- t
+	}
+	close(queue)
+	wg.Wait()
+	for t := 0; t < len(tiles); t++ {
+		if filmTiles[t] != nil {
+			acc.Film.MergeTile(filmTiles[t])
+		}
+	}
+}
+
+// renderTile add samples samples, or the budget of each pixel, to the pixels
+// of one tile, splatting them on ft
+func (rd *Renderer) renderTile(acc *Accumulator, ft *FilmTile, tile Tile, samples int, budget []int, smp *geom.Sampler) {
+	var aov *AOVSample
+	if acc.aov != nil {
+		aov = new(AOVSample)
+	}
+	for y := tile.Y0; y < tile.Y1; y++ {
+		for x := tile.X0; x < tile.X1; x++ {
+			n := samples
+			if budget != nil {
+				n = budget[y*acc.Width+x]
+			}
+			first := acc.Samples(x, y)
+			for s := first; s < first+n; s++ {
+				col, dx, dy := rd.renderSample(x, y, s, smp, aov)
+				if aov != nil {
+					acc.AddAOV(x, y, s, aov)
+				}
+				acc.Add(x, y, col)
+				ft.AddSample(x, y, dx, dy, col)
+			}
+		}
+	}
+}
+
+// renderSample estimate the color of the sample s of the pixel (x, y),
+// y = 0 being the top row, recording its first hit in aov if it is not nil.
+// It also returns the position of the sample from the top left corner of the
+// pixel.
+func (rd *Renderer) renderSample(x, y, s int, smp *geom.Sampler, aov *AOVSample) (geom.Vec3, float64, float64) {
+	scene := rd.Scene
+	width := scene.Settings.Width
+	height := scene.Settings.Height
+	// the camera space has j = 0 on the bottom row
+	i := x
+	j := height - 1 - y
+	smp.SeedPixel(scene.Settings.Seed, x, y, s)
+	du := smp.Float64()
+	dv := smp.Float64()
+	u := (float64(i) + du) / float64(width)
+	v := (float64(j) + dv) / float64(height-1)
+	var r = scene.Camera.GetRay(u, v, smp)
+	// v goes up, y down
+	return deNan(rd.integrator.LiAOV(r, smp, aov)), du, 1 - dv
+}
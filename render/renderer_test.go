@@ -0,0 +1,122 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/scenes"
+)
+
+func TestSplitTiles(t *testing.T) {
+	sizes := [][3]int{{40, 30, 8}, {17, 5, 16}, {1, 1, 4}, {33, 65, 0}}
+	for _, size := range sizes {
+		width, height := size[0], size[1]
+		covered := make([]int, width*height)
+		for _, tile := range render.SplitTiles(width, height, size[2]) {
+			for y := tile.Y0; y < tile.Y1; y++ {
+				for x := tile.X0; x < tile.X1; x++ {
+					covered[y*width+x]++
+				}
+			}
+		}
+		for i := 0; i < len(covered); i++ {
+			if covered[i] != 1 {
+				t.Fatalf("%vx%v by %v : pixel %v is in %v tiles", width, height, size[2], i, covered[i])
+			}
+		}
+	}
+}
+
+// renderCornell render a small Cornell box with the given settings on top of
+// the test ones
+func renderCornell(t *testing.T, workers int, filter render.Filter, seed uint64) *render.Framebuffer {
+	t.Helper()
+	settings := &render.Settings{
+		Width:    40,
+		Height:   30,
+		Samples:  4,
+		Seed:     seed,
+		Workers:  workers,
+		TileSize: 8,
+		Filter:   filter,
+	}
+	if err := settings.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	scene := scenes.CornellBox(settings, scenes.DefaultLeftWall, scenes.DefaultRightWall)
+	fb := render.NewRenderer(scene).Render()
+	// a black image would compare equal whatever the render
+	for i := 0; i < len(fb.Pix); i++ {
+		if fb.Pix[i].SquaredLength() > 0 {
+			return fb
+		}
+	}
+	t.Fatal("the Cornell box is black")
+	return nil
+}
+
+// samePix return the index of the first pixel differing between a and b, -1
+// if they are identical
+func samePix(a, b *render.Framebuffer) int {
+	for i := 0; i < len(a.Pix); i++ {
+		if a.Pix[i] != b.Pix[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRenderWorkers(t *testing.T) {
+	filters := []struct {
+		name   string
+		filter render.Filter
+	}{
+		{"box", nil},
+		{"mitchell", render.NewMitchellFilter(render.DefaultMitchellRadius, 1.0/3, 1.0/3)},
+	}
+	for _, f := range filters {
+		t.Run(f.name, func(t *testing.T) {
+			one := renderCornell(t, 1, f.filter, 1)
+			eight := renderCornell(t, 8, f.filter, 1)
+			if i := samePix(one, eight); i >= 0 {
+				t.Errorf("pixel %v is %v with 1 worker and %v with 8", i, one.Pix[i], eight.Pix[i])
+			}
+		})
+	}
+}
+
+func TestRenderSeed(t *testing.T) {
+	a := renderCornell(t, 4, nil, 7)
+	b := renderCornell(t, 4, nil, 7)
+	if i := samePix(a, b); i >= 0 {
+		t.Errorf("pixel %v is %v then %v with the same seed", i, a.Pix[i], b.Pix[i])
+	}
+	c := renderCornell(t, 4, nil, 8)
+	if samePix(a, c) < 0 {
+		t.Error("the seeds 7 and 8 give the same image")
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	settings := &render.Settings{
+		Width:    40,
+		Height:   30,
+		Samples:  1,
+		Workers:  8,
+		TileSize: 8,
+	}
+	scene := scenes.CornellBox(settings, scenes.DefaultLeftWall, scenes.DefaultRightWall)
+	renderer := render.NewRenderer(scene)
+	// the calls are serialized, the count of pixels done only grows
+	last := 0
+	renderer.Progress = func(done, total int) {
+		if done <= last || total != settings.Width*settings.Height {
+			t.Errorf("%v pixels done of %v after %v", done, total, last)
+		}
+		last = done
+	}
+	renderer.Render()
+	if last != settings.Width*settings.Height {
+		t.Errorf("%v pixels rendered of %v", last, settings.Width*settings.Height)
+	}
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"fmt"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// Settings holds the parameters of the output image and sampling
+type Settings struct {
+	Width   int
+	Height  int
+	Samples int
+	// MaxDepth is the maximum number of bounces of a path, 0 means
+	// DefaultMaxDepth
+	MaxDepth int
+	// RouletteDepth is the number of bounces after which paths are randomly
+	// ended depending on their throughput, 0 means DefaultRouletteDepth.
+	// Set it to MaxDepth or more to disable Russian roulette.
+	RouletteDepth int
+	// Seed determines all the random numbers of the render : the same seed
+	// always gives the same image
+	Seed uint64
+	// Workers is the number of goroutines rendering tiles, 0 means GOMAXPROCS
+	Workers int
+	// TileSize is the side in pixels of the square tiles handed to the
+	// workers, 0 means DefaultTileSize
+	TileSize int
+	// Filter reconstructs the pixels from the samples, nil means a box
+	// filter of radius 0.5 : the average of the samples of each pixel
+	Filter Filter
+	// AOVs makes the renders record the AOVs of the first hits, see
+	// Renderer.AOVs
+	AOVs bool
+}
+
+// DefaultTileSize is the tile side used when Settings.TileSize is not set
+const DefaultTileSize = 16
+
+// Validate return an error describing the first invalid setting, nil if the
+// settings can be rendered
+func (s *Settings) Validate() error {
+	switch {
+	case s.Width <= 0 || s.Height <= 0:
+		return fmt.Errorf("invalid image size %vx%v, the width and height must be positive", s.Width, s.Height)
+	case s.Samples <= 0:
+		return fmt.Errorf("invalid number of samples %v, it must be positive", s.Samples)
+	case s.MaxDepth < 0:
+		return fmt.Errorf("invalid maximum depth %v, it must be positive or 0 for the default", s.MaxDepth)
+	case s.RouletteDepth < 0:
+		return fmt.Errorf("invalid roulette depth %v, it must be positive or 0 for the default", s.RouletteDepth)
+	case s.Workers < 0:
+		return fmt.Errorf("invalid number of workers %v, it must be positive or 0 for all the cores", s.Workers)
+	case s.TileSize < 0:
+		return fmt.Errorf("invalid tile size %v, it must be positive or 0 for the default", s.TileSize)
+	}
+	return nil
+}
+
+// Scene is everything the Renderer needs to produce an image :
+//   - Objects is the world that rays are traced against
+//   - Lights is the list of shapes sampled directly (lights and glass)
+//   - Camera is the point of view
+//   - Settings are the image and sampling parameters
+type Scene struct {
+	Objects  geom.Hitable
+	Lights   geom.Hitable
+	Camera   *view.Camera
+	Settings *Settings
+}
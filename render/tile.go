@@ -0,0 +1,36 @@
+package render
+
+// Tile is a rectangular part of the image, X0 and Y0 included, X1 and Y1
+// excluded, y = 0 being the top row
+type Tile struct {
+	X0 int
+	Y0 int
+	X1 int
+	Y1 int
+}
+
+// Pixels return the number of pixels covered by the tile
+func (t Tile) Pixels() int {
+	return (t.X1 - t.X0) * (t.Y1 - t.Y0)
+}
+
+// SplitTiles cut a width x height image in square tiles of side size, row by
+// row from the top left corner. Tiles on the right and bottom borders are
+// cropped to the image.
+func SplitTiles(width, height, size int) []Tile {
+	if size < 1 {
+		size = DefaultTileSize
+	}
+	tiles := make([]Tile, 0, ((width+size-1)/size)*((height+size-1)/size))
+	for y := 0; y < height; y += size {
+		for x := 0; x < width; x += size {
+			tiles = append(tiles, Tile{
+				X0: x,
+				Y0: y,
+				X1: min(x+size, width),
+				Y1: min(y+size, height),
+			})
+		}
+	}
+	return tiles
+}
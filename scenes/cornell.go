@@ -0,0 +1,60 @@
+package scenes
+
+import (
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// MakeCornellBoxObjects build the objects of the Cornell box with a glass ball
+// and a white box, leftWall and rightWall being the colors of the side walls
+func MakeCornellBoxObjects(leftWall, rightWall geom.Vec3) *geom.HitableList {
+	list := make([]geom.Hitable, 50)
+	left := geom.Lambertian{Albedo: geom.NewConstantTexture(leftWall)}
+	white := geom.Lambertian{Albedo: geom.NewConstantTexture(geom.NewVec3(0.73, 0.73, 0.73))}
+	right := geom.Lambertian{Albedo: geom.NewConstantTexture(rightWall)}
+	light := geom.DiffuseLight{Emit: geom.NewConstantTexture(geom.NewVec3(15, 15, 15))}
+	list[0] = geom.NewFlipNormals(geom.NewYZRect(0, 555, 0, 555, 555, right))
+	list[1] = geom.NewYZRect(0, 555, 0, 555, 0, left)
+	list[2] = geom.NewFlipNormals(geom.NewXZRect(213, 343, 227, 332, 554, light))
+	list[3] = geom.NewFlipNormals(geom.NewXZRect(0, 555, 0, 555, 555, white))
+	list[4] = geom.NewXZRect(0, 555, 0, 555, 0, white)
+	list[5] = geom.NewFlipNormals(geom.NewXYRect(0, 555, 0, 555, 555, white))
+	// boxes
+	glass := geom.Dielectric{RefIdx: 1.5}
+	list[6] = geom.NewSphere(geom.NewVec3(190, 90, 190), 90, glass)
+	list[7] = geom.NewTranslate(geom.NewRotateY(geom.NewBox(geom.NewVec3(0, 0, 0), geom.NewVec3(165, 330, 165), white), 15), geom.NewVec3(265, 0, 295))
+	// the object IDs follow the list, the material IDs are left, right,
+	// white, light and glass
+	materialIDs := []int{2, 1, 4, 3, 3, 3, 5, 3}
+	for i := 0; i < 8; i++ {
+		list[i] = geom.NewTagged(list[i], i+1, materialIDs[i])
+	}
+	return geom.NewHitableList(&list, 8)
+}
+
+// MakeCornellBoxLights build the shapes toward which rays are sampled :
+// the ceiling light and the glass ball
+func MakeCornellBoxLights() *geom.HitableList {
+	list := make([]geom.Hitable, 2)
+	list[0] = geom.NewXZRect(213, 343, 227, 332, 554, geom.NewNoMaterial())
+	list[1] = geom.NewSphere(geom.NewVec3(190, 90, 190), 90, geom.NewNoMaterial())
+	return geom.NewHitableList(&list, 2)
+}
+
+// CornellBox build the complete Cornell box scene
+func CornellBox(settings *render.Settings, leftWall, rightWall geom.Vec3) *render.Scene {
+	var lookFrom = geom.NewVec3(278, 278, -800)
+	var lookAt = geom.NewVec3(278, 278, 0)
+	distToFocus := 10.0
+	aperture := 0.0
+	vfov := 40.0
+	aspect := float64(settings.Width) / float64(settings.Height)
+	var cam = view.NewCamera(lookFrom, lookAt, geom.NewVec3(0, 1, 0), vfov, aspect, aperture, distToFocus, 0.0, 1.0)
+	return &render.Scene{
+		Objects:  MakeCornellBoxObjects(leftWall, rightWall),
+		Lights:   MakeCornellBoxLights(),
+		Camera:   cam,
+		Settings: settings,
+	}
+}
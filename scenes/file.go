@@ -0,0 +1,63 @@
+package scenes
+
+import (
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// CameraSpec are the parameters of view.NewCamera. The aspect ratio is taken
+// from the size of the image when it is 0.
+type CameraSpec struct {
+	LookFrom  geom.Vec3
+	LookAt    geom.Vec3
+	Vup       geom.Vec3
+	Vfov      float64
+	Aspect    float64
+	Aperture  float64
+	FocusDist float64
+	Time0     float64
+	Time1     float64
+}
+
+// Camera build the camera for an image of the size of the settings
+func (c CameraSpec) Camera(settings *render.Settings) *view.Camera {
+	aspect := c.Aspect
+	if aspect == 0 {
+		aspect = float64(settings.Width) / float64(settings.Height)
+	}
+	return view.NewCamera(c.LookFrom, c.LookAt, c.Vup, c.Vfov, aspect, c.Aperture, c.FocusDist, c.Time0, c.Time1)
+}
+
+// File is the content of a scene file : the objects and lights are built,
+// the camera is built for the size of the image when the scene is rendered
+type File struct {
+	Description string
+	// Settings are the render settings of the file, the Workers being left
+	// to the machine
+	Settings render.Settings
+	Camera   CameraSpec
+	// Textures and Materials are the named textures and materials, which
+	// the objects refer to by their name in the file
+	Textures  map[string]geom.Texture
+	Materials map[string]geom.Material
+	Objects   []geom.Hitable
+	Lights    []geom.Hitable
+}
+
+// Scene return the scene rendered with settings, the settings of the file
+// if it is nil
+func (f *File) Scene(settings *render.Settings) *render.Scene {
+	if settings == nil {
+		s := f.Settings
+		settings = &s
+	}
+	objects := append([]geom.Hitable(nil), f.Objects...)
+	lights := append([]geom.Hitable(nil), f.Lights...)
+	return &render.Scene{
+		Objects:  geom.NewHitableList(&objects, len(objects)),
+		Lights:   geom.NewHitableList(&lights, len(lights)),
+		Camera:   f.Camera.Camera(settings),
+		Settings: settings,
+	}
+}
@@ -0,0 +1,77 @@
+package scenes
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/mesh"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// DefaultGLTFVfov is the vertical field of view of the camera of the glTF
+// files without camera
+const DefaultGLTFVfov = 40
+
+// LoadGLTF read the glTF file at path as a scene file : its meshes are the
+// objects, their emissive triangles the lights, and its first camera the
+// camera. The files without camera are seen from the front, along -Z. The
+// renderer having neither sky nor punctual light, a file without emissive
+// material can only be included in a JSON scene file with lights, as a mesh.
+func LoadGLTF(path string) (*File, error) {
+	g, err := mesh.LoadGLTF(path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := newMesh(path, g.Model, MeshOptions{})
+	if err != nil {
+		return nil, err
+	}
+	lights, err := newMesh(path, g.Model, MeshOptions{Emissive: true})
+	if err != nil {
+		return nil, fmt.Errorf("%v : the scene has no emissive material to light it, it can be included in a JSON scene file with lights", path)
+	}
+	var camera CameraSpec
+	if len(g.Cameras) > 0 {
+		c := g.Cameras[0]
+		camera = CameraSpec{
+			LookFrom:  c.LookFrom,
+			LookAt:    c.LookAt,
+			Vup:       c.Vup,
+			Vfov:      c.Vfov,
+			Aspect:    c.Aspect,
+			FocusDist: c.LookAt.Minus(c.LookFrom).Length(),
+			Time1:     1,
+		}
+	} else {
+		var box geom.Aabb
+		objects.BoundingBox(0, 1, &box)
+		camera = frontCamera(box)
+	}
+	return &File{
+		Description: filepath.Base(path),
+		Settings:    render.Settings{Width: DefaultFileWidth, Height: DefaultFileHeight, Samples: DefaultFileSamples},
+		Camera:      camera,
+		Textures:    map[string]geom.Texture{},
+		Materials:   map[string]geom.Material{},
+		Objects:     []geom.Hitable{objects},
+		Lights:      []geom.Hitable{lights},
+	}, nil
+}
+
+// frontCamera return a camera looking at a box along -Z, far enough for the
+// sphere around the box to be seen whole
+func frontCamera(box geom.Aabb) CameraSpec {
+	center := box.Min().Plus(box.Max()).ByScalar(2)
+	radius := box.Max().Minus(box.Min()).Length() / 2
+	distance := radius / math.Sin(DefaultGLTFVfov*math.Pi/360)
+	return CameraSpec{
+		LookFrom:  center.Plus(geom.NewVec3(0, 0, distance)),
+		LookAt:    center,
+		Vup:       geom.NewVec3(0, 1, 0),
+		Vfov:      DefaultGLTFVfov,
+		FocusDist: distance,
+		Time1:     1,
+	}
+}
@@ -0,0 +1,204 @@
+package scenes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Error is an error of a scene file, located by its line and column
+type Error struct {
+	Path   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v:%v:%v: %v", e.Path, e.Line, e.Column, e.Msg)
+}
+
+// nodeKind is the type of a JSON value
+type nodeKind int
+
+const (
+	nodeNull nodeKind = iota
+	nodeBool
+	nodeNumber
+	nodeString
+	nodeArray
+	nodeObject
+)
+
+func (k nodeKind) String() string {
+	switch k {
+	case nodeBool:
+		return "a boolean"
+	case nodeNumber:
+		return "a number"
+	case nodeString:
+		return "a string"
+	case nodeArray:
+		return "an array"
+	case nodeObject:
+		return "an object"
+	}
+	return "null"
+}
+
+// node is a JSON value with its offset in the file, so that the errors of
+// the scene can be located
+type node struct {
+	kind   nodeKind
+	offset int
+	// text is the string, or the number as written
+	text    string
+	boolean bool
+	// keys are the keys of an object, in the order of the file
+	keys       []string
+	keyOffsets []int
+	// values are the values of the keys of an object or the items of an
+	// array
+	values []*node
+}
+
+// parseJSON parse a JSON document into a tree of nodes
+func parseJSON(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := parseNode(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &offsetError{offset: skipSpace(data, int(dec.InputOffset())), msg: "unexpected data after the scene"}
+	}
+	return root, nil
+}
+
+// skipSpace return the offset of the first token at or after offset, the
+// decoder offsets being at the end of the previous token
+func skipSpace(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// parseNode parse the next value of the decoder
+func parseNode(dec *json.Decoder, data []byte) (*node, error) {
+	n := &node{offset: skipSpace(data, int(dec.InputOffset()))}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			n.kind = nodeObject
+			for dec.More() {
+				keyOffset := skipSpace(data, int(dec.InputOffset()))
+				tok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := tok.(string)
+				if n.get(key) != nil {
+					return nil, &offsetError{offset: keyOffset, msg: fmt.Sprintf("duplicate key %q", key)}
+				}
+				value, err := parseNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.keyOffsets = append(n.keyOffsets, keyOffset)
+				n.values = append(n.values, value)
+			}
+		} else {
+			n.kind = nodeArray
+			for dec.More() {
+				value, err := parseNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				n.values = append(n.values, value)
+			}
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case bool:
+		n.kind = nodeBool
+		n.boolean = t
+	case json.Number:
+		n.kind = nodeNumber
+		n.text = string(t)
+	case string:
+		n.kind = nodeString
+		n.text = t
+	case nil:
+		n.kind = nodeNull
+	}
+	return n, nil
+}
+
+// get return the value of the key of an object, nil if it is missing
+func (n *node) get(key string) *node {
+	for i := 0; i < len(n.keys); i++ {
+		if n.keys[i] == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// position return the line and column, starting at 1, of an offset
+func position(data []byte, offset int) (int, int) {
+	offset = max(min(offset, len(data)), 0)
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// offsetError is an error of the JSON document at an offset
+type offsetError struct {
+	offset int
+	msg    string
+}
+
+func (e *offsetError) Error() string {
+	return e.msg
+}
+
+// syntaxError convert an error of the JSON parser into a located Error
+func syntaxError(path string, data []byte, err error) error {
+	var oe *offsetError
+	var se *json.SyntaxError
+	switch {
+	case errors.As(err, &oe):
+		line, column := position(data, oe.offset)
+		return &Error{Path: path, Line: line, Column: column, Msg: oe.msg}
+	case errors.As(err, &se):
+		// the offset is after the invalid character
+		line, column := position(data, int(se.Offset)-1)
+		return &Error{Path: path, Line: line, Column: column, Msg: se.Error()}
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		line, column := position(data, len(data))
+		return &Error{Path: path, Line: line, Column: column, Msg: "unexpected end of file"}
+	}
+	return err
+}
+
+// number return the value of a number node
+func (n *node) number() (float64, error) {
+	return strconv.ParseFloat(n.text, 64)
+}
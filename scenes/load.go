@@ -0,0 +1,770 @@
+package scenes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// default values of the scene files
+const (
+	DefaultFileWidth   = 400
+	DefaultFileHeight  = 400
+	DefaultFileSamples = 100
+)
+
+// LoadFile read the scene file at path, a JSON scene file (see Load) or a
+// glTF file (see LoadGLTF)
+func LoadFile(path string) (*File, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".gltf" || ext == ".glb" {
+		return LoadGLTF(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, path)
+}
+
+// Load read a JSON scene file, the format being described in
+// docs/scene-format.md. The errors are *Error located in the file, path being
+// the name of the file in their messages.
+func Load(r io.Reader, path string) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseJSON(data)
+	if err != nil {
+		return nil, syntaxError(path, data, err)
+	}
+	l := &loader{
+		path: path,
+		data: data,
+	}
+	f := l.file(root)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return f, nil
+}
+
+// loader builds a scene from the nodes of a file, keeping the first error
+type loader struct {
+	path string
+	data []byte
+	err  error
+	// named textures and materials of the file
+	textures  map[string]geom.Texture
+	materials map[string]geom.Material
+	// shutter times of the camera, for the bounding volume hierarchies
+	time0 float64
+	time1 float64
+}
+
+// failAt record an error at an offset of the file, if there is none yet
+func (l *loader) failAt(offset int, format string, args ...interface{}) {
+	if l.err == nil {
+		line, column := position(l.data, offset)
+		l.err = &Error{Path: l.path, Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// fail record an error at a node
+func (l *loader) fail(n *node, format string, args ...interface{}) {
+	l.failAt(n.offset, format, args...)
+}
+
+// object is an object node whose keys are read one by one, so that the keys
+// that are never read can be reported as unknown
+type object struct {
+	l    *loader
+	n    *node
+	read map[string]bool
+}
+
+// object return the reader of an object node, what describing the node in
+// the error messages
+func (l *loader) object(n *node, what string) *object {
+	if n.kind != nodeObject {
+		l.fail(n, "%v must be an object, not %v", what, n.kind)
+	}
+	return &object{l: l, n: n, read: map[string]bool{}}
+}
+
+// get return the value of a key, nil if it is missing
+func (o *object) get(key string) *node {
+	o.read[key] = true
+	return o.n.get(key)
+}
+
+// required return the value of a key, reporting it if it is missing
+func (o *object) required(key string) *node {
+	v := o.get(key)
+	if v == nil {
+		o.l.fail(o.n, "missing %q", key)
+		return &node{offset: o.n.offset}
+	}
+	return v
+}
+
+// at return the value of a key to locate an error, the object itself if the
+// key is missing
+func (o *object) at(key string) *node {
+	if v := o.n.get(key); v != nil {
+		return v
+	}
+	return o.n
+}
+
+// close report the first key that was not read
+func (o *object) close() {
+	for i := 0; i < len(o.n.keys); i++ {
+		if !o.read[o.n.keys[i]] {
+			o.l.failAt(o.n.keyOffsets[i], "unknown key %q", o.n.keys[i])
+		}
+	}
+}
+
+func (o *object) float(key string) float64 {
+	return o.l.float(o.required(key))
+}
+
+func (o *object) floatOr(key string, def float64) float64 {
+	if v := o.get(key); v != nil {
+		return o.l.float(v)
+	}
+	return def
+}
+
+func (o *object) intOr(key string, def int) int {
+	if v := o.get(key); v != nil {
+		return o.l.integer(v)
+	}
+	return def
+}
+
+func (o *object) vec(key string) geom.Vec3 {
+	return o.l.vec(o.required(key))
+}
+
+func (o *object) vecOr(key string, def geom.Vec3) geom.Vec3 {
+	if v := o.get(key); v != nil {
+		return o.l.vec(v)
+	}
+	return def
+}
+
+func (o *object) str(key string) string {
+	return o.l.str(o.required(key))
+}
+
+func (o *object) strOr(key string, def string) string {
+	if v := o.get(key); v != nil {
+		return o.l.str(v)
+	}
+	return def
+}
+
+func (o *object) boolOr(key string, def bool) bool {
+	if v := o.get(key); v != nil {
+		return o.l.boolean(v)
+	}
+	return def
+}
+
+func (l *loader) float(n *node) float64 {
+	if n.kind != nodeNumber {
+		l.fail(n, "expected a number, not %v", n.kind)
+		return 0
+	}
+	v, err := n.number()
+	if err != nil {
+		l.fail(n, "invalid number %v", n.text)
+	}
+	return v
+}
+
+func (l *loader) integer(n *node) int {
+	if n.kind != nodeNumber {
+		l.fail(n, "expected an integer, not %v", n.kind)
+		return 0
+	}
+	v, err := strconv.Atoi(n.text)
+	if err != nil {
+		l.fail(n, "invalid integer %v", n.text)
+	}
+	return v
+}
+
+func (l *loader) str(n *node) string {
+	if n.kind != nodeString {
+		l.fail(n, "expected a string, not %v", n.kind)
+	}
+	return n.text
+}
+
+func (l *loader) boolean(n *node) bool {
+	if n.kind != nodeBool {
+		l.fail(n, "expected a boolean, not %v", n.kind)
+	}
+	return n.boolean
+}
+
+// vec read an array of three numbers
+func (l *loader) vec(n *node) geom.Vec3 {
+	if n.kind != nodeArray || len(n.values) != 3 {
+		l.fail(n, "expected an array of three numbers")
+		return geom.Vec3{}
+	}
+	return geom.NewVec3(l.float(n.values[0]), l.float(n.values[1]), l.float(n.values[2]))
+}
+
+// vecs read an array of vectors
+func (l *loader) vecs(n *node, what string) []geom.Vec3 {
+	items := l.array(n, what)
+	v := make([]geom.Vec3, len(items))
+	for i := 0; i < len(items) && l.err == nil; i++ {
+		v[i] = l.vec(items[i])
+	}
+	return v
+}
+
+// uvs read an array of texture coordinates [u, v]
+func (l *loader) uvs(n *node) []geom.Vec3 {
+	items := l.array(n, "uvs")
+	uv := make([]geom.Vec3, len(items))
+	for i := 0; i < len(items) && l.err == nil; i++ {
+		if items[i].kind != nodeArray || len(items[i].values) != 2 {
+			l.fail(items[i], "expected an array of two numbers")
+			break
+		}
+		uv[i] = geom.NewVec3(l.float(items[i].values[0]), l.float(items[i].values[1]), 0)
+	}
+	return uv
+}
+
+// integers read an array of integers
+func (l *loader) integers(n *node, what string) []int {
+	items := l.array(n, what)
+	v := make([]int, len(items))
+	for i := 0; i < len(items) && l.err == nil; i++ {
+		v[i] = l.integer(items[i])
+	}
+	return v
+}
+
+// array return the items of an array node
+func (l *loader) array(n *node, what string) []*node {
+	if n.kind != nodeArray {
+		l.fail(n, "%v must be an array, not %v", what, n.kind)
+	}
+	return n.values
+}
+
+// file read the root object of a scene file
+func (l *loader) file(root *node) *File {
+	f := &File{
+		Textures:  map[string]geom.Texture{},
+		Materials: map[string]geom.Material{},
+	}
+	l.textures = f.Textures
+	l.materials = f.Materials
+	o := l.object(root, "the scene")
+	f.Description = o.strOr("description", "")
+	if v := o.get("settings"); v != nil {
+		f.Settings = l.settings(v)
+	} else {
+		f.Settings = render.Settings{Width: DefaultFileWidth, Height: DefaultFileHeight, Samples: DefaultFileSamples}
+	}
+	f.Camera = l.camera(o.required("camera"))
+	l.time0 = f.Camera.Time0
+	l.time1 = f.Camera.Time1
+	// the named textures and materials can use the ones defined before them
+	if v := o.get("textures"); v != nil {
+		defs := l.object(v, "textures")
+		for i := 0; i < len(v.keys) && l.err == nil; i++ {
+			defs.read[v.keys[i]] = true
+			l.textures[v.keys[i]] = l.texture(v.values[i])
+		}
+	}
+	if v := o.get("materials"); v != nil {
+		defs := l.object(v, "materials")
+		for i := 0; i < len(v.keys) && l.err == nil; i++ {
+			defs.read[v.keys[i]] = true
+			l.materials[v.keys[i]] = l.material(v.values[i])
+		}
+	}
+	f.Objects = l.hitables(o.required("objects"), "objects", false)
+	f.Lights = l.hitables(o.required("lights"), "lights", true)
+	o.close()
+	return f
+}
+
+// settings read the render settings
+func (l *loader) settings(n *node) render.Settings {
+	o := l.object(n, "settings")
+	s := render.Settings{
+		Width:         o.intOr("width", DefaultFileWidth),
+		Height:        o.intOr("height", DefaultFileHeight),
+		Samples:       o.intOr("samples", DefaultFileSamples),
+		MaxDepth:      o.intOr("maxDepth", 0),
+		RouletteDepth: o.intOr("rouletteDepth", 0),
+		TileSize:      o.intOr("tileSize", 0),
+	}
+	if v := o.get("seed"); v != nil {
+		seed, err := strconv.ParseUint(v.text, 10, 64)
+		if v.kind != nodeNumber || err != nil {
+			l.fail(v, "the seed must be a positive integer")
+		}
+		s.Seed = seed
+	}
+	if v := o.get("filter"); v != nil {
+		s.Filter = l.filter(v)
+	}
+	o.close()
+	if err := s.Validate(); err != nil {
+		l.fail(n, "%v", err)
+	}
+	return s
+}
+
+// filter read a filter, its name or an object with its type and radius
+func (l *loader) filter(n *node) render.Filter {
+	name := ""
+	radius := 0.0
+	if n.kind == nodeString {
+		name = n.text
+	} else {
+		o := l.object(n, "the filter")
+		name = o.str("type")
+		radius = o.floatOr("radius", 0)
+		o.close()
+	}
+	f, err := render.ParseFilter(name, radius)
+	if err != nil {
+		l.fail(n, "%v", err)
+	}
+	return f
+}
+
+// camera read the parameters of the camera
+func (l *loader) camera(n *node) CameraSpec {
+	o := l.object(n, "the camera")
+	c := CameraSpec{
+		LookFrom: o.vec("lookFrom"),
+		LookAt:   o.vec("lookAt"),
+		Vup:      o.vecOr("vup", geom.NewVec3(0, 1, 0)),
+		Vfov:     o.float("vfov"),
+		Aspect:   o.floatOr("aspect", 0),
+		Aperture: o.floatOr("aperture", 0),
+		Time0:    o.floatOr("time0", 0),
+		Time1:    o.floatOr("time1", 1),
+	}
+	c.FocusDist = o.floatOr("focusDist", c.LookAt.Minus(c.LookFrom).Length())
+	o.close()
+	switch {
+	case l.err != nil:
+	case c.LookAt.Minus(c.LookFrom).SquaredLength() == 0:
+		l.fail(n, "the camera looks at its own position")
+	case geom.Cross(c.Vup, c.LookAt.Minus(c.LookFrom)).SquaredLength() == 0:
+		l.fail(n, "vup is along the direction of the camera")
+	case c.Vfov <= 0 || c.Vfov >= 180:
+		l.fail(o.at("vfov"), "the field of view must be between 0 and 180 degrees")
+	case c.Aspect < 0 || c.Aperture < 0 || c.FocusDist <= 0:
+		l.fail(n, "the aspect, aperture and focus distance can not be negative")
+	}
+	return c
+}
+
+// texture read a texture : the name of a texture, a color [r, g, b] or an
+// object with its type
+func (l *loader) texture(n *node) geom.Texture {
+	switch n.kind {
+	case nodeString:
+		t, ok := l.textures[n.text]
+		if !ok {
+			l.fail(n, "unknown texture %q", n.text)
+		}
+		return t
+	case nodeArray:
+		return geom.NewConstantTexture(l.vec(n))
+	}
+	o := l.object(n, "a texture")
+	var t geom.Texture
+	switch typ := o.str("type"); typ {
+	case "constant":
+		t = geom.NewConstantTexture(o.vec("color"))
+	case "checker":
+		even := l.texture(o.required("even"))
+		odd := l.texture(o.required("odd"))
+		t = geom.NewCheckerTexture(even, odd)
+	case "noise":
+		scale := o.float("scale")
+		seed := o.intOr("seed", 0)
+		if seed < 0 {
+			l.fail(o.at("seed"), "the seed must be a positive integer")
+		}
+		t = geom.NewNoiseTexture(scale, uint64(seed))
+	default:
+		l.fail(o.at("type"), "unknown texture type %q", typ)
+	}
+	o.close()
+	return t
+}
+
+// material read a material : the name of a material or an object with its
+// type
+func (l *loader) material(n *node) geom.Material {
+	if n.kind == nodeString {
+		m, ok := l.materials[n.text]
+		if !ok {
+			l.fail(n, "unknown material %q", n.text)
+		}
+		return m
+	}
+	o := l.object(n, "a material")
+	var m geom.Material
+	switch typ := o.str("type"); typ {
+	case "lambertian":
+		m = geom.Lambertian{Albedo: l.texture(o.required("albedo"))}
+	case "metal":
+		m = geom.Metal{Albedo: o.vec("albedo"), Fuzz: o.floatOr("fuzz", 0)}
+	case "dielectric":
+		m = geom.Dielectric{RefIdx: o.float("refIdx")}
+	case "diffuseLight":
+		m = geom.DiffuseLight{Emit: l.texture(o.required("emit"))}
+	case "none":
+		m = geom.NewNoMaterial()
+	default:
+		l.fail(o.at("type"), "unknown material type %q", typ)
+	}
+	o.close()
+	return m
+}
+
+// triangleMesh read a mesh of triangles given by the indices of their
+// vertices, of a single material or of the materials given by
+// materialIndices
+func (l *loader) triangleMesh(o *object, material func() geom.Material) geom.Hitable {
+	positions := l.vecs(o.required("positions"), "positions")
+	var normals, uvs []geom.Vec3
+	if v := o.get("normals"); v != nil {
+		normals = l.vecs(v, "normals")
+	}
+	if v := o.get("uvs"); v != nil {
+		uvs = l.uvs(v)
+	}
+	var indices []int
+	triangles := l.array(o.required("triangles"), "triangles")
+	for i := 0; i < len(triangles) && l.err == nil; i++ {
+		tri := l.integers(triangles[i], "a triangle")
+		if len(tri) != 3 {
+			l.fail(triangles[i], "a triangle must be an array of three indices")
+		}
+		for j := 0; j < len(tri); j++ {
+			if tri[j] < 0 || tri[j] >= len(positions) {
+				l.fail(triangles[i].values[j], "index %v out of range, %v positions", tri[j], len(positions))
+			}
+		}
+		indices = append(indices, tri...)
+	}
+	var materials []geom.Material
+	var materialIndices []int
+	if v := o.get("materials"); v != nil {
+		items := l.array(v, "materials")
+		for i := 0; i < len(items) && l.err == nil; i++ {
+			materials = append(materials, l.material(items[i]))
+		}
+		materialIndices = l.integers(o.required("materialIndices"), "materialIndices")
+		for i := 0; i < len(materialIndices) && l.err == nil; i++ {
+			if materialIndices[i] < 0 || materialIndices[i] >= len(materials) {
+				l.fail(o.at("materialIndices"), "material index %v out of range, %v materials", materialIndices[i], len(materials))
+			}
+		}
+	} else {
+		materials = []geom.Material{material()}
+	}
+	switch {
+	case l.err != nil:
+		return nil
+	case normals != nil && len(normals) != len(positions):
+		l.fail(o.at("normals"), "there must be as many normals as positions")
+	case uvs != nil && len(uvs) != len(positions):
+		l.fail(o.at("uvs"), "there must be as many uvs as positions")
+	case materialIndices != nil && len(materialIndices) != len(triangles):
+		l.fail(o.at("materialIndices"), "there must be a material index for each triangle")
+	case !hasArea(positions, indices):
+		l.fail(o.at("triangles"), "the mesh has no triangle")
+	}
+	if l.err != nil {
+		return nil
+	}
+	mesh := geom.NewTriangleMesh(positions, normals, uvs, indices, materials[0])
+	if materialIndices != nil {
+		mesh.SetMaterials(materials, materialIndices)
+	}
+	return mesh
+}
+
+// hasArea return true if one of the triangles is not degenerate
+func hasArea(positions []geom.Vec3, indices []int) bool {
+	for i := 0; i+2 < len(indices); i += 3 {
+		p0 := positions[indices[i]]
+		if geom.Cross(positions[indices[i+1]].Minus(p0), positions[indices[i+2]].Minus(p0)).SquaredLength() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mesh read a mesh file, named relatively to the scene file
+func (l *loader) mesh(o *object) geom.Hitable {
+	file := o.str("file")
+	var opts MeshOptions
+	if v := o.get("groups"); v != nil {
+		items := l.array(v, "groups")
+		for i := 0; i < len(items); i++ {
+			opts.Groups = append(opts.Groups, l.str(items[i]))
+		}
+	}
+	if v := o.get("material"); v != nil {
+		opts.Mat = l.material(v)
+	}
+	opts.Points = o.floatOr("points", 0)
+	opts.Scale = o.floatOr("scale", 1)
+	opts.Emissive = o.boolOr("emissive", false)
+	if o.get("floor") != nil {
+		opts.OnFloor = true
+		opts.Floor = o.float("floor")
+	}
+	switch {
+	case l.err != nil:
+		return nil
+	case opts.Scale <= 0:
+		l.fail(o.at("scale"), "the scale of a mesh must be positive")
+		return nil
+	case opts.Points < 0:
+		l.fail(o.at("points"), "the radius of the points can not be negative")
+		return nil
+	case opts.Points > 0 && len(opts.Groups) > 0:
+		l.fail(o.at("groups"), "the points of a mesh can not be chosen by group")
+		return nil
+	case opts.Points > 0 && opts.Emissive:
+		l.fail(o.at("emissive"), "the points of a mesh can not be emissive")
+		return nil
+	}
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(l.path), path)
+	}
+	m, err := NewMesh(path, opts)
+	if err != nil {
+		l.fail(o.at("file"), "%v", err)
+		return nil
+	}
+	m.File = file
+	return m
+}
+
+// transforms read an array of transforms, composed in their order
+func (l *loader) transforms(n *node) geom.Matrix {
+	items := l.array(n, "transforms")
+	m := geom.IdentityMatrix()
+	for i := 0; i < len(items) && l.err == nil; i++ {
+		m = l.transform(items[i]).Times(m)
+	}
+	return m
+}
+
+// transform read a transform : a translation, a rotation, a scaling, a
+// look-at or an affine matrix
+func (l *loader) transform(n *node) geom.Matrix {
+	o := l.object(n, "a transform")
+	m := geom.IdentityMatrix()
+	switch typ := o.str("type"); typ {
+	case "translate":
+		m = geom.Translation(o.vec("offset"))
+	case "rotate":
+		axis := o.vec("axis")
+		if axis.SquaredLength() == 0 && l.err == nil {
+			l.fail(o.at("axis"), "the axis of a rotation can not be null")
+		}
+		m = geom.Rotation(axis, o.float("angle"))
+	case "scale":
+		if v := o.get("factor"); v != nil {
+			f := l.float(v)
+			m = geom.Scaling(geom.NewVec3(f, f, f))
+		} else {
+			m = geom.Scaling(o.vec("factors"))
+		}
+	case "lookAt":
+		from := o.vec("from")
+		to := o.vec("to")
+		up := o.vecOr("up", geom.NewVec3(0, 1, 0))
+		if l.err == nil && geom.Cross(up, to.Minus(from)).SquaredLength() == 0 {
+			l.fail(n, "from and to can not be the same, nor aligned with up")
+		}
+		m = geom.LookAt(from, to, up)
+	case "matrix":
+		v := o.required("rows")
+		rows := l.array(v, "rows")
+		if len(rows) != 4 && l.err == nil {
+			l.fail(v, "a matrix needs 4 rows")
+		}
+		for i := 0; i < len(rows) && i < 4 && l.err == nil; i++ {
+			row := l.array(rows[i], "a row")
+			if len(row) != 4 {
+				l.fail(rows[i], "a row needs 4 numbers")
+			}
+			for j := 0; j < len(row) && j < 4 && l.err == nil; j++ {
+				m[i][j] = l.float(row[j])
+			}
+		}
+		if m[3] != [4]float64{0, 0, 0, 1} && l.err == nil {
+			l.fail(v, "the last row of the matrix must be [0, 0, 0, 1]")
+		}
+	default:
+		l.fail(o.at("type"), "unknown transform type %q", typ)
+	}
+	o.close()
+	return m
+}
+
+// hitables read an array of objects, which can not be empty
+func (l *loader) hitables(n *node, what string, lights bool) []geom.Hitable {
+	items := l.array(n, what)
+	if len(items) == 0 && l.err == nil {
+		l.fail(n, "%v can not be empty", what)
+	}
+	list := make([]geom.Hitable, 0, len(items))
+	for i := 0; i < len(items) && l.err == nil; i++ {
+		list = append(list, l.hitable(items[i], lights))
+	}
+	return list
+}
+
+// hitable read an object of the scene, lights being true for the shapes
+// sampled toward, whose material is optional
+func (l *loader) hitable(n *node, lights bool) geom.Hitable {
+	o := l.object(n, "an object")
+	typ := o.str("type")
+	material := func() geom.Material {
+		if lights && o.get("material") == nil {
+			return geom.NewNoMaterial()
+		}
+		return l.material(o.required("material"))
+	}
+	// child read the object transformed by a translation, rotation or flip
+	child := func() geom.Hitable {
+		return l.hitable(o.required("object"), lights)
+	}
+	var h geom.Hitable
+	switch typ {
+	case "sphere":
+		h = geom.NewSphere(o.vec("center"), o.float("radius"), material())
+	case "movingSphere":
+		time0, time1 := o.float("time0"), o.float("time1")
+		// the center is interpolated between the two times
+		if time0 == time1 {
+			l.fail(o.at("time1"), "time1 must differ from time0")
+		}
+		h = geom.NewMovingSphere(o.vec("center0"), o.vec("center1"), o.float("radius"), time0, time1, material())
+	case "xyRect":
+		h = geom.NewXYRect(o.float("x0"), o.float("x1"), o.float("y0"), o.float("y1"), o.float("k"), material())
+	case "xzRect":
+		h = geom.NewXZRect(o.float("x0"), o.float("x1"), o.float("z0"), o.float("z1"), o.float("k"), material())
+	case "yzRect":
+		h = geom.NewYZRect(o.float("y0"), o.float("y1"), o.float("z0"), o.float("z1"), o.float("k"), material())
+	case "box":
+		h = geom.NewBox(o.vec("min"), o.vec("max"), material())
+	case "triangle":
+		tri := geom.NewTriangle(o.vec("p0"), o.vec("p1"), o.vec("p2"), material())
+		if v := o.get("normals"); v != nil {
+			if n := l.vecs(v, "normals"); len(n) != 3 {
+				l.fail(v, "normals must be an array of three vectors")
+			} else {
+				tri.SetNormals(n[0], n[1], n[2])
+			}
+		}
+		if v := o.get("uvs"); v != nil {
+			if uv := l.uvs(v); len(uv) != 3 {
+				l.fail(v, "uvs must be an array of three [u, v] arrays")
+			} else {
+				tri.SetUVs(uv[0], uv[1], uv[2])
+			}
+		}
+		h = tri
+	case "triangleMesh":
+		h = l.triangleMesh(o, material)
+	case "mesh":
+		h = l.mesh(o)
+	case "translate":
+		offset := o.vec("offset")
+		if c := child(); l.err == nil {
+			h = geom.NewTranslate(c, offset)
+		}
+	case "rotateY":
+		angle := o.float("angle")
+		if c := child(); l.err == nil {
+			h = geom.NewRotateY(c, angle)
+		}
+	case "transform":
+		m := l.transforms(o.required("transforms"))
+		if _, ok := m.Inverse(); !ok && l.err == nil {
+			l.fail(o.at("transforms"), "the transform is not invertible")
+		}
+		if c := child(); l.err == nil {
+			h = geom.NewTransform(c, m)
+		}
+	case "flip":
+		if c := child(); l.err == nil {
+			h = geom.NewFlipNormals(c)
+		}
+	case "list", "bvh":
+		items := l.hitables(o.required("objects"), "objects", lights)
+		if l.err != nil {
+			break
+		}
+		list := geom.NewHitableList(&items, len(items))
+		if typ == "list" {
+			h = list
+			break
+		}
+		var opts geom.BVHOptions
+		switch strategy := o.strOr("strategy", "sah"); strings.ToLower(strategy) {
+		case "sah":
+			opts.Strategy = geom.BVHSAH
+		case "median":
+			opts.Strategy = geom.BVHMedian
+		default:
+			l.fail(o.at("strategy"), "unknown BVH strategy %q", strategy)
+		}
+		opts.LeafSize = o.intOr("leafSize", 0)
+		if l.err == nil {
+			h = geom.NewLinearBVH(list, l.time0, l.time1, opts)
+		}
+	default:
+		l.fail(o.at("type"), "unknown object type %q", typ)
+	}
+	objectID := o.intOr("objectID", 0)
+	materialID := o.intOr("materialID", 0)
+	if objectID < 0 || materialID < 0 {
+		l.fail(n, "the IDs can not be negative")
+	}
+	o.close()
+	if l.err != nil {
+		return nil
+	}
+	if objectID != 0 || materialID != 0 {
+		h = geom.NewTagged(h, objectID, materialID)
+	}
+	return h
+}
@@ -0,0 +1,88 @@
+package scenes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// testScene is a scene whose objects start on line 6
+const testScene = `{
+  "camera": {"lookFrom": [0, 1, -5], "lookAt": [0, 1, 0], "vfov": 40},
+  "materials": {"white": {"type": "lambertian", "albedo": [0.7, 0.7, 0.7]}},
+  "lights": [{"type": "sphere", "center": [0, 5, 0], "radius": 1}],
+  "objects": [
+    OBJECT
+  ]
+}`
+
+// testSphere is an object of the test scenes
+const testSphere = `{"type": "sphere", "center": [0, 0, 0], "radius": 1, "material": "white"}`
+
+func loadTestScene(object string) (*File, error) {
+	return Load(strings.NewReader(strings.Replace(testScene, "OBJECT", object, 1)), "test.json")
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		object string
+		line   int
+		msg    string
+	}{
+		{"malformed", `{"type": "sphere", "center": [0, 0, 0] "radius": 1}`, 6, "invalid character"},
+		{"unknown type", `{"type": "cube"}`, 6, `unknown object type "cube"`},
+		{"missing radius", `{"type": "sphere", "center": [0, 0, 0], "material": "white"}`, 6, `"radius"`},
+		{"unknown material", `{"type": "sphere", "center": [0, 0, 0], "radius": 1,
+      "material": "red"}`, 7, `unknown material "red"`},
+		{"still moving sphere", `{"type": "movingSphere", "center0": [0, 0, 0], "center1": [1, 0, 0], "radius": 1,
+      "time0": 0.5, "time1": 0.5, "material": "white"}`, 7, "time1 must differ from time0"},
+		{"missing transforms", `{"type": "transform",
+      "object": ` + testSphere + `}`, 6, `"transforms"`},
+		{"last row", `{"type": "transform", "object": ` + testSphere + `,
+      "transforms": [{"type": "matrix",
+        "rows": [[1, 0, 0, 0], [0, 1, 0, 0], [0, 0, 1, 0], [0, 0, 1, 1]]}]}`, 8, "the last row of the matrix"},
+		{"singular", `{"type": "transform", "object": ` + testSphere + `,
+      "transforms": [{"type": "scale", "factors": [1, 0, 1]}]}`, 7, "not invertible"},
+	}
+	for _, test := range tests {
+		_, err := loadTestScene(test.object)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%v : %v is not a located error", test.name, err)
+			continue
+		}
+		if e.Path != "test.json" || e.Line != test.line || !strings.Contains(e.Msg, test.msg) {
+			t.Errorf("%v : %q instead of line %v %q", test.name, err, test.line, test.msg)
+		}
+	}
+}
+
+func TestLoadTransform(t *testing.T) {
+	f, err := loadTestScene(`{"type": "transform", "object": ` + testSphere + `,
+      "transforms": [{"type": "scale", "factor": 2}, {"type": "translate", "offset": [1, 0, 0]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sphere is scaled, then moved
+	var box geom.Aabb
+	f.Objects[0].BoundingBox(0, 1, &box)
+	if box.Min() != geom.NewVec3(-1, -2, -2) || box.Max() != geom.NewVec3(3, 2, 2) {
+		t.Errorf("box %v %v instead of [-1 -2 -2] [3 2 2]", box.Min(), box.Max())
+	}
+}
+
+func TestLoadCornell(t *testing.T) {
+	f, err := LoadFile("data/cornell.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Objects) != 8 || len(f.Lights) != 2 {
+		t.Errorf("%v objects and %v lights instead of 8 and 2", len(f.Objects), len(f.Lights))
+	}
+	if f.Settings.Width != 400 || f.Settings.Samples != 100 {
+		t.Errorf("settings %+v", f.Settings)
+	}
+}
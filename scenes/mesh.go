@@ -0,0 +1,155 @@
+package scenes
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/mesh"
+)
+
+// Mesh is an object read from a mesh file, which is written back in the scene
+// files as a reference to the mesh file
+type Mesh struct {
+	Ptr geom.Hitable
+	// File is the path of the mesh file, relative to the scene file when it
+	// is written
+	File    string
+	Options MeshOptions
+}
+
+// MeshOptions are the options of NewMesh
+type MeshOptions struct {
+	// Groups are the groups of the mesh in the scene, all of them if empty
+	Groups []string
+	// Mat replaces the materials of the mesh file when it is not nil
+	Mat geom.Material
+	// Points is the radius of the spheres drawn at the vertices instead of
+	// the triangles, for the point clouds, 0 for the triangles
+	Points float64
+	// Scale multiplies the positions of the mesh file, to change its units,
+	// 0 for 1
+	Scale float64
+	// OnFloor places the bottom of the bounding box of the mesh at the height
+	// Floor
+	OnFloor bool
+	Floor   float64
+	// Emissive keeps only the triangles of diffuse light, to sample the
+	// lights of a mesh
+	Emissive bool
+}
+
+// NewMesh read the mesh file at path, a Wavefront OBJ, PLY, STL or glTF file
+func NewMesh(path string, opts MeshOptions) (*Mesh, error) {
+	model, err := loadModel(path)
+	if err != nil {
+		return nil, err
+	}
+	return newMesh(path, model, opts)
+}
+
+// loadModel read the mesh file at path with the reader of its extension
+func loadModel(path string) (*mesh.Model, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".obj":
+		return mesh.LoadOBJ(path)
+	case ".ply":
+		return mesh.LoadPLY(path)
+	case ".stl":
+		return mesh.LoadSTL(path)
+	case ".gltf", ".glb":
+		g, err := mesh.LoadGLTF(path)
+		if err != nil {
+			return nil, err
+		}
+		return g.Model, nil
+	default:
+		return nil, fmt.Errorf("%v : unknown mesh format %q", path, ext)
+	}
+}
+
+// newMesh return the mesh of a model read from path, which is modified by
+// the scale, the floor and the material of the options
+func newMesh(path string, model *mesh.Model, opts MeshOptions) (*Mesh, error) {
+	if opts.Scale != 0 {
+		model.Scale(opts.Scale)
+	}
+	// the vertices are moved before the mesh is built, the emissive
+	// triangles being placed as the whole mesh
+	if y := bottom(model, opts); opts.OnFloor && !math.IsInf(y, 0) {
+		model.Translate(geom.NewVec3(0, opts.Floor-y, 0))
+	}
+	if opts.Mat != nil {
+		model.SetMaterial(opts.Mat)
+	}
+	if opts.Points == 0 && len(model.Groups) == 0 {
+		return nil, fmt.Errorf("%v : the mesh has no face, its vertices can be drawn as points", path)
+	}
+	var h geom.Hitable
+	var err error
+	if opts.Points > 0 {
+		h, err = model.Points(opts.Points)
+	} else {
+		h, err = model.Hitable(opts.Groups...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v : %v", path, err)
+	}
+	if opts.Emissive {
+		h, err = model.Emissive().Hitable(opts.Groups...)
+		if err != nil {
+			return nil, fmt.Errorf("%v : the mesh has no emissive triangle", path)
+		}
+	}
+	return &Mesh{
+		Ptr:     h,
+		File:    path,
+		Options: opts,
+	}, nil
+}
+
+// bottom return the lowest height of the mesh of the options : of the
+// vertices of its groups, or of the spheres of its points. It is infinite
+// when the mesh is empty.
+func bottom(model *mesh.Model, opts MeshOptions) float64 {
+	y := math.Inf(1)
+	if opts.Points > 0 {
+		for i := 0; i < len(model.Positions); i++ {
+			y = math.Min(y, model.Positions[i].Y()-opts.Points)
+		}
+		return y
+	}
+	groups := model.Groups
+	if len(opts.Groups) > 0 {
+		groups = nil
+		for i := 0; i < len(opts.Groups); i++ {
+			if g := model.Group(opts.Groups[i]); g != nil {
+				groups = append(groups, g)
+			}
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		for j := 0; j < len(groups[i].Indices); j++ {
+			y = math.Min(y, model.Positions[groups[i].Indices[j]].Y())
+		}
+	}
+	return y
+}
+
+func (m *Mesh) Hit(r geom.Ray, tMin, tMax float64, rec *geom.HitRecord) bool {
+	return m.Ptr.Hit(r, tMin, tMax, rec)
+}
+
+func (m *Mesh) BoundingBox(t0, t1 float64, box *geom.Aabb) bool {
+	return m.Ptr.BoundingBox(t0, t1, box)
+}
+
+func (m *Mesh) PdfValue(o, v geom.Vec3) float64 {
+	return m.Ptr.PdfValue(o, v)
+}
+
+func (m *Mesh) Random(o geom.Vec3, s *geom.Sampler) geom.Vec3 {
+	return m.Ptr.Random(o, s)
+}
@@ -0,0 +1,60 @@
+package scenes
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// testSTL is a mesh of two solids, a low triangle and a high one
+const testSTL = `solid low
+facet normal 0 1 0
+outer loop
+vertex 0 -3 0
+vertex 1 -2 0
+vertex 0 -2 1
+endloop
+endfacet
+endsolid low
+solid high
+facet normal 0 1 0
+outer loop
+vertex 0 2 0
+vertex 1 3 0
+vertex 0 3 1
+endloop
+endfacet
+endsolid high
+`
+
+func TestNewMeshOnFloor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.stl")
+	if err := os.WriteFile(path, []byte(testSTL), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		opts   MeshOptions
+		bottom float64
+		top    float64
+	}{
+		{"scaled", MeshOptions{Scale: 2, OnFloor: true, Floor: 1}, 1, 13},
+		{"group", MeshOptions{Groups: []string{"high"}, OnFloor: true, Floor: -1}, -1, 0},
+		{"points", MeshOptions{Points: 0.5, OnFloor: true}, 0, 7},
+		{"not on floor", MeshOptions{Floor: 1}, -3, 3},
+	}
+	for _, test := range tests {
+		m, err := NewMesh(path, test.opts)
+		if err != nil {
+			t.Fatalf("%v : %v", test.name, err)
+		}
+		var box geom.Aabb
+		m.BoundingBox(0, 1, &box)
+		if math.Abs(box.Min().Y()-test.bottom) > 1e-6 || math.Abs(box.Max().Y()-test.top) > 1e-6 {
+			t.Errorf("%v : from %v to %v instead of %v to %v", test.name, box.Min().Y(), box.Max().Y(), test.bottom, test.top)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package scenes
+
+import (
+	"sort"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+)
+
+// DefaultLeftWall and DefaultRightWall are the red and grey side walls of
+// the Cornell box
+var (
+	DefaultLeftWall  = geom.NewVec3(0.65, 0.05, 0.05)
+	DefaultRightWall = geom.NewVec3(0.45, 0.45, 0.45)
+)
+
+// CornellDescription is the description of the Cornell box
+const CornellDescription = "Cornell box with a glass ball and a white box, red and grey walls"
+
+// Entry is a built-in scene that can be selected by its name
+type Entry struct {
+	Name        string
+	Description string
+	Build       func(settings *render.Settings) *render.Scene
+}
+
+// registry holds the built-in scenes by name
+var registry = map[string]Entry{}
+
+// Register add a scene to the built-in scenes, replacing the one with the
+// same name
+func Register(e Entry) {
+	registry[e.Name] = e
+}
+
+// Lookup return the built-in scene of the given name
+func Lookup(name string) (Entry, bool) {
+	e, ok := registry[name]
+	return e, ok
+}
+
+// List return the built-in scenes sorted by name
+func List() []Entry {
+	entries := make([]Entry, 0, len(registry))
+	for _, e := range registry {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func init() {
+	Register(Entry{
+		Name:        "cornell",
+		Description: CornellDescription,
+		Build: func(settings *render.Settings) *render.Scene {
+			return CornellBox(settings, DefaultLeftWall, DefaultRightWall)
+		},
+	})
+}
@@ -0,0 +1,895 @@
+package scenes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+	"github.com/AureClai/RayTracingGoTest/render"
+	"github.com/AureClai/RayTracingGoTest/view"
+)
+
+// maxLineLength is the length under which a JSON value is written on a
+// single line
+const maxLineLength = 100
+
+// NewFile return the file of a scene built in memory, which can be written
+// with Write. The elements of the lists of objects and lights become the
+// objects and lights of the file, and the camera parameters are computed
+// back from the camera.
+func NewFile(scene *render.Scene) *File {
+	f := &File{
+		Camera:    cameraSpec(scene.Camera),
+		Textures:  map[string]geom.Texture{},
+		Materials: map[string]geom.Material{},
+		Objects:   elements(scene.Objects),
+		Lights:    elements(scene.Lights),
+	}
+	if scene.Settings != nil {
+		f.Settings = *scene.Settings
+		f.Settings.Workers = 0
+		f.Settings.AOVs = false
+		// the aspect follows the image unless it was different
+		if math.Abs(f.Camera.Aspect-float64(f.Settings.Width)/float64(f.Settings.Height)) < 1e-9 {
+			f.Camera.Aspect = 0
+		}
+	}
+	return f
+}
+
+// elements return the hitables of a list, or the hitable itself
+func elements(h geom.Hitable) []geom.Hitable {
+	if h == nil {
+		return nil
+	}
+	l, ok := h.(*geom.HitableList)
+	if !ok {
+		return []geom.Hitable{h}
+	}
+	list := make([]geom.Hitable, l.Len())
+	for i := 0; i < l.Len(); i++ {
+		list[i] = *l.GetAt(i)
+	}
+	return list
+}
+
+// cameraSpec return parameters of view.NewCamera giving the camera, up to
+// the rounding errors
+func cameraSpec(cam *view.Camera) CameraSpec {
+	// the center of the screen is at the focus distance in front of the camera
+	center := cam.LowerLeftCorner.Plus(cam.Horizontal.TimesScalar(0.5)).Plus(cam.Vertical.TimesScalar(0.5))
+	focusDist := center.Minus(cam.Origin).Length()
+	return CameraSpec{
+		LookFrom:  cam.Origin,
+		LookAt:    center,
+		Vup:       cam.V,
+		Vfov:      2 * math.Atan(cam.Vertical.Length()/2/focusDist) * 180 / math.Pi,
+		Aspect:    cam.Horizontal.Length() / cam.Vertical.Length(),
+		Aperture:  2 * cam.LensRadius,
+		FocusDist: focusDist,
+		Time0:     cam.Time0,
+		Time1:     cam.Time1,
+	}
+}
+
+// WriteFile write the file as JSON at path, see Write
+func WriteFile(path string, f *File) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(out, f); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Write write the file as JSON in the format read by Load. The textures and
+// materials of f.Textures and f.Materials keep their name, the other ones
+// used by several objects are named texture1, material1... and the types
+// unknown to the format are errors.
+func Write(w io.Writer, f *File) error {
+	e := &encoder{
+		textureNames:   map[geom.Texture]string{},
+		materialNames:  map[geom.Material]string{},
+		textureUses:    map[geom.Texture]int{},
+		materialUses:   map[geom.Material]int{},
+		textureByName:  map[string]geom.Texture{},
+		materialByName: map[string]geom.Material{},
+		visited:        map[interface{}]bool{},
+	}
+	e.name(f)
+	root := e.file(f)
+	if e.err != nil {
+		return e.err
+	}
+	var buf bytes.Buffer
+	writeNode(&buf, root, "")
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encoder converts a file into a tree of JSON nodes, keeping the first error
+type encoder struct {
+	err            error
+	textureNames   map[geom.Texture]string
+	materialNames  map[geom.Material]string
+	textureByName  map[string]geom.Texture
+	materialByName map[string]geom.Material
+	// number of references to the textures and materials, and the order
+	// in which they are first referenced
+	textureUses   map[geom.Texture]int
+	materialUses  map[geom.Material]int
+	textureOrder  []geom.Texture
+	materialOrder []geom.Material
+	// visited are the textures and materials whose references were counted
+	visited map[interface{}]bool
+	// where is the element of the file being counted, named in the errors
+	where string
+}
+
+func (e *encoder) fail(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	e.err = fmt.Errorf(format, args...)
+	if e.where != "" {
+		e.err = fmt.Errorf("%v : %v", e.where, e.err)
+	}
+}
+
+// name give their name to the textures and materials : the named ones of the
+// file, and the ones used several times
+func (e *encoder) name(f *File) {
+	textureNames := sortedKeys(f.Textures)
+	for i := 0; i < len(textureNames); i++ {
+		t := f.Textures[textureNames[i]]
+		e.where = fmt.Sprintf("texture %q", textureNames[i])
+		if e.countTexture(t, false) {
+			e.textureByName[textureNames[i]] = t
+			if _, ok := e.textureNames[t]; !ok {
+				e.textureNames[t] = textureNames[i]
+			}
+		}
+	}
+	materialNames := sortedKeys(f.Materials)
+	for i := 0; i < len(materialNames); i++ {
+		m := f.Materials[materialNames[i]]
+		e.where = fmt.Sprintf("material %q", materialNames[i])
+		if e.countMaterial(m, false) {
+			e.materialByName[materialNames[i]] = m
+			if _, ok := e.materialNames[m]; !ok {
+				e.materialNames[m] = materialNames[i]
+			}
+		}
+	}
+	for i := 0; i < len(f.Objects); i++ {
+		e.where = fmt.Sprintf("object %v", i+1)
+		e.countHitable(f.Objects[i], false)
+	}
+	for i := 0; i < len(f.Lights); i++ {
+		e.where = fmt.Sprintf("light %v", i+1)
+		e.countHitable(f.Lights[i], true)
+	}
+	e.where = ""
+	for i := 0; i < len(e.textureOrder); i++ {
+		t := e.textureOrder[i]
+		if _, ok := e.textureNames[t]; !ok && e.textureUses[t] > 1 {
+			name := freeName("texture", e.textureByName)
+			e.textureNames[t] = name
+			e.textureByName[name] = t
+		}
+	}
+	for i := 0; i < len(e.materialOrder); i++ {
+		m := e.materialOrder[i]
+		if _, ok := e.materialNames[m]; !ok && e.materialUses[m] > 1 {
+			name := freeName("material", e.materialByName)
+			e.materialNames[m] = name
+			e.materialByName[name] = m
+		}
+	}
+}
+
+// sortedKeys return the keys of a map in alphabetical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// freeName return the first name prefix1, prefix2... not in names
+func freeName[V any](prefix string, names map[string]V) string {
+	for i := 1; ; i++ {
+		name := prefix + strconv.Itoa(i)
+		if _, ok := names[name]; !ok {
+			return name
+		}
+	}
+}
+
+// unknownTexture return the texture of a type unknown to the format among t
+// and the textures it uses, nil if all are known and can be compared
+func unknownTexture(t geom.Texture) geom.Texture {
+	switch tex := t.(type) {
+	case *geom.ConstantTexture, geom.ConstantTexture, *geom.NoiseTexture, geom.NoiseTexture:
+		return nil
+	case *geom.CheckerTexture:
+		return unknownTexture(*tex)
+	case geom.CheckerTexture:
+		if u := unknownTexture(tex.Even); u != nil {
+			return u
+		}
+		return unknownTexture(tex.Odd)
+	}
+	return t
+}
+
+// failTexture record the error of a texture unknown to the format
+func (e *encoder) failTexture(t geom.Texture) {
+	if _, ok := t.(*geom.ImageTexture); ok {
+		// the images only come from the mesh files, which keep them
+		e.fail("can not write image textures, load the mesh from its file instead")
+		return
+	}
+	e.fail("can not write textures of type %T", t)
+}
+
+// countTexture count a reference to a texture, and the references to the
+// textures it uses the first time it is met. It return false for the types
+// unknown to the format.
+func (e *encoder) countTexture(t geom.Texture, use bool) bool {
+	if u := unknownTexture(t); u != nil {
+		e.failTexture(u)
+		return false
+	}
+	if use {
+		if e.textureUses[t] == 0 {
+			e.textureOrder = append(e.textureOrder, t)
+		}
+		e.textureUses[t]++
+	}
+	if e.visited[t] {
+		return true
+	}
+	e.visited[t] = true
+	switch tex := t.(type) {
+	case *geom.CheckerTexture:
+		e.countTexture(tex.Even, true)
+		e.countTexture(tex.Odd, true)
+	case geom.CheckerTexture:
+		e.countTexture(tex.Even, true)
+		e.countTexture(tex.Odd, true)
+	}
+	return true
+}
+
+// countMaterial count a reference to a material, and the references to its
+// texture the first time it is met
+func (e *encoder) countMaterial(m geom.Material, use bool) bool {
+	var tex geom.Texture
+	switch mat := m.(type) {
+	case geom.Lambertian:
+		tex = mat.Albedo
+	case *geom.Lambertian:
+		tex = mat.Albedo
+	case geom.DiffuseLight:
+		tex = mat.Emit
+	case *geom.DiffuseLight:
+		tex = mat.Emit
+	case geom.Metal, *geom.Metal, geom.Dielectric, *geom.Dielectric:
+	default:
+		if m == nil || !geom.IsNoMaterial(m) {
+			e.fail("can not write materials of type %T", m)
+			return false
+		}
+	}
+	if u := unknownTexture(tex); u != nil {
+		e.failTexture(u)
+		return false
+	}
+	if use {
+		if e.materialUses[m] == 0 {
+			e.materialOrder = append(e.materialOrder, m)
+		}
+		e.materialUses[m]++
+	}
+	if e.visited[m] {
+		return true
+	}
+	e.visited[m] = true
+	if tex != nil {
+		e.countTexture(tex, true)
+	}
+	return true
+}
+
+// countHitable count the materials used by a hitable, the shapes of the
+// lights not needing one
+func (e *encoder) countHitable(h geom.Hitable, lights bool) {
+	countMat := func(m geom.Material) {
+		if !(lights && geom.IsNoMaterial(m)) {
+			e.countMaterial(m, true)
+		}
+	}
+	switch obj := h.(type) {
+	case *geom.Sphere:
+		countMat(obj.Mat)
+	case *geom.MovingSphere:
+		countMat(obj.Mat)
+	case *geom.XYRect:
+		countMat(obj.Mat)
+	case *geom.XZRect:
+		countMat(obj.Mat)
+	case *geom.YZRect:
+		countMat(obj.Mat)
+	case *geom.Box:
+		countMat(obj.Mat)
+	case *geom.Triangle:
+		countMat(obj.Mat)
+	case *geom.TriangleMesh:
+		if obj.MaterialIndices == nil {
+			countMat(obj.Materials[0])
+			break
+		}
+		for i := 0; i < len(obj.Materials); i++ {
+			e.countMaterial(obj.Materials[i], true)
+		}
+	case *Mesh:
+		if obj.Options.Mat != nil {
+			countMat(obj.Options.Mat)
+		}
+	default:
+		children := e.children(h)
+		for i := 0; i < len(children); i++ {
+			e.countHitable(children[i], lights)
+		}
+	}
+}
+
+// children return the hitables inside the transformations and groups
+func (e *encoder) children(h geom.Hitable) []geom.Hitable {
+	switch obj := h.(type) {
+	case *geom.Translate:
+		return []geom.Hitable{obj.Ptr}
+	case *geom.RotateY:
+		return []geom.Hitable{obj.Ptr}
+	case *geom.Transform:
+		return []geom.Hitable{obj.Ptr}
+	case *geom.FlipNormals:
+		return []geom.Hitable{obj.Ptr}
+	case *geom.Tagged:
+		return []geom.Hitable{obj.Ptr}
+	case *geom.HitableList:
+		return elements(obj)
+	case *geom.LinearBVH:
+		return obj.Primitives()
+	case *geom.BVHNode:
+		return bvhLeaves(obj, nil)
+	}
+	e.fail("can not write objects of type %T", h)
+	return nil
+}
+
+// bvhLeaves append the objects of the leaves of a hierarchy to list
+func bvhLeaves(h geom.Hitable, list []geom.Hitable) []geom.Hitable {
+	switch node := h.(type) {
+	case *geom.BVHNode:
+		list = bvhLeaves(node.Left, list)
+		// the nodes of a single object have it on both sides
+		if node.Right != node.Left {
+			list = bvhLeaves(node.Right, list)
+		}
+		return list
+	case *geom.HitableList:
+		return append(list, elements(node)...)
+	}
+	return append(list, h)
+}
+
+// file return the root node of the file
+func (e *encoder) file(f *File) *node {
+	root := &node{kind: nodeObject}
+	if f.Description != "" {
+		root.set("description", stringNode(f.Description))
+	}
+	root.set("settings", e.settings(&f.Settings))
+	root.set("camera", e.camera(f.Camera))
+	if len(e.textureByName) > 0 {
+		root.set("textures", e.textureDefinitions())
+	}
+	if len(e.materialByName) > 0 {
+		defs := &node{kind: nodeObject}
+		names := sortedKeys(e.materialByName)
+		for i := 0; i < len(names); i++ {
+			defs.set(names[i], e.materialBody(e.materialByName[names[i]]))
+		}
+		root.set("materials", defs)
+	}
+	root.set("objects", e.hitables(f.Objects, false))
+	root.set("lights", e.hitables(f.Lights, true))
+	return root
+}
+
+func (e *encoder) settings(s *render.Settings) *node {
+	n := &node{kind: nodeObject}
+	n.set("width", intNode(s.Width))
+	n.set("height", intNode(s.Height))
+	n.set("samples", intNode(s.Samples))
+	if s.MaxDepth != 0 {
+		n.set("maxDepth", intNode(s.MaxDepth))
+	}
+	if s.RouletteDepth != 0 {
+		n.set("rouletteDepth", intNode(s.RouletteDepth))
+	}
+	if s.Seed != 0 {
+		n.set("seed", &node{kind: nodeNumber, text: strconv.FormatUint(s.Seed, 10)})
+	}
+	if s.TileSize != 0 {
+		n.set("tileSize", intNode(s.TileSize))
+	}
+	if s.Filter != nil {
+		n.set("filter", e.filter(s.Filter))
+	}
+	return n
+}
+
+// filter return the name of a filter, with its radius if it is not the
+// default one
+func (e *encoder) filter(f render.Filter) *node {
+	var name string
+	var radius float64
+	switch f.(type) {
+	case *render.BoxFilter, render.BoxFilter:
+		name, radius = "box", render.DefaultBoxRadius
+	case *render.TentFilter, render.TentFilter:
+		name, radius = "tent", render.DefaultTentRadius
+	case *render.GaussianFilter, render.GaussianFilter:
+		name, radius = "gaussian", render.DefaultGaussianRadius
+	case *render.MitchellFilter, render.MitchellFilter:
+		name, radius = "mitchell", render.DefaultMitchellRadius
+	case *render.LanczosFilter, render.LanczosFilter:
+		name, radius = "lanczos", render.DefaultLanczosRadius
+	default:
+		e.fail("can not write filters of type %T", f)
+		return nil
+	}
+	if f.Radius() == radius {
+		return stringNode(name)
+	}
+	n := &node{kind: nodeObject}
+	n.set("type", stringNode(name))
+	n.set("radius", e.number(f.Radius()))
+	return n
+}
+
+func (e *encoder) camera(c CameraSpec) *node {
+	n := &node{kind: nodeObject}
+	n.set("lookFrom", e.vec(c.LookFrom))
+	n.set("lookAt", e.vec(c.LookAt))
+	n.set("vup", e.vec(c.Vup))
+	n.set("vfov", e.number(c.Vfov))
+	if c.Aspect != 0 {
+		n.set("aspect", e.number(c.Aspect))
+	}
+	n.set("aperture", e.number(c.Aperture))
+	n.set("focusDist", e.number(c.FocusDist))
+	n.set("time0", e.number(c.Time0))
+	n.set("time1", e.number(c.Time1))
+	return n
+}
+
+// textureDefinitions return the named textures, each one after the textures
+// it uses
+func (e *encoder) textureDefinitions() *node {
+	defs := &node{kind: nodeObject}
+	done := map[string]bool{}
+	var define func(name string)
+	define = func(name string) {
+		if done[name] {
+			return
+		}
+		done[name] = true
+		body := e.textureBody(e.textureByName[name], define)
+		defs.set(name, body)
+	}
+	names := sortedKeys(e.textureByName)
+	for i := 0; i < len(names); i++ {
+		define(names[i])
+	}
+	return defs
+}
+
+// texture return the name of a named texture, its definition otherwise
+func (e *encoder) texture(t geom.Texture, define func(name string)) *node {
+	if name, ok := e.textureNames[t]; ok {
+		if define != nil {
+			define(name)
+		}
+		return stringNode(name)
+	}
+	return e.textureBody(t, define)
+}
+
+// textureBody return the definition of a texture, define being called with
+// the names of the textures it uses before they are referred to
+func (e *encoder) textureBody(t geom.Texture, define func(name string)) *node {
+	n := &node{kind: nodeObject}
+	switch tex := t.(type) {
+	case *geom.ConstantTexture:
+		return e.vec(tex.Color)
+	case geom.ConstantTexture:
+		return e.vec(tex.Color)
+	case *geom.CheckerTexture:
+		return e.textureBody(*tex, define)
+	case geom.CheckerTexture:
+		n.set("type", stringNode("checker"))
+		n.set("even", e.texture(tex.Even, define))
+		n.set("odd", e.texture(tex.Odd, define))
+	case *geom.NoiseTexture:
+		return e.textureBody(*tex, define)
+	case geom.NoiseTexture:
+		n.set("type", stringNode("noise"))
+		n.set("scale", e.number(tex.Scale))
+		n.set("seed", &node{kind: nodeNumber, text: strconv.FormatUint(tex.Seed, 10)})
+	default:
+		e.failTexture(t)
+	}
+	return n
+}
+
+// material return the name of a named material, its definition otherwise
+func (e *encoder) material(m geom.Material) *node {
+	if name, ok := e.materialNames[m]; ok {
+		return stringNode(name)
+	}
+	return e.materialBody(m)
+}
+
+func (e *encoder) materialBody(m geom.Material) *node {
+	n := &node{kind: nodeObject}
+	switch mat := m.(type) {
+	case *geom.Lambertian:
+		return e.materialBody(*mat)
+	case geom.Lambertian:
+		n.set("type", stringNode("lambertian"))
+		n.set("albedo", e.texture(mat.Albedo, nil))
+	case *geom.Metal:
+		return e.materialBody(*mat)
+	case geom.Metal:
+		n.set("type", stringNode("metal"))
+		n.set("albedo", e.vec(mat.Albedo))
+		if mat.Fuzz != 0 {
+			n.set("fuzz", e.number(mat.Fuzz))
+		}
+	case *geom.Dielectric:
+		return e.materialBody(*mat)
+	case geom.Dielectric:
+		n.set("type", stringNode("dielectric"))
+		n.set("refIdx", e.number(mat.RefIdx))
+	case *geom.DiffuseLight:
+		return e.materialBody(*mat)
+	case geom.DiffuseLight:
+		n.set("type", stringNode("diffuseLight"))
+		n.set("emit", e.texture(mat.Emit, nil))
+	default:
+		if m == nil || !geom.IsNoMaterial(m) {
+			e.fail("can not write materials of type %T", m)
+		}
+		n.set("type", stringNode("none"))
+	}
+	return n
+}
+
+func (e *encoder) hitables(list []geom.Hitable, lights bool) *node {
+	n := &node{kind: nodeArray}
+	for i := 0; i < len(list); i++ {
+		n.values = append(n.values, e.hitable(list[i], lights))
+	}
+	return n
+}
+
+// hitable return the node of an object, the material of the shapes of the
+// lights being left out when they have none
+func (e *encoder) hitable(h geom.Hitable, lights bool) *node {
+	n := &node{kind: nodeObject}
+	setMaterial := func(m geom.Material) {
+		if !(lights && geom.IsNoMaterial(m)) {
+			n.set("material", e.material(m))
+		}
+	}
+	switch obj := h.(type) {
+	case *geom.Sphere:
+		n.set("type", stringNode("sphere"))
+		n.set("center", e.vec(obj.Center))
+		n.set("radius", e.number(obj.Radius))
+		setMaterial(obj.Mat)
+	case *geom.MovingSphere:
+		n.set("type", stringNode("movingSphere"))
+		n.set("center0", e.vec(obj.Center0))
+		n.set("center1", e.vec(obj.Center1))
+		n.set("radius", e.number(obj.Radius))
+		n.set("time0", e.number(obj.Time0))
+		n.set("time1", e.number(obj.Time1))
+		setMaterial(obj.Mat)
+	case *geom.XYRect:
+		n.set("type", stringNode("xyRect"))
+		e.setNumbers(n, "x0", obj.X0, "x1", obj.X1, "y0", obj.Y0, "y1", obj.Y1, "k", obj.K)
+		setMaterial(obj.Mat)
+	case *geom.XZRect:
+		n.set("type", stringNode("xzRect"))
+		e.setNumbers(n, "x0", obj.X0, "x1", obj.X1, "z0", obj.Z0, "z1", obj.Z1, "k", obj.K)
+		setMaterial(obj.Mat)
+	case *geom.YZRect:
+		n.set("type", stringNode("yzRect"))
+		e.setNumbers(n, "y0", obj.Y0, "y1", obj.Y1, "z0", obj.Z0, "z1", obj.Z1, "k", obj.K)
+		setMaterial(obj.Mat)
+	case *geom.Box:
+		n.set("type", stringNode("box"))
+		n.set("min", e.vec(obj.PMin))
+		n.set("max", e.vec(obj.PMax))
+		setMaterial(obj.Mat)
+	case *geom.Triangle:
+		n.set("type", stringNode("triangle"))
+		n.set("p0", e.vec(obj.P0))
+		n.set("p1", e.vec(obj.P1))
+		n.set("p2", e.vec(obj.P2))
+		if obj.HasNormals {
+			n.set("normals", &node{kind: nodeArray, values: []*node{e.vec(obj.N0), e.vec(obj.N1), e.vec(obj.N2)}})
+		}
+		if obj.HasUVs {
+			n.set("uvs", &node{kind: nodeArray, values: []*node{e.uv(obj.UV0), e.uv(obj.UV1), e.uv(obj.UV2)}})
+		}
+		setMaterial(obj.Mat)
+	case *geom.TriangleMesh:
+		n.set("type", stringNode("triangleMesh"))
+		n.set("positions", e.vecs(obj.Positions, e.vec))
+		if obj.Normals != nil {
+			n.set("normals", e.vecs(obj.Normals, e.vec))
+		}
+		if obj.UVs != nil {
+			n.set("uvs", e.vecs(obj.UVs, e.uv))
+		}
+		triangles := &node{kind: nodeArray}
+		for i := 0; i+2 < len(obj.Indices); i += 3 {
+			triangles.values = append(triangles.values, &node{kind: nodeArray, values: []*node{intNode(obj.Indices[i]), intNode(obj.Indices[i+1]), intNode(obj.Indices[i+2])}})
+		}
+		n.set("triangles", triangles)
+		if obj.MaterialIndices == nil {
+			setMaterial(obj.Materials[0])
+			break
+		}
+		materials := &node{kind: nodeArray}
+		for i := 0; i < len(obj.Materials); i++ {
+			materials.values = append(materials.values, e.material(obj.Materials[i]))
+		}
+		n.set("materials", materials)
+		indices := &node{kind: nodeArray}
+		for i := 0; i < len(obj.MaterialIndices); i++ {
+			indices.values = append(indices.values, intNode(obj.MaterialIndices[i]))
+		}
+		n.set("materialIndices", indices)
+	case *Mesh:
+		n.set("type", stringNode("mesh"))
+		n.set("file", stringNode(filepath.ToSlash(obj.File)))
+		opts := obj.Options
+		if len(opts.Groups) > 0 {
+			groups := &node{kind: nodeArray}
+			for i := 0; i < len(opts.Groups); i++ {
+				groups.values = append(groups.values, stringNode(opts.Groups[i]))
+			}
+			n.set("groups", groups)
+		}
+		if opts.Points > 0 {
+			n.set("points", e.number(opts.Points))
+		}
+		if opts.Scale != 0 && opts.Scale != 1 {
+			n.set("scale", e.number(opts.Scale))
+		}
+		if opts.OnFloor {
+			n.set("floor", e.number(opts.Floor))
+		}
+		if opts.Emissive {
+			n.set("emissive", boolNode(true))
+		}
+		if opts.Mat != nil {
+			n.set("material", e.material(opts.Mat))
+		}
+	case *geom.Translate:
+		n.set("type", stringNode("translate"))
+		n.set("offset", e.vec(obj.Offset))
+		n.set("object", e.hitable(obj.Ptr, lights))
+	case *geom.RotateY:
+		n.set("type", stringNode("rotateY"))
+		n.set("angle", e.number(obj.Angle))
+		n.set("object", e.hitable(obj.Ptr, lights))
+	case *geom.Transform:
+		// the transforms composed are written as their matrix
+		rows := &node{kind: nodeArray}
+		for i := 0; i < 4; i++ {
+			row := &node{kind: nodeArray}
+			for j := 0; j < 4; j++ {
+				row.values = append(row.values, e.number(obj.Matrix[i][j]))
+			}
+			rows.values = append(rows.values, row)
+		}
+		matrix := &node{kind: nodeObject}
+		matrix.set("type", stringNode("matrix"))
+		matrix.set("rows", rows)
+		n.set("type", stringNode("transform"))
+		n.set("transforms", &node{kind: nodeArray, values: []*node{matrix}})
+		n.set("object", e.hitable(obj.Ptr, lights))
+	case *geom.FlipNormals:
+		n.set("type", stringNode("flip"))
+		n.set("object", e.hitable(obj.Ptr, lights))
+	case *geom.Tagged:
+		// the IDs are keys of the object tagged
+		n = e.hitable(obj.Ptr, lights)
+		if obj.ObjectID != 0 {
+			n.set("objectID", intNode(obj.ObjectID))
+		}
+		if obj.MaterialID != 0 {
+			n.set("materialID", intNode(obj.MaterialID))
+		}
+	case *geom.HitableList:
+		n.set("type", stringNode("list"))
+		n.set("objects", e.hitables(elements(obj), lights))
+	case *geom.LinearBVH:
+		n.set("type", stringNode("bvh"))
+		n.set("objects", e.hitables(e.children(h), lights))
+		opts := obj.Options()
+		if opts.Strategy == geom.BVHMedian {
+			n.set("strategy", stringNode("median"))
+		}
+		if opts.LeafSize > 1 {
+			n.set("leafSize", intNode(opts.LeafSize))
+		}
+	case *geom.BVHNode:
+		// NewBVHNode splits the objects in halves
+		n.set("type", stringNode("bvh"))
+		n.set("objects", e.hitables(e.children(h), lights))
+		n.set("strategy", stringNode("median"))
+	default:
+		e.fail("can not write objects of type %T", h)
+	}
+	return n
+}
+
+// setNumbers set keys to numbers, given as key, value, key, value...
+func (e *encoder) setNumbers(n *node, kv ...interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		n.set(kv[i].(string), e.number(kv[i+1].(float64)))
+	}
+}
+
+// number return the node of a number, written with the fewest digits that
+// read back to the same value
+func (e *encoder) number(v float64) *node {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		e.fail("can not write %v in JSON", v)
+	}
+	return &node{kind: nodeNumber, text: strconv.FormatFloat(v, 'g', -1, 64)}
+}
+
+func (e *encoder) vec(v geom.Vec3) *node {
+	return &node{kind: nodeArray, values: []*node{e.number(v.X()), e.number(v.Y()), e.number(v.Z())}}
+}
+
+// vecs return the array of the nodes of vectors
+func (e *encoder) vecs(v []geom.Vec3, item func(geom.Vec3) *node) *node {
+	n := &node{kind: nodeArray, values: make([]*node, len(v))}
+	for i := 0; i < len(v); i++ {
+		n.values[i] = item(v[i])
+	}
+	return n
+}
+
+// uv return the node [u, v] of texture coordinates
+func (e *encoder) uv(v geom.Vec3) *node {
+	return &node{kind: nodeArray, values: []*node{e.number(v.X()), e.number(v.Y())}}
+}
+
+func intNode(v int) *node {
+	return &node{kind: nodeNumber, text: strconv.Itoa(v)}
+}
+
+func boolNode(b bool) *node {
+	return &node{kind: nodeBool, boolean: b}
+}
+
+func stringNode(s string) *node {
+	return &node{kind: nodeString, text: s}
+}
+
+// set set the value of a key of an object node, adding the key at the end if
+// it is new
+func (n *node) set(key string, value *node) {
+	for i := 0; i < len(n.keys); i++ {
+		if n.keys[i] == key {
+			n.values[i] = value
+			return
+		}
+	}
+	n.keys = append(n.keys, key)
+	n.values = append(n.values, value)
+}
+
+// writeNode write a node as indented JSON, the values short enough being
+// written on a single line
+func writeNode(buf *bytes.Buffer, n *node, indent string) {
+	compact := compactNode(n)
+	if len(indent)+len(compact) <= maxLineLength || (n.kind != nodeObject && n.kind != nodeArray) || len(n.values) == 0 {
+		buf.WriteString(compact)
+		return
+	}
+	open, close := "[", "]"
+	if n.kind == nodeObject {
+		open, close = "{", "}"
+	}
+	buf.WriteString(open + "\n")
+	inner := indent + "  "
+	for i := 0; i < len(n.values); i++ {
+		buf.WriteString(inner)
+		if n.kind == nodeObject {
+			buf.WriteString(quote(n.keys[i]) + ": ")
+		}
+		writeNode(buf, n.values[i], inner)
+		if i+1 < len(n.values) {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(indent + close)
+}
+
+// compactNode return a node written on a single line
+func compactNode(n *node) string {
+	switch n.kind {
+	case nodeNull:
+		return "null"
+	case nodeBool:
+		return strconv.FormatBool(n.boolean)
+	case nodeNumber:
+		return n.text
+	case nodeString:
+		return quote(n.text)
+	}
+	var buf bytes.Buffer
+	if n.kind == nodeObject {
+		buf.WriteByte('{')
+	} else {
+		buf.WriteByte('[')
+	}
+	for i := 0; i < len(n.values); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if n.kind == nodeObject {
+			buf.WriteString(quote(n.keys[i]) + ": ")
+		}
+		buf.WriteString(compactNode(n.values[i]))
+	}
+	if n.kind == nodeObject {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.String()
+}
+
+// quote return a string as a JSON string
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,77 @@
+package scenes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	geom "github.com/AureClai/RayTracingGoTest/geometry"
+)
+
+// roundTrip write a file, read it back and write it again, the two written
+// files having to be the same
+func roundTrip(t *testing.T, f *File) {
+	t.Helper()
+	var first, second bytes.Buffer
+	if err := Write(&first, f); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(bytes.NewReader(first.Bytes()), "first.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&second, g); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("the file changes when it is written again :\n%s\n---\n%s", first.Bytes(), second.Bytes())
+	}
+}
+
+func TestWriteCornell(t *testing.T) {
+	f, err := LoadFile("data/cornell.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, f)
+}
+
+func TestWriteTransform(t *testing.T) {
+	f, err := loadTestScene(`{"type": "translate", "offset": [1, 2, 3], "object":
+      {"type": "rotateY", "angle": 15, "object":
+        {"type": "transform", "object": ` + testSphere + `,
+          "transforms": [{"type": "rotate", "axis": [1, 1, 0], "angle": 30}, {"type": "scale", "factors": [1, 2, 3]}]}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, f)
+}
+
+func TestWriteMaterials(t *testing.T) {
+	checker := `{"type": "lambertian", "albedo": {"type": "checker", "even": [0.1, 0.1, 0.1],
+      "odd": {"type": "noise", "scale": 4, "seed": 7}}}`
+	f, err := loadTestScene(`{"type": "movingSphere", "center0": [0, 0, 0], "center1": [0, 1, 0], "radius": 1,
+        "time0": 0, "time1": 1, "material": ` + checker + `},
+      {"type": "sphere", "center": [2, 0, 0], "radius": 1, "material": {"type": "metal", "albedo": [0.8, 0.6, 0.2], "fuzz": 0.3}},
+      {"type": "sphere", "center": [4, 0, 0], "radius": 1, "material": {"type": "dielectric", "refIdx": 1.5}},
+      {"type": "sphere", "center": [6, 0, 0], "radius": 1, "material": {"type": "lambertian",
+        "albedo": {"type": "noise", "scale": 0.5}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, f)
+}
+
+func TestWriteImageTexture(t *testing.T) {
+	f, err := loadTestScene(testSphere)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := geom.NewImageTexture(1, 1, []geom.Vec3{geom.NewVec3(1, 0, 0)})
+	checker := geom.NewCheckerTexture(geom.NewConstantTexture(geom.NewVec3(0, 0, 0)), image)
+	f.Objects = append(f.Objects, geom.NewSphere(geom.NewVec3(2, 0, 0), 1, geom.Lambertian{Albedo: checker}))
+	err = Write(&bytes.Buffer{}, f)
+	if err == nil || !strings.Contains(err.Error(), "object 2 : can not write image textures") {
+		t.Errorf("an image texture is written with the error %v", err)
+	}
+}
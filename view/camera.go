@@ -2,17 +2,16 @@ package view
 
 import (
 	"math"
-	"math/rand"
 
-	g "../geometry"
+	g "github.com/AureClai/RayTracingGoTest/geometry"
 )
 
-func randomInUnitDisk() *g.Vec3 {
-	var p = new(g.Vec3)
+func randomInUnitDisk(smp *g.Sampler) g.Vec3 {
+	var p g.Vec3
 
 	for {
-		p = g.NewVec3(2*(rand.Float64()-0.5), 2*(rand.Float64()-0.5), 2*(rand.Float64()-0.5))
-		if g.Dot(p, p) >= 1 {
+		p = g.NewVec3(2*(smp.Float64()-0.5), 2*(smp.Float64()-0.5), 0)
+		if g.Dot(p, p) < 1 {
 			break
 		}
 	}
@@ -21,19 +20,19 @@ func randomInUnitDisk() *g.Vec3 {
 
 // Camera is a struct that modelize the camera of the scene
 type Camera struct {
-	Origin          *g.Vec3
-	Horizontal      *g.Vec3
-	Vertical        *g.Vec3
-	LowerLeftCorner *g.Vec3
-	U               *g.Vec3
-	V               *g.Vec3
-	W               *g.Vec3
+	Origin          g.Vec3
+	Horizontal      g.Vec3
+	Vertical        g.Vec3
+	LowerLeftCorner g.Vec3
+	U               g.Vec3
+	V               g.Vec3
+	W               g.Vec3
 	LensRadius      float64
 	Time0           float64
 	Time1           float64
 }
 
-func NewCamera(lookFrom, lookAt, vup *g.Vec3, vfov, aspect, aperture, focusDist, t0, t1 float64) *Camera {
+func NewCamera(lookFrom, lookAt, vup g.Vec3, vfov, aspect, aperture, focusDist, t0, t1 float64) *Camera {
 	lensRadius := aperture / 2
 	theta := vfov * math.Pi / 180
 	halfHeight := math.Tan(theta / 2)
@@ -59,9 +58,11 @@ func NewCamera(lookFrom, lookAt, vup *g.Vec3, vfov, aspect, aperture, focusDist,
 	}
 }
 
-func (cam *Camera) GetRay(s, t float64) *g.Ray {
-	var rd = randomInUnitDisk().TimesScalar(cam.LensRadius)
+// GetRay return the ray going through the point (s, t) of the screen, s and t
+// between 0 and 1, the lens and shutter being sampled with smp
+func (cam *Camera) GetRay(s, t float64, smp *g.Sampler) g.Ray {
+	var rd = randomInUnitDisk(smp).TimesScalar(cam.LensRadius)
 	var offset = cam.U.TimesScalar(rd.X()).Plus(cam.V.TimesScalar(rd.Y()))
-	var time = cam.Time0 + rand.Float64()*(cam.Time1-cam.Time0)
+	var time = cam.Time0 + smp.Float64()*(cam.Time1-cam.Time0)
 	return g.NewRayWithTime(cam.Origin.Plus(offset), cam.LowerLeftCorner.Plus(cam.Horizontal.TimesScalar(s)).Plus(cam.Vertical.TimesScalar(t)).Minus(cam.Origin).Minus(offset), time)
 }